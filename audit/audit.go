@@ -0,0 +1,51 @@
+// This file contains the tamper-evident audit log of account changes. Each
+// record carries the hash of the record before it, so changing or deleting
+// a record breaks the chain from that point on.
+package audit
+
+import "time"
+
+// GenesisHash is the previous hash of the first record
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Redacted replaces the values of secret fields in changes, the change
+// itself is still recorded.
+const Redacted = "[redacted]"
+
+// secretFields never have their values recorded
+var secretFields = map[string]bool{
+	"password": true,
+}
+
+type Record struct {
+	Id int
+	// ActorId is the user who made the change, zero for anonymous
+	// requests like registrations
+	ActorId int
+	// SubjectId is the user changed, zero for actions on no particular
+	// user
+	SubjectId int
+	Action    string
+	Changes   []Change
+	RequestId string
+	IpAddress string
+	CreatedAt time.Time
+	PrevHash  string
+	Hash      string
+}
+
+// Change of a field, an empty value is an unset field
+type Change struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type Verifier struct {
+	prevHash string
+	verified int
+}
+
+func NewVerifier() *Verifier {
+	return &Verifier{prevHash: GenesisHash}
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Diff lists the fields whose value changed, sorted by field. Fields only
+// in one of the maps changed from or to an empty value.
+func Diff(before map[string]string, after map[string]string) []Change {
+	fields := map[string]bool{}
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	var changes []Change
+	for field := range fields {
+		if before[field] == after[field] {
+			continue
+		}
+		change := Change{Field: field, Before: before[field], After: after[field]}
+		if secretFields[field] {
+			change.Before, change.After = Redacted, Redacted
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// hashedRecord is the canonical form of a record, the id is left out as it
+// is only known after the insert.
+type hashedRecord struct {
+	PrevHash  string   `json:"prevHash"`
+	ActorId   int      `json:"actorId"`
+	SubjectId int      `json:"subjectId"`
+	Action    string   `json:"action"`
+	Changes   []Change `json:"changes"`
+	RequestId string   `json:"requestId"`
+	IpAddress string   `json:"ipAddress"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// Hash chains the record to the hash of the previous record. CreatedAt is
+// hashed in microseconds, the precision it is stored with.
+func Hash(prevHash string, record Record) string {
+	changes := record.Changes
+	if changes == nil {
+		changes = []Change{}
+	}
+	data, _ := json.Marshal(hashedRecord{
+		PrevHash:  prevHash,
+		ActorId:   record.ActorId,
+		SubjectId: record.SubjectId,
+		Action:    record.Action,
+		Changes:   changes,
+		RequestId: record.RequestId,
+		IpAddress: record.IpAddress,
+		CreatedAt: record.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify checks the next record of the chain, records must be verified in
+// the order they were written.
+func (v *Verifier) Verify(record Record) error {
+	if record.PrevHash != v.prevHash {
+		return fmt.Errorf("record %d: previous hash %s doesn't match %s, a record before was changed or deleted", record.Id, record.PrevHash, v.prevHash)
+	}
+	if hash := Hash(record.PrevHash, record); hash != record.Hash {
+		return fmt.Errorf("record %d: hash %s doesn't match its content %s, the record was changed", record.Id, record.Hash, hash)
+	}
+	v.prevHash = record.Hash
+	v.verified++
+	return nil
+}
+
+func (v *Verifier) Verified() int {
+	return v.verified
+}
+
+// LastHash is the hash of the last verified record. Deleting the latest
+// records can only be detected by comparing it with a copy kept elsewhere.
+func (v *Verifier) LastHash() string {
+	return v.prevHash
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	changes := Diff(
+		map[string]string{"full_name": "John", "phone_number": "+628123456789", "password": "old"},
+		map[string]string{"full_name": "John Doe", "phone_number": "+628123456789", "password": "new", "status": "active"},
+	)
+	expected := []Change{
+		{Field: "full_name", Before: "John", After: "John Doe"},
+		{Field: "password", Before: Redacted, After: Redacted},
+		{Field: "status", Before: "", After: "active"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, got %v", expected, changes)
+	}
+
+	if changes := Diff(map[string]string{"a": "1"}, map[string]string{"a": "1"}); changes != nil {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.UTC)
+	chain := func() []Record {
+		records := []Record{
+			{Id: 1, SubjectId: 1, Action: "user.create", CreatedAt: createdAt},
+			{Id: 2, ActorId: 1, SubjectId: 1, Action: "user.update", Changes: []Change{{Field: "full_name", Before: "John", After: "John Doe"}}, CreatedAt: createdAt},
+			{Id: 3, ActorId: 1, SubjectId: 1, Action: "user.login", RequestId: "abc", IpAddress: "192.0.2.1", CreatedAt: createdAt},
+		}
+		prevHash := GenesisHash
+		for i := range records {
+			records[i].PrevHash = prevHash
+			records[i].Hash = Hash(prevHash, records[i])
+			prevHash = records[i].Hash
+		}
+		return records
+	}
+
+	tests := []struct {
+		caseName         string
+		tamper           func(records []Record) []Record
+		expectedVerified int
+	}{
+		{
+			caseName:         "Intact chain",
+			tamper:           func(records []Record) []Record { return records },
+			expectedVerified: 3,
+		},
+		{
+			caseName: "Changed record",
+			tamper: func(records []Record) []Record {
+				records[1].Changes[0].After = "Jane Doe"
+				return records
+			},
+			expectedVerified: 1,
+		},
+		{
+			caseName: "Deleted record",
+			tamper: func(records []Record) []Record {
+				return append(records[:1], records[2:]...)
+			},
+			expectedVerified: 1,
+		},
+		{
+			caseName: "Rehashed record",
+			tamper: func(records []Record) []Record {
+				records[0].ActorId = 2
+				records[0].Hash = Hash(records[0].PrevHash, records[0])
+				return records
+			},
+			expectedVerified: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			v := NewVerifier()
+			for _, record := range test.tamper(chain()) {
+				if err := v.Verify(record); err != nil {
+					break
+				}
+			}
+			if v.Verified() != test.expectedVerified {
+				t.Errorf("Expected %d verified records, got %d", test.expectedVerified, v.Verified())
+			}
+		})
+	}
+}
+
+func TestHashPrecision(t *testing.T) {
+	// Postgres keeps microseconds, the hash must survive the round trip
+	record := Record{Action: "user.create", CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.UTC)}
+	stored := record
+	stored.CreatedAt = record.CreatedAt.Truncate(time.Microsecond).In(time.FixedZone("WIB", 7*60*60))
+	if Hash(GenesisHash, record) != Hash(GenesisHash, stored) {
+		t.Errorf("Expected the same hash after storing")
+	}
+}
@@ -0,0 +1,10 @@
+// This file contains the interfaces for the audit layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package audit
+
+type VerifierInterface interface {
+	Verify(record Record) error
+	Verified() int
+	LastHash() string
+}
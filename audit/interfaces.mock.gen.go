@@ -0,0 +1,76 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: audit/interfaces.go
+
+// Package audit is a generated GoMock package.
+package audit
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockVerifierInterface is a mock of VerifierInterface interface.
+type MockVerifierInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockVerifierInterfaceMockRecorder
+}
+
+// MockVerifierInterfaceMockRecorder is the mock recorder for MockVerifierInterface.
+type MockVerifierInterfaceMockRecorder struct {
+	mock *MockVerifierInterface
+}
+
+// NewMockVerifierInterface creates a new mock instance.
+func NewMockVerifierInterface(ctrl *gomock.Controller) *MockVerifierInterface {
+	mock := &MockVerifierInterface{ctrl: ctrl}
+	mock.recorder = &MockVerifierInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVerifierInterface) EXPECT() *MockVerifierInterfaceMockRecorder {
+	return m.recorder
+}
+
+// LastHash mocks base method.
+func (m *MockVerifierInterface) LastHash() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastHash")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// LastHash indicates an expected call of LastHash.
+func (mr *MockVerifierInterfaceMockRecorder) LastHash() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastHash", reflect.TypeOf((*MockVerifierInterface)(nil).LastHash))
+}
+
+// Verified mocks base method.
+func (m *MockVerifierInterface) Verified() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verified")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Verified indicates an expected call of Verified.
+func (mr *MockVerifierInterfaceMockRecorder) Verified() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verified", reflect.TypeOf((*MockVerifierInterface)(nil).Verified))
+}
+
+// Verify mocks base method.
+func (m *MockVerifierInterface) Verify(record Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockVerifierInterfaceMockRecorder) Verify(record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockVerifierInterface)(nil).Verify), record)
+}
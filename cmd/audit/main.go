@@ -0,0 +1,87 @@
+// Command audit checks the audit log offline.
+//
+//	audit verify [-config path] [-anchor hash]
+//
+// verify walks the hash chain from the first entry and exits with status 1
+// at the first entry which was changed, or when an entry before it was
+// deleted. The hash of the last entry is printed, keep it outside the
+// database and pass it as the anchor of the next run to detect deleted
+// entries at the end of the log too. The database is configured like the
+// service, by the file of -config or CONFIG_PATH and the environment.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/asrul10/UserService/audit"
+	"github.com/asrul10/UserService/config"
+	"github.com/asrul10/UserService/repository"
+)
+
+const batchSize = 1000
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "verify" {
+		fmt.Fprintln(os.Stderr, "usage: audit verify [-config path] [-anchor hash]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	configPath := flags.String("config", os.Getenv("CONFIG_PATH"), "path of the YAML or TOML configuration file")
+	anchor := flags.String("anchor", "", "hash of an entry verified before, which must still be in the log")
+	flags.Parse(os.Args[2:])
+
+	cfg, err := config.Load(config.LoadOptions{Path: *configPath})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load config:", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid config:", err)
+		os.Exit(1)
+	}
+
+	repo := repository.NewRepository(cfg.Repository.RepositoryOptions())
+	if err := verify(context.Background(), repo, *anchor); err != nil {
+		fmt.Fprintln(os.Stderr, "Audit log verification failed:", err)
+		os.Exit(1)
+	}
+}
+
+func verify(ctx context.Context, repo repository.RepositoryInterface, anchor string) error {
+	verifier := audit.NewVerifier()
+	anchorFound := anchor == ""
+
+	afterId := 0
+	for {
+		resp, err := repo.GetAuditRecords(ctx, repository.GetAuditRecordsInput{
+			AfterId: afterId,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, record := range resp.Records {
+			if err := verifier.Verify(record); err != nil {
+				return err
+			}
+			if record.Hash == anchor {
+				anchorFound = true
+			}
+			afterId = record.Id
+		}
+		if len(resp.Records) < batchSize {
+			break
+		}
+	}
+
+	if !anchorFound {
+		return fmt.Errorf("anchor %s not found after %d entries, entries at the end of the log were deleted", anchor, verifier.Verified())
+	}
+	fmt.Printf("Verified %d entries, last hash %s\n", verifier.Verified(), verifier.LastHash())
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/asrul10/UserService/config"
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `Usage: main [-config path] [command]
+
+Without a command the service is started.
+
+Commands:
+  config print [--redacted]    print the effective configuration as YAML
+  phones normalize [-dry-run]  rewrite the stored phone numbers to E.164
+`
+
+// runCommand runs the command of args instead of the service
+func runCommand(cfg config.Config, args []string) {
+	switch {
+	case len(args) >= 2 && args[0] == "config" && args[1] == "print":
+		printConfig(cfg, args[2:])
+	case len(args) >= 2 && args[0] == "phones" && args[1] == "normalize":
+		normalizePhones(cfg, args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func printConfig(cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("config print", flag.ExitOnError)
+	redact := flags.Bool("redacted", false, "replace the secrets with REDACTED")
+	flags.Parse(args)
+
+	if *redact {
+		cfg = cfg.Redacted()
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		fatal("Failed to print config", err)
+	}
+}
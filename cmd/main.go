@@ -1,42 +1,321 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
+	"github.com/asrul10/UserService/config"
+	"github.com/asrul10/UserService/encryption"
+	"github.com/asrul10/UserService/events"
 	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/geoip"
 	"github.com/asrul10/UserService/handler"
 	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/logging"
+	"github.com/asrul10/UserService/metrics"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/outbox"
+	"github.com/asrul10/UserService/password"
+	"github.com/asrul10/UserService/phone"
 	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/retention"
+	"github.com/asrul10/UserService/risk"
+	"github.com/asrul10/UserService/totp"
+	"github.com/asrul10/UserService/tracing"
+	"github.com/asrul10/UserService/webauthn"
+	"github.com/asrul10/UserService/webhook"
 
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func main() {
+	// Settings of the file are overridden by the environment variables
+	configPath := flag.String("config", os.Getenv("CONFIG_PATH"), "path of the YAML or TOML configuration file")
+	flag.Parse()
+	cfg, err := config.Load(config.LoadOptions{Path: *configPath})
+	if err != nil {
+		fatal("Failed to load config", err)
+	}
+	if flag.NArg() > 0 {
+		runCommand(cfg, flag.Args())
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("Invalid config", err)
+	}
+
+	// Records below the level (debug, info, warn or error) are dropped
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+		fatal("Failed to parse log level", err)
+	}
+	logger := logging.NewLogger(logging.NewLoggerOptions{Level: level})
+	slog.SetDefault(logger)
+
+	// Spans are exported to stdout, a file, an OTLP collector or dropped
+	tracerProvider, err := tracing.NewTracerProvider(context.Background(), tracing.NewTracerProviderOptions{
+		Exporter:     cfg.Tracing.Exporter,
+		FilePath:     cfg.Tracing.FilePath,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+	})
+	if err != nil {
+		fatal("Failed to create trace exporter", err)
+	}
+	// Workers and the server stop on SIGTERM or SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tracer := tracerProvider.Tracer(tracing.TracerName)
+
 	e := echo.New()
+	e.Server.ReadTimeout = cfg.Server.ReadTimeout
+	e.Server.WriteTimeout = cfg.Server.WriteTimeout
+	e.Server.IdleTimeout = cfg.Server.IdleTimeout
+
+	var workers sync.WaitGroup
+	server, repo := newServer(ctx, &workers, e, cfg, logger, tracer)
+
+	// Audit entries are correlated with the request which wrote them
+	e.Use(handler.RequestID())
 
-	var server generated.ServerInterface = newServer(e)
+	// Operations of api.yml declare the permissions they require
+	swagger, err := generated.GetSwagger()
+	if err != nil {
+		fatal("Failed to load API spec", err)
+	}
+	// Requests are traced and observed by the operationId of the spec
+	e.Use(server.Trace(swagger))
+	e.Use(server.RecordMetrics(swagger))
+
+	permissions, err := server.RequirePermissions(swagger)
+	if err != nil {
+		fatal("Failed to load operation permissions", err)
+	}
+	e.Use(permissions)
 
 	generated.RegisterHandlers(e, server)
-	e.Logger.Fatal(e.Start(":1323"))
-}
+	e.GET("/metrics", echo.WrapHandler(server.Metrics.Handler()))
+
+	go func() {
+		if err := e.Start(fmt.Sprintf(":%d", cfg.Server.Port)); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("Failed to start the server", err)
+		}
+	}()
+	<-ctx.Done()
+	stop()
 
-func newServer(e *echo.Echo) *handler.Server {
-	dbDsn := os.Getenv("DATABASE_URL")
-	jwtPrivateKeyPath := os.Getenv("JWT_PRIVATE_KEY_PATH")
-	jwtPublicKeyPath := os.Getenv("JWT_PUBLIC_KEY_PATH")
+	// New connections are refused while the requests in flight, the
+	// workers and the spans left are given the shutdown timeout to finish
+	logger.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to drain the requests", "error", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		logger.Error("Failed to stop the workers", "error", shutdownCtx.Err())
+	}
+	if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to flush the spans", "error", err)
+	}
+	if err := repo.Close(); err != nil {
+		logger.Error("Failed to close the database", "error", err)
+	}
+}
 
-	var repo repository.RepositoryInterface = repository.NewRepository(repository.NewRepositoryOptions{
-		Dsn: dbDsn,
+// newServer starts the background workers in workers, they stop when ctx
+// is done. The repository is returned to be closed after them.
+func newServer(ctx context.Context, workers *sync.WaitGroup, e *echo.Echo, cfg config.Config, logger *slog.Logger, tracer trace.Tracer) (*handler.Server, *repository.Repository) {
+	db := newRepository(cfg, logger, tracer)
+	var repo repository.RepositoryInterface = db
+	var m metrics.MetricsInterface = metrics.NewMetrics(metrics.NewMetricsOptions{
+		Db: db.Db,
 	})
 	var helper helper.HelperInterface = helper.NewHelper(helper.NewHelperOptions{
-		JwtPrivateKeyPath: jwtPrivateKeyPath,
-		JwtPublicKeyPath:  jwtPublicKeyPath,
+		JwtPrivateKeyPath: cfg.Helper.JwtPrivateKeyPath,
+		JwtPublicKeyPath:  cfg.Helper.JwtPublicKeyPath,
+		AccessTokenTTL:    cfg.Helper.AccessTokenTTL,
+		RefreshTokenTTL:   cfg.Helper.RefreshTokenTTL,
+		MfaTokenTTL:       cfg.Helper.MfaTokenTTL,
+		Logger:            logger,
+		Metrics:           m,
+		Tracer:            tracer,
 	})
 
-	opts := handler.NewServerOptions{
+	var estimator password.EstimatorInterface = password.NewEstimator(password.NewEstimatorOptions{})
+	var policy password.PolicyInterface = password.NewPolicy(password.NewPolicyOptions{
+		Config:    password.PolicyConfig(cfg.Handler.Password.Policy),
+		Estimator: estimator,
+	})
+
+	blocklist, err := password.NewBlocklist(password.NewBlocklistOptions{
+		Path: cfg.Handler.Password.BlocklistPath,
+	})
+	if err != nil {
+		fatal("Failed to load password blocklist", err)
+	}
+
+	phoneParser, err := phone.NewParser(phone.NewParserOptions{
+		DefaultRegion:  cfg.Handler.Phone.DefaultRegion,
+		AllowedRegions: cfg.Handler.Phone.AllowedRegions,
+	})
+	if err != nil {
+		fatal("Failed to load phone metadata", err)
+	}
+
+	var pruner retention.PrunerInterface = retention.NewPruner(retention.NewPrunerOptions{
+		Repository:          repo,
+		Logger:              logger,
+		LoginEventRetention: cfg.Retention.LoginEventRetention,
+	})
+	runWorker(ctx, workers, pruner.Run)
+
+	// Without a GeoIP database logins are still compared by device and
+	// network, only impossible travel is not detected
+	geoipDatabase, err := geoip.NewDatabase(geoip.NewDatabaseOptions{
+		Path: cfg.Handler.GeoIP.DatabasePath,
+	})
+	if err != nil {
+		fatal("Failed to load GeoIP database", err)
+	}
+	var riskAssessor risk.AssessorInterface = risk.NewAssessor(risk.NewAssessorOptions{
+		GeoIP: geoipDatabase,
+	})
+
+	secretCipher, err := encryption.NewCipher(encryption.NewCipherOptions{
+		Key: cfg.Handler.SecretEncryptionKey,
+	})
+	if err != nil {
+		fatal("Failed to load secret encryption key", err)
+	}
+
+	var publisher events.EventPublisherInterface
+	switch cfg.Events.Publisher {
+	case "", "stdout":
+		publisher, err = events.NewWriterPublisher(events.NewWriterPublisherOptions{})
+	case "file":
+		publisher, err = events.NewWriterPublisher(events.NewWriterPublisherOptions{
+			Path: cfg.Events.FilePath,
+		})
+	case "webhook":
+		publisher = events.NewWebhookPublisher(events.NewWebhookPublisherOptions{
+			URL: cfg.Events.WebhookURL,
+		})
+	default:
+		fatal("Unknown event publisher", nil, "publisher", cfg.Events.Publisher)
+	}
+	if err != nil {
+		fatal("Failed to open event file", err)
+	}
+
+	// One-time codes are sent as SMS through the gateway, the log notifier
+	// only logs them and is refused outside development by the config
+	var notify notifier.NotifierInterface
+	switch cfg.Notifier.Provider {
+	case notifier.ProviderHTTP:
+		notify = notifier.NewHTTPNotifier(notifier.NewHTTPNotifierOptions{
+			URL:     cfg.Notifier.URL,
+			Token:   cfg.Notifier.Token,
+			Timeout: cfg.Notifier.Timeout,
+		})
+	case notifier.ProviderLog:
+		logger.Warn("One-time codes are logged instead of delivered, for development only")
+		notify = notifier.NewLogNotifier(notifier.NewLogNotifierOptions{
+			Logger:   logger,
+			ShowCode: cfg.Notifier.Dev,
+		})
+	default:
+		fatal("Unknown notifier provider", nil, "provider", cfg.Notifier.Provider)
+	}
+
+	// Events are also queued for the webhook subscriptions of partners
+	var relay outbox.RelayInterface = outbox.NewRelay(outbox.NewRelayOptions{
+		Repository: repo,
+		Logger:     logger,
+		Publisher: events.NewMultiPublisher(events.NewMultiPublisherOptions{
+			Publishers: []events.EventPublisherInterface{
+				publisher,
+				webhook.NewFanout(webhook.NewFanoutOptions{Repository: repo}),
+			},
+		}),
+	})
+	runWorker(ctx, workers, relay.Run)
+	var dispatcher webhook.DispatcherInterface = webhook.NewDispatcher(webhook.NewDispatcherOptions{
 		Repository: repo,
-		Helper:     helper,
-		Echo:       e,
+		Cipher:     secretCipher,
+		Logger:     logger,
+	})
+	runWorker(ctx, workers, dispatcher.Run)
+
+	opts := handler.NewServerOptions{
+		Repository:        repo,
+		Helper:            helper,
+		PasswordPolicy:    policy,
+		PasswordBlocklist: blocklist,
+		PasswordEstimator: estimator,
+		PhoneParser:       phoneParser,
+		Notifier:          notify,
+		TOTP:              totp.NewTOTP(totp.NewTOTPOptions{Issuer: cfg.Handler.TOTP.Issuer}),
+		WebAuthn: webauthn.NewWebAuthn(webauthn.NewWebAuthnOptions{
+			RPID:    cfg.Handler.WebAuthn.RPID,
+			RPName:  cfg.Handler.WebAuthn.RPName,
+			Origins: cfg.Handler.WebAuthn.Origins,
+		}),
+		RiskAssessor:    riskAssessor,
+		SecretCipher:    secretCipher,
+		RegistrationTTL: cfg.Handler.RegistrationTTL,
+		RefreshTokenTTL: cfg.Helper.RefreshTokenTTL,
+		MfaTokenTTL:     cfg.Helper.MfaTokenTTL,
+		Logger:          logger,
+		Metrics:         m,
+		Tracer:          tracer,
+		Echo:            e,
+	}
+	return handler.NewServer(opts), db
+}
+
+// newRepository connects to the database of the config, tracer may be nil
+func newRepository(cfg config.Config, logger *slog.Logger, tracer trace.Tracer) *repository.Repository {
+	opts := cfg.Repository.RepositoryOptions()
+	opts.Logger = logger
+	opts.Tracer = tracer
+	return repository.NewRepository(opts)
+}
+
+// runWorker runs the worker in the background until ctx is done
+func runWorker(ctx context.Context, workers *sync.WaitGroup, run func(context.Context)) {
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		run(ctx)
+	}()
+}
+
+// fatal logs why the service can't start and exits
+func fatal(msg string, err error, args ...any) {
+	if err != nil {
+		args = append(args, "error", err)
 	}
-	return handler.NewServer(opts)
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
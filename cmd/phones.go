@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/asrul10/UserService/config"
+	"github.com/asrul10/UserService/logging"
+	"github.com/asrul10/UserService/phone"
+	"github.com/asrul10/UserService/repository"
+)
+
+const phoneBatchSize = 1000
+
+type normalizeReport struct {
+	Checked    int
+	Normalized int
+	Failed     int
+}
+
+// normalizePhones rewrites the phone numbers stored before they were
+// normalized, so their users are found by the normalized number they log
+// in with. Numbers which don't parse, or whose normalized form belongs to
+// another user, are reported to be fixed by hand and the exit status is 1.
+func normalizePhones(cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("phones normalize", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "only report the numbers which would be rewritten")
+	flags.Parse(args)
+
+	if err := cfg.Validate(); err != nil {
+		fatal("Invalid config", err)
+	}
+	// Numbers of every region are parsed, the allowed regions only limit
+	// new numbers
+	parser, err := phone.NewParser(phone.NewParserOptions{
+		DefaultRegion: cfg.Handler.Phone.DefaultRegion,
+	})
+	if err != nil {
+		fatal("Failed to load phone metadata", err)
+	}
+	repo := newRepository(cfg, slog.Default(), nil)
+	report, err := normalizePhoneNumbers(context.Background(), repo, parser, *dryRun, os.Stdout)
+	repo.Close()
+	if err != nil {
+		fatal("Failed to normalize phone numbers", err)
+	}
+	verb := "normalized"
+	if *dryRun {
+		verb = "would normalize"
+	}
+	fmt.Printf("Checked %d users, %s %d, %d failed\n", report.Checked, verb, report.Normalized, report.Failed)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func normalizePhoneNumbers(ctx context.Context, repo repository.RepositoryInterface, parser phone.ParserInterface, dryRun bool, out io.Writer) (report normalizeReport, err error) {
+	afterId := 0
+	for {
+		resp, err := repo.ListPhoneNumbers(ctx, repository.ListPhoneNumbersInput{
+			AfterId: afterId,
+			Limit:   phoneBatchSize,
+		})
+		if err != nil {
+			return report, err
+		}
+		for _, user := range resp.Users {
+			afterId = user.UserId
+			report.Checked++
+
+			number, err := parser.Parse(user.PhoneNumber)
+			if err != nil {
+				report.Failed++
+				fmt.Fprintf(out, "user %d: %s doesn't parse: %s\n", user.UserId, logging.MaskPhone(user.PhoneNumber), err)
+				continue
+			}
+			if number.E164 == user.PhoneNumber {
+				continue
+			}
+			if dryRun {
+				report.Normalized++
+				fmt.Fprintf(out, "user %d: %s would be rewritten to %s\n", user.UserId, logging.MaskPhone(user.PhoneNumber), logging.MaskPhone(number.E164))
+				continue
+			}
+
+			_, err = repo.NormalizePhoneNumber(ctx, repository.NormalizePhoneNumberInput{
+				UserId:         user.UserId,
+				OldPhoneNumber: user.PhoneNumber,
+				PhoneNumber:    number.E164,
+				Audit:          repository.AuditEntry{Action: repository.AuditActionUserPhoneNormalize},
+			})
+			switch {
+			case errors.Is(err, repository.ErrPhoneNumberTaken):
+				report.Failed++
+				fmt.Fprintf(out, "user %d: %s is registered by another user\n", user.UserId, logging.MaskPhone(number.E164))
+			case errors.Is(err, sql.ErrNoRows):
+				// Changed since it was listed, the new number is
+				// normalized already
+			case err != nil:
+				return report, err
+			default:
+				report.Normalized++
+			}
+		}
+		if len(resp.Users) < phoneBatchSize {
+			return report, nil
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// This file contains the configuration of the service. It is loaded from
+// a YAML or TOML file, overridden by the environment variables and
+// validated before the service starts. Every setting has an environment
+// variable, so the service can still be configured without a file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/password"
+	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/retention"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRegistrationTTL is how long an unverified registration holds the
+// phone number when the config doesn't set it
+const DefaultRegistrationTTL = time.Hour * 24
+
+type Config struct {
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Log        LogConfig        `yaml:"log" toml:"log"`
+	Tracing    TracingConfig    `yaml:"tracing" toml:"tracing"`
+	Repository RepositoryConfig `yaml:"repository" toml:"repository"`
+	Helper     HelperConfig     `yaml:"helper" toml:"helper"`
+	Handler    HandlerConfig    `yaml:"handler" toml:"handler"`
+	Notifier   NotifierConfig   `yaml:"notifier" toml:"notifier"`
+	Events     EventsConfig     `yaml:"events" toml:"events"`
+	Retention  RetentionConfig  `yaml:"retention" toml:"retention"`
+}
+
+type ServerConfig struct {
+	Port         int           `yaml:"port" toml:"port" env:"HTTP_PORT"`
+	ReadTimeout  time.Duration `yaml:"readTimeout" toml:"readTimeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"writeTimeout" toml:"writeTimeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout" toml:"idleTimeout" env:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownTimeout is how long the requests in flight and the workers
+	// are given to finish on SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+type LogConfig struct {
+	// Level is one of debug, info, warn or error
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+}
+
+type TracingConfig struct {
+	// Exporter is one of none, stdout, file or otlp
+	Exporter     string `yaml:"exporter" toml:"exporter" env:"TRACE_EXPORTER"`
+	FilePath     string `yaml:"filePath" toml:"filePath" env:"TRACE_FILE_PATH"`
+	OTLPEndpoint string `yaml:"otlpEndpoint" toml:"otlpEndpoint" env:"TRACE_OTLP_ENDPOINT"`
+}
+
+type RepositoryConfig struct {
+	// Dsn is a postgres:// URL or a list of key=value settings
+	Dsn             string        `yaml:"dsn" toml:"dsn" env:"DATABASE_URL"`
+	MaxOpenConns    int           `yaml:"maxOpenConns" toml:"maxOpenConns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"maxIdleConns" toml:"maxIdleConns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" toml:"connMaxLifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" toml:"connMaxIdleTime" env:"DB_CONN_MAX_IDLE_TIME"`
+	// ConnectRetries is how many times the first ping is retried, the
+	// backoff starts at ConnectBackoff and doubles
+	ConnectRetries int           `yaml:"connectRetries" toml:"connectRetries" env:"DB_CONNECT_RETRIES"`
+	ConnectBackoff time.Duration `yaml:"connectBackoff" toml:"connectBackoff" env:"DB_CONNECT_BACKOFF"`
+	// Statement timeouts of the reads and writes of the requests, and of
+	// the batches of the background workers
+	ReadTimeout  time.Duration `yaml:"readTimeout" toml:"readTimeout" env:"DB_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"writeTimeout" toml:"writeTimeout" env:"DB_WRITE_TIMEOUT"`
+	BatchTimeout time.Duration `yaml:"batchTimeout" toml:"batchTimeout" env:"DB_BATCH_TIMEOUT"`
+}
+
+type HelperConfig struct {
+	JwtPrivateKeyPath string        `yaml:"jwtPrivateKeyPath" toml:"jwtPrivateKeyPath" env:"JWT_PRIVATE_KEY_PATH"`
+	JwtPublicKeyPath  string        `yaml:"jwtPublicKeyPath" toml:"jwtPublicKeyPath" env:"JWT_PUBLIC_KEY_PATH"`
+	AccessTokenTTL    time.Duration `yaml:"accessTokenTTL" toml:"accessTokenTTL" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL   time.Duration `yaml:"refreshTokenTTL" toml:"refreshTokenTTL" env:"REFRESH_TOKEN_TTL"`
+	MfaTokenTTL       time.Duration `yaml:"mfaTokenTTL" toml:"mfaTokenTTL" env:"MFA_TOKEN_TTL"`
+}
+
+type HandlerConfig struct {
+	// RegistrationTTL is how long an unverified registration holds the
+	// phone number
+	RegistrationTTL time.Duration `yaml:"registrationTTL" toml:"registrationTTL" env:"REGISTRATION_TTL"`
+	// SecretEncryptionKey is the base64 encoded 32 bytes key of the
+	// secrets stored in the database
+	SecretEncryptionKey string         `yaml:"secretEncryptionKey" toml:"secretEncryptionKey" env:"SECRET_ENCRYPTION_KEY"`
+	Password            PasswordConfig `yaml:"password" toml:"password"`
+	Phone               PhoneConfig    `yaml:"phone" toml:"phone"`
+	TOTP                TOTPConfig     `yaml:"totp" toml:"totp"`
+	WebAuthn            WebAuthnConfig `yaml:"webauthn" toml:"webauthn"`
+	GeoIP               GeoIPConfig    `yaml:"geoip" toml:"geoip"`
+}
+
+type PasswordConfig struct {
+	Policy PasswordPolicyConfig `yaml:"policy" toml:"policy"`
+	// PolicyPath is a JSON policy file of older deployments, the policy of
+	// the config and the environment override it. It is optional.
+	PolicyPath string `yaml:"policyPath" toml:"policyPath" env:"PASSWORD_POLICY_PATH"`
+	// BlocklistPath is optional, the built-in blocklist is used without it
+	BlocklistPath string `yaml:"blocklistPath" toml:"blocklistPath" env:"PASSWORD_BLOCKLIST_PATH"`
+}
+
+// PasswordPolicyConfig converts to password.PolicyConfig, their fields
+// have to stay the same
+type PasswordPolicyConfig struct {
+	MinLength int `yaml:"minLength" toml:"minLength" env:"PASSWORD_MIN_LENGTH"`
+	// MaxLength in characters, 0 only leaves the bcrypt limit of
+	// password.MaxBytes
+	MaxLength        int  `yaml:"maxLength" toml:"maxLength" env:"PASSWORD_MAX_LENGTH"`
+	MinUppercase     int  `yaml:"minUppercase" toml:"minUppercase" env:"PASSWORD_MIN_UPPERCASE"`
+	MinLowercase     int  `yaml:"minLowercase" toml:"minLowercase" env:"PASSWORD_MIN_LOWERCASE"`
+	MinNumber        int  `yaml:"minNumber" toml:"minNumber" env:"PASSWORD_MIN_NUMBER"`
+	MinSpecialChar   int  `yaml:"minSpecialChar" toml:"minSpecialChar" env:"PASSWORD_MIN_SPECIAL_CHAR"`
+	MaxRepeatedChars int  `yaml:"maxRepeatedChars" toml:"maxRepeatedChars" env:"PASSWORD_MAX_REPEATED_CHARS"`
+	ForbidPhone      bool `yaml:"forbidPhoneNumber" toml:"forbidPhoneNumber" env:"PASSWORD_FORBID_PHONE_NUMBER"`
+	ForbidFullName   bool `yaml:"forbidFullName" toml:"forbidFullName" env:"PASSWORD_FORBID_FULL_NAME"`
+	// MinStrengthScore is the lowest estimator score (0-4) accepted, 0
+	// disables the check
+	MinStrengthScore int `yaml:"minStrengthScore" toml:"minStrengthScore" env:"PASSWORD_MIN_STRENGTH_SCORE"`
+}
+
+type PhoneConfig struct {
+	DefaultRegion string `yaml:"defaultRegion" toml:"defaultRegion" env:"PHONE_DEFAULT_REGION"`
+	// AllowedRegions are all allowed when empty
+	AllowedRegions []string `yaml:"allowedRegions" toml:"allowedRegions" env:"PHONE_ALLOWED_REGIONS"`
+}
+
+type TOTPConfig struct {
+	Issuer string `yaml:"issuer" toml:"issuer" env:"TOTP_ISSUER"`
+}
+
+type WebAuthnConfig struct {
+	RPID    string   `yaml:"rpId" toml:"rpId" env:"WEBAUTHN_RP_ID"`
+	RPName  string   `yaml:"rpName" toml:"rpName" env:"WEBAUTHN_RP_NAME"`
+	Origins []string `yaml:"origins" toml:"origins" env:"WEBAUTHN_ORIGINS"`
+}
+
+type GeoIPConfig struct {
+	// DatabasePath is optional, impossible travel is not detected
+	// without it
+	DatabasePath string `yaml:"databasePath" toml:"databasePath" env:"GEOIP_DATABASE_PATH"`
+}
+
+type NotifierConfig struct {
+	// Provider is http, the SMS gateway of URL, or log. The log notifier
+	// delivers nothing and logs the one-time codes, it requires Dev.
+	Provider string `yaml:"provider" toml:"provider" env:"NOTIFIER_PROVIDER"`
+	// Dev allows the log notifier, never set it in production
+	Dev bool   `yaml:"dev" toml:"dev" env:"NOTIFIER_DEV"`
+	URL string `yaml:"url" toml:"url" env:"NOTIFIER_URL"`
+	// Token is sent to the gateway as a bearer token
+	Token   string        `yaml:"token" toml:"token" env:"NOTIFIER_TOKEN"`
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"NOTIFIER_TIMEOUT"`
+}
+
+type EventsConfig struct {
+	// Publisher is one of stdout, file or webhook
+	Publisher  string `yaml:"publisher" toml:"publisher" env:"EVENT_PUBLISHER"`
+	FilePath   string `yaml:"filePath" toml:"filePath" env:"EVENT_FILE_PATH"`
+	WebhookURL string `yaml:"webhookURL" toml:"webhookURL" env:"EVENT_WEBHOOK_URL"`
+}
+
+type RetentionConfig struct {
+	LoginEventRetention time.Duration `yaml:"loginEventRetention" toml:"loginEventRetention" env:"LOGIN_EVENT_RETENTION"`
+}
+
+// Default returns the settings used when neither the file nor the
+// environment sets them
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            1323,
+			ReadTimeout:     time.Second * 10,
+			WriteTimeout:    time.Second * 30,
+			IdleTimeout:     time.Second * 120,
+			ShutdownTimeout: time.Second * 30,
+		},
+		Log:     LogConfig{Level: "info"},
+		Tracing: TracingConfig{Exporter: "none"},
+		Repository: RepositoryConfig{
+			MaxOpenConns:    repository.DefaultMaxOpenConns,
+			MaxIdleConns:    repository.DefaultMaxIdleConns,
+			ConnMaxLifetime: repository.DefaultConnMaxLifetime,
+			ConnMaxIdleTime: repository.DefaultConnMaxIdleTime,
+			ConnectRetries:  repository.DefaultConnectRetries,
+			ConnectBackoff:  repository.DefaultConnectBackoff,
+			ReadTimeout:     repository.DefaultReadTimeout,
+			WriteTimeout:    repository.DefaultWriteTimeout,
+			BatchTimeout:    repository.DefaultBatchTimeout,
+		},
+		Helper: HelperConfig{
+			AccessTokenTTL:  helper.AccessTokenExpireDuration,
+			RefreshTokenTTL: helper.RefreshTokenExpireDuration,
+			MfaTokenTTL:     helper.MfaTokenExpireDuration,
+		},
+		Handler: HandlerConfig{
+			RegistrationTTL: DefaultRegistrationTTL,
+			Password: PasswordConfig{
+				Policy: PasswordPolicyConfig(password.DefaultPolicyConfig()),
+			},
+			Phone: PhoneConfig{DefaultRegion: "ID"},
+			TOTP:  TOTPConfig{Issuer: "UserService"},
+			WebAuthn: WebAuthnConfig{
+				RPID:    "localhost",
+				RPName:  "UserService",
+				Origins: []string{"http://localhost:1323"},
+			},
+		},
+		Notifier: NotifierConfig{
+			Provider: notifier.ProviderHTTP,
+			Timeout:  notifier.DefaultHTTPTimeout,
+		},
+		Events: EventsConfig{Publisher: "stdout"},
+		Retention: RetentionConfig{
+			LoginEventRetention: retention.DefaultLoginEventRetention,
+		},
+	}
+}
+
+type LoadOptions struct {
+	// Path of the file, YAML (.yaml or .yml) or TOML (.toml) by its
+	// extension. Only the defaults and the environment are used when
+	// empty.
+	Path string
+	// LookupEnv defaults to os.LookupEnv
+	LookupEnv func(key string) (string, bool)
+}
+
+// Load returns the defaults overridden by the file, then by the
+// environment. Unknown keys of the file and values which don't parse are
+// errors, the result still has to be validated.
+func Load(opts LoadOptions) (Config, error) {
+	if opts.LookupEnv == nil {
+		opts.LookupEnv = os.LookupEnv
+	}
+
+	cfg := Default()
+	var read []byte
+	if opts.Path != "" {
+		var err error
+		read, err = os.ReadFile(opts.Path)
+		if err != nil {
+			return cfg, err
+		}
+	}
+	if err := apply(&cfg, opts, read); err != nil {
+		return cfg, err
+	}
+
+	// The JSON policy file of older deployments is only known once the
+	// settings are read, they are applied again on top of it
+	if cfg.Handler.Password.PolicyPath != "" {
+		policy, err := password.LoadPolicyConfig(cfg.Handler.Password.PolicyPath)
+		if err != nil {
+			return cfg, fmt.Errorf("handler.password.policyPath: %w", err)
+		}
+		cfg = Default()
+		cfg.Handler.Password.Policy = PasswordPolicyConfig(policy)
+		if err := apply(&cfg, opts, read); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// apply overrides cfg with the file read from opts.Path, then with the
+// environment
+func apply(cfg *Config, opts LoadOptions, read []byte) error {
+	if opts.Path != "" {
+		if err := decode(opts.Path, read, cfg); err != nil {
+			return fmt.Errorf("%s: %w", opts.Path, err)
+		}
+	}
+	return applyEnv(cfg, opts.LookupEnv)
+}
+
+func decode(path string, read []byte, cfg *Config) error {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(read))
+		decoder.KnownFields(true)
+		// An empty file keeps the defaults
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	case ".toml":
+		meta, err := toml.NewDecoder(bytes.NewReader(read)).Decode(cfg)
+		if err != nil {
+			return err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown key %q", undecoded[0].String())
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown config format %q, expected .yaml, .yml or .toml", filepath.Ext(path))
+	}
+}
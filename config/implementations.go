@@ -0,0 +1,301 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asrul10/UserService/encryption"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/tracing"
+	"github.com/lib/pq"
+)
+
+const redacted = "REDACTED"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// dsnPassword matches the password of a key=value DSN, quoted or not
+var dsnPassword = regexp.MustCompile(`password=('(?:[^'\\]|\\.)*'|\S+)`)
+
+// applyEnv overrides the settings of cfg with the environment variables
+// named by their env tags. Lists are comma separated.
+func applyEnv(cfg *Config, lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if field.Kind() == reflect.Struct && field.Type() != durationType {
+				walk(field)
+				continue
+			}
+			name := v.Type().Field(i).Tag.Get("env")
+			if name == "" {
+				continue
+			}
+			value, ok := lookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setField(field, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem())
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, value string) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Validate returns every invalid setting, joined
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("server.port %d is not between 1 and 65535", c.Server.Port)
+	}
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.shutdownTimeout", c.Server.ShutdownTimeout},
+		{"repository.connMaxLifetime", c.Repository.ConnMaxLifetime},
+		{"repository.connMaxIdleTime", c.Repository.ConnMaxIdleTime},
+		{"repository.connectBackoff", c.Repository.ConnectBackoff},
+		{"repository.readTimeout", c.Repository.ReadTimeout},
+		{"repository.writeTimeout", c.Repository.WriteTimeout},
+		{"repository.batchTimeout", c.Repository.BatchTimeout},
+		{"helper.accessTokenTTL", c.Helper.AccessTokenTTL},
+		{"helper.refreshTokenTTL", c.Helper.RefreshTokenTTL},
+		{"helper.mfaTokenTTL", c.Helper.MfaTokenTTL},
+		{"handler.registrationTTL", c.Handler.RegistrationTTL},
+		{"notifier.timeout", c.Notifier.Timeout},
+		{"retention.loginEventRetention", c.Retention.LoginEventRetention},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			invalid("%s must be positive, got %s", d.name, d.value)
+		}
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		invalid("log.level: %w", err)
+	}
+
+	switch c.Tracing.Exporter {
+	case "", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		if c.Tracing.FilePath == "" {
+			invalid("tracing.filePath is required by the file exporter")
+		}
+	default:
+		invalid("tracing.exporter %q is not one of none, stdout, file or otlp", c.Tracing.Exporter)
+	}
+
+	if c.Repository.Dsn == "" {
+		invalid("repository.dsn is required")
+	} else if _, err := pq.NewConnector(c.Repository.Dsn); err != nil {
+		invalid("repository.dsn is malformed: %w", err)
+	}
+	if c.Repository.MaxOpenConns < 1 {
+		invalid("repository.maxOpenConns must be at least 1, got %d", c.Repository.MaxOpenConns)
+	}
+	if c.Repository.MaxIdleConns < 1 || c.Repository.MaxIdleConns > c.Repository.MaxOpenConns {
+		invalid("repository.maxIdleConns must be between 1 and maxOpenConns, got %d", c.Repository.MaxIdleConns)
+	}
+	if c.Repository.ConnectRetries < 0 {
+		invalid("repository.connectRetries must not be negative, got %d", c.Repository.ConnectRetries)
+	}
+
+	// Files read at startup have to exist, only the keys are required
+	paths := []struct {
+		name     string
+		value    string
+		required bool
+	}{
+		{"helper.jwtPrivateKeyPath", c.Helper.JwtPrivateKeyPath, true},
+		{"helper.jwtPublicKeyPath", c.Helper.JwtPublicKeyPath, true},
+		{"handler.password.policyPath", c.Handler.Password.PolicyPath, false},
+		{"handler.password.blocklistPath", c.Handler.Password.BlocklistPath, false},
+		{"handler.geoip.databasePath", c.Handler.GeoIP.DatabasePath, false},
+	}
+	for _, p := range paths {
+		if p.value == "" {
+			if p.required {
+				invalid("%s is required", p.name)
+			}
+			continue
+		}
+		if _, err := os.Stat(p.value); err != nil {
+			invalid("%s: %w", p.name, err)
+		}
+	}
+
+	policy := c.Handler.Password.Policy
+	counts := []struct {
+		name  string
+		value int
+	}{
+		{"maxLength", policy.MaxLength},
+		{"minUppercase", policy.MinUppercase},
+		{"minLowercase", policy.MinLowercase},
+		{"minNumber", policy.MinNumber},
+		{"minSpecialChar", policy.MinSpecialChar},
+		{"maxRepeatedChars", policy.MaxRepeatedChars},
+	}
+	for _, count := range counts {
+		if count.value < 0 {
+			invalid("handler.password.policy.%s must not be negative, got %d", count.name, count.value)
+		}
+	}
+	if policy.MinLength < 1 {
+		invalid("handler.password.policy.minLength must be at least 1, got %d", policy.MinLength)
+	}
+	if policy.MaxLength > 0 && policy.MaxLength < policy.MinLength {
+		invalid("handler.password.policy.maxLength %d is below minLength %d", policy.MaxLength, policy.MinLength)
+	}
+	if policy.MinStrengthScore < 0 || policy.MinStrengthScore > 4 {
+		invalid("handler.password.policy.minStrengthScore %d is not between 0 and 4", policy.MinStrengthScore)
+	}
+
+	if c.Handler.SecretEncryptionKey == "" {
+		invalid("handler.secretEncryptionKey is required")
+	} else if key, err := base64.StdEncoding.DecodeString(c.Handler.SecretEncryptionKey); err != nil || len(key) != encryption.KeySize {
+		invalid("handler.secretEncryptionKey is not %d base64 encoded bytes", encryption.KeySize)
+	}
+	if len(c.Handler.WebAuthn.Origins) == 0 {
+		invalid("handler.webauthn.origins is required")
+	}
+	for _, origin := range c.Handler.WebAuthn.Origins {
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("handler.webauthn.origins %q is not an origin like https://example.com", origin)
+		}
+	}
+
+	// Without a real notifier no one-time code reaches the users
+	switch c.Notifier.Provider {
+	case notifier.ProviderHTTP:
+		if u, err := url.Parse(c.Notifier.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("notifier.url %q is not a URL, it is required by the http provider", c.Notifier.URL)
+		}
+	case notifier.ProviderLog:
+		if !c.Notifier.Dev {
+			invalid("notifier.provider log delivers nothing and is only allowed with notifier.dev")
+		}
+	default:
+		invalid("notifier.provider %q is not one of http or log", c.Notifier.Provider)
+	}
+
+	switch c.Events.Publisher {
+	case "", "stdout":
+	case "file":
+		if c.Events.FilePath == "" {
+			invalid("events.filePath is required by the file publisher")
+		}
+	case "webhook":
+		if u, err := url.Parse(c.Events.WebhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("events.webhookURL %q is not a URL", c.Events.WebhookURL)
+		}
+	default:
+		invalid("events.publisher %q is not one of stdout, file or webhook", c.Events.Publisher)
+	}
+
+	return errors.Join(errs...)
+}
+
+// RepositoryOptions returns the options of repository.NewRepository, the
+// logger and the tracer are left to the caller
+func (c RepositoryConfig) RepositoryOptions() repository.NewRepositoryOptions {
+	// Zero retries of the config disable them, the repository would
+	// default them
+	connectRetries := c.ConnectRetries
+	if connectRetries == 0 {
+		connectRetries = -1
+	}
+	return repository.NewRepositoryOptions{
+		Dsn:             c.Dsn,
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: c.ConnMaxLifetime,
+		ConnMaxIdleTime: c.ConnMaxIdleTime,
+		ConnectRetries:  connectRetries,
+		ConnectBackoff:  c.ConnectBackoff,
+		ReadTimeout:     c.ReadTimeout,
+		WriteTimeout:    c.WriteTimeout,
+		BatchTimeout:    c.BatchTimeout,
+	}
+}
+
+// Redacted returns the configuration without its secrets, for printing
+func (c Config) Redacted() Config {
+	if c.Handler.SecretEncryptionKey != "" {
+		c.Handler.SecretEncryptionKey = redacted
+	}
+	if c.Notifier.Token != "" {
+		c.Notifier.Token = redacted
+	}
+	c.Repository.Dsn = redactDsn(c.Repository.Dsn)
+	return c
+}
+
+func redactDsn(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "password="+redacted)
+}
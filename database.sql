@@ -13,8 +13,229 @@ CREATE TABLE users (
   full_name VARCHAR ( 60 ) NOT NULL,
   password VARCHAR ( 255 ) NOT NULL,
   success_login_count INT DEFAULT 0,
+  token_version INT NOT NULL DEFAULT 0,
+  status VARCHAR ( 32 ) NOT NULL DEFAULT 'active',
+  -- Set by support, the password can't be used until the user changes it
+  password_reset_required BOOLEAN NOT NULL DEFAULT FALSE,
   created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
   deleted_at TIMESTAMP WITH TIME ZONE
 );
 
+-- Prefix search of phone numbers in the admin API
+CREATE INDEX users_phone_number_pattern_idx ON users ( phone_number varchar_pattern_ops );
+
+
+CREATE TABLE phone_change_requests (
+  user_id BIGINT PRIMARY KEY REFERENCES users ( id ) ON DELETE CASCADE,
+  phone_number VARCHAR ( 50 ) NOT NULL,
+  code_hash VARCHAR ( 255 ) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE registration_verifications (
+  user_id BIGINT PRIMARY KEY REFERENCES users ( id ) ON DELETE CASCADE,
+  code_hash VARCHAR ( 255 ) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE user_totp (
+  user_id BIGINT PRIMARY KEY REFERENCES users ( id ) ON DELETE CASCADE,
+  -- AES-GCM sealed base32 secret, never stored in plain text
+  secret_encrypted TEXT NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  -- Time step of the last accepted code, a code is only accepted once
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  failed_attempts INT NOT NULL DEFAULT 0,
+  last_failed_at TIMESTAMP WITH TIME ZONE,
+  enabled_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE recovery_codes (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users ( id ) ON DELETE CASCADE,
+  code_hash VARCHAR ( 255 ) NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes ( user_id );
+
+CREATE TABLE login_codes (
+  user_id BIGINT PRIMARY KEY REFERENCES users ( id ) ON DELETE CASCADE,
+  code_hash VARCHAR ( 255 ) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webauthn_credentials (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users ( id ) ON DELETE CASCADE,
+  credential_id BYTEA UNIQUE NOT NULL,
+  -- COSE encoded public key
+  public_key BYTEA NOT NULL,
+  sign_count BIGINT NOT NULL DEFAULT 0,
+  aaguid BYTEA,
+  name VARCHAR ( 60 ) NOT NULL,
+  last_used_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials ( user_id );
+
+-- Pending registration and login ceremonies, a challenge is used once
+CREATE TABLE webauthn_challenges (
+  challenge VARCHAR ( 64 ) PRIMARY KEY,
+  user_id BIGINT REFERENCES users ( id ) ON DELETE CASCADE,
+  ceremony VARCHAR ( 16 ) NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per login, tokens carry the id in the "sid" claim
+CREATE TABLE sessions (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users ( id ) ON DELETE CASCADE,
+  user_agent VARCHAR ( 255 ) NOT NULL DEFAULT '',
+  ip_address VARCHAR ( 45 ) NOT NULL DEFAULT '',
+  -- "jti" of the latest refresh token, older ones are rejected
+  refresh_token_id VARCHAR ( 64 ) NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  revoked_at TIMESTAMP WITH TIME ZONE,
+  last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions ( user_id );
+
+-- Append only history of login attempts
+CREATE TABLE login_events (
+  id BIGSERIAL PRIMARY KEY,
+  -- NULL for attempts on unknown phone numbers
+  user_id BIGINT REFERENCES users ( id ) ON DELETE CASCADE,
+  method VARCHAR ( 16 ) NOT NULL,
+  success BOOLEAN NOT NULL,
+  failure_reason VARCHAR ( 32 ),
+  ip_address VARCHAR ( 45 ) NOT NULL DEFAULT '',
+  user_agent VARCHAR ( 255 ) NOT NULL DEFAULT '',
+  -- NULL when the login was not assessed
+  risk_decision VARCHAR ( 16 ),
+  risk_reasons TEXT[],
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX login_events_user_id_idx ON login_events ( user_id, id DESC );
+CREATE INDEX login_events_created_at_idx ON login_events ( created_at );
+
+-- Roles grant permissions, named "<resource>:<action>", which are embedded
+-- as scopes in the access tokens of their users
+CREATE TABLE roles (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR ( 32 ) UNIQUE NOT NULL,
+  description VARCHAR ( 255 ) NOT NULL DEFAULT '',
+  permissions TEXT[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE user_roles (
+  user_id BIGINT REFERENCES users ( id ) ON DELETE CASCADE,
+  role_id BIGINT REFERENCES roles ( id ) ON DELETE CASCADE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ( user_id, role_id )
+);
+
+CREATE INDEX user_roles_role_id_idx ON user_roles ( role_id );
+
+-- The first administrator is granted by hand:
+-- INSERT INTO user_roles (user_id, role_id) SELECT <user id>, id FROM roles WHERE name = 'admin';
+INSERT INTO roles (name, description, permissions) VALUES
+  ('admin', 'Full access to users, roles and webhooks', '{users:read,users:write,roles:read,roles:write,webhooks:read,webhooks:write}'),
+  ('support', 'Read access to users', '{users:read}');
+
+-- Changes of users and actions of support staff on them. The log is append
+-- only, every entry carries the hash of the entry before it, see the audit
+-- package. Users are not referenced, deleting a user must not change the
+-- entries about them.
+CREATE TABLE audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  actor_id BIGINT,
+  -- NULL for actions on no particular user, like listing users
+  subject_id BIGINT,
+  action VARCHAR ( 64 ) NOT NULL,
+  -- Changed fields with their values before and after, secrets redacted
+  changes JSONB NOT NULL DEFAULT '[]',
+  request_id VARCHAR ( 64 ) NOT NULL DEFAULT '',
+  ip_address VARCHAR ( 45 ) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  prev_hash CHAR ( 64 ) NOT NULL,
+  hash CHAR ( 64 ) NOT NULL
+);
+
+CREATE INDEX audit_log_subject_id_idx ON audit_log ( subject_id, id DESC );
+
+-- Domain events waiting to be published, written in the transaction of the
+-- change. The relay deletes an event once it is published.
+CREATE TABLE outbox (
+  id BIGSERIAL PRIMARY KEY,
+  event_type VARCHAR ( 64 ) NOT NULL,
+  user_id BIGINT NOT NULL,
+  data JSONB NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  attempts INT NOT NULL DEFAULT 0,
+  -- A claimed event is invisible to other relays until then
+  next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX outbox_next_attempt_at_idx ON outbox ( next_attempt_at, id );
+
+-- Endpoints of partner services receiving the domain events
+CREATE TABLE webhook_subscriptions (
+  id BIGSERIAL PRIMARY KEY,
+  url VARCHAR ( 2048 ) NOT NULL,
+  event_types TEXT[] NOT NULL,
+  -- Secret signing the deliveries, encrypted with SECRET_ENCRYPTION_KEY
+  encrypted_secret TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Deliveries of an event to a subscription. A delivery out of attempts is
+-- dead and kept until it is replayed.
+CREATE TABLE webhook_deliveries (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions ( id ) ON DELETE CASCADE,
+  event_id BIGINT NOT NULL,
+  event_type VARCHAR ( 64 ) NOT NULL,
+  payload JSONB NOT NULL,
+  -- pending, delivered or dead
+  status VARCHAR ( 16 ) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  -- A claimed delivery is invisible to other dispatchers until then
+  next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  -- Response of the last attempt, 0 when no response was received
+  last_status_code INT NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_at TIMESTAMP WITH TIME ZONE,
+  -- Events are published at least once, a subscription receives them once
+  UNIQUE ( subscription_id, event_id )
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries ( next_attempt_at, id ) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_subscription_id_idx ON webhook_deliveries ( subscription_id, id DESC );
+
+-- Version of this schema, a new row is added with every change of it and
+-- repository.SchemaVersion is bumped to match. The service is not ready
+-- until the database is migrated to the version it expects.
+CREATE TABLE schema_version (
+  version INT PRIMARY KEY,
+  applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO schema_version ( version ) VALUES ( 1 );
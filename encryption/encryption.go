@@ -0,0 +1,44 @@
+// This file contains the cipher used to encrypt secrets at rest, e.g. TOTP
+// secrets. Values are sealed with AES-256-GCM and stored base64 encoded
+// with the nonce in front.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+)
+
+// KeySize is the size in bytes of the AES-256 key
+const KeySize = 32
+
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+type NewCipherOptions struct {
+	// Key is the base64 encoded 32 bytes key
+	Key string
+}
+
+func NewCipher(opts NewCipherOptions) (*Cipher, error) {
+	key, err := base64.StdEncoding.DecodeString(opts.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid encryption key: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
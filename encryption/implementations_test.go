@@ -0,0 +1,61 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+var testKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", KeySize)))
+
+func TestEncryptDecrypt(t *testing.T) {
+	c, err := NewCipher(NewCipherOptions{Key: testKey})
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+
+	ciphertext, err := c.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if strings.Contains(ciphertext, "JBSWY3DPEHPK3PXP") {
+		t.Errorf("Expected plaintext to be hidden, got %s", ciphertext)
+	}
+
+	plaintext, err := c.Decrypt(ciphertext)
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if plaintext != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Expected JBSWY3DPEHPK3PXP, got %s", plaintext)
+	}
+
+	// Every encryption uses a new nonce
+	again, _ := c.Encrypt("JBSWY3DPEHPK3PXP")
+	if again == ciphertext {
+		t.Errorf("Expected different ciphertexts for the same plaintext")
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	c, _ := NewCipher(NewCipherOptions{Key: testKey})
+	ciphertext, _ := c.Encrypt("secret")
+
+	sealed, _ := base64.StdEncoding.DecodeString(ciphertext)
+	sealed[len(sealed)-1] ^= 1
+	if _, err := c.Decrypt(base64.StdEncoding.EncodeToString(sealed)); err != ErrInvalidCiphertext {
+		t.Errorf("Expected ErrInvalidCiphertext, got %v", err)
+	}
+	if _, err := c.Decrypt("short"); err != ErrInvalidCiphertext {
+		t.Errorf("Expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestNewCipherInvalidKey(t *testing.T) {
+	if _, err := NewCipher(NewCipherOptions{Key: ""}); err == nil {
+		t.Errorf("Expected error for empty key, got nil")
+	}
+	if _, err := NewCipher(NewCipherOptions{Key: "not base64!"}); err == nil {
+		t.Errorf("Expected error for invalid key, got nil")
+	}
+}
@@ -0,0 +1,9 @@
+// This file contains the interfaces for the encryption layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package encryption
+
+type CipherInterface interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: encryption/interfaces.go
+
+// Package encryption is a generated GoMock package.
+package encryption
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCipherInterface is a mock of CipherInterface interface.
+type MockCipherInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCipherInterfaceMockRecorder
+}
+
+// MockCipherInterfaceMockRecorder is the mock recorder for MockCipherInterface.
+type MockCipherInterfaceMockRecorder struct {
+	mock *MockCipherInterface
+}
+
+// NewMockCipherInterface creates a new mock instance.
+func NewMockCipherInterface(ctrl *gomock.Controller) *MockCipherInterface {
+	mock := &MockCipherInterface{ctrl: ctrl}
+	mock.recorder = &MockCipherInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCipherInterface) EXPECT() *MockCipherInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Decrypt mocks base method.
+func (m *MockCipherInterface) Decrypt(ciphertext string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decrypt", ciphertext)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decrypt indicates an expected call of Decrypt.
+func (mr *MockCipherInterfaceMockRecorder) Decrypt(ciphertext interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decrypt", reflect.TypeOf((*MockCipherInterface)(nil).Decrypt), ciphertext)
+}
+
+// Encrypt mocks base method.
+func (m *MockCipherInterface) Encrypt(plaintext string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Encrypt", plaintext)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Encrypt indicates an expected call of Encrypt.
+func (mr *MockCipherInterfaceMockRecorder) Encrypt(plaintext interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encrypt", reflect.TypeOf((*MockCipherInterface)(nil).Encrypt), plaintext)
+}
@@ -0,0 +1,74 @@
+// This file contains the publishers of domain events. The writer publisher
+// is meant for local development and for shipping events with a log
+// collector, the webhook publisher posts every event to one endpoint. The
+// multi publisher combines them.
+package events
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const DefaultWebhookTimeout = time.Second * 10
+
+type WriterPublisher struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+type NewWriterPublisherOptions struct {
+	// Path of the file the events are appended to, one JSON object per
+	// line. Events are written to stdout when empty.
+	Path string
+}
+
+func NewWriterPublisher(opts NewWriterPublisherOptions) (*WriterPublisher, error) {
+	if opts.Path == "" {
+		return &WriterPublisher{writer: os.Stdout}, nil
+	}
+
+	file, err := os.OpenFile(opts.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterPublisher{writer: file}, nil
+}
+
+type WebhookPublisher struct {
+	URL    string
+	Client *http.Client
+}
+
+type NewWebhookPublisherOptions struct {
+	URL string
+	// Timeout of a delivery, defaults to DefaultWebhookTimeout
+	Timeout time.Duration
+}
+
+func NewWebhookPublisher(opts NewWebhookPublisherOptions) *WebhookPublisher {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return &WebhookPublisher{
+		URL:    opts.URL,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+type MultiPublisher struct {
+	Publishers []EventPublisherInterface
+}
+
+type NewMultiPublisherOptions struct {
+	Publishers []EventPublisherInterface
+}
+
+func NewMultiPublisher(opts NewMultiPublisherOptions) *MultiPublisher {
+	return &MultiPublisher{
+		Publishers: opts.Publishers,
+	}
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEvent() Event {
+	return Event{
+		Id:         1,
+		Type:       TypeUserRegistered,
+		UserId:     1,
+		OccurredAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Data:       json.RawMessage(`{"userId":1,"phoneNumber":"+628123456789","fullName":"John Doe","status":"active"}`),
+	}
+}
+
+func TestWriterPublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	p, err := NewWriterPublisher(NewWriterPublisherOptions{Path: path})
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := p.Publish(context.Background(), testEvent()); err != nil {
+			t.Errorf("Expected nil, got %s", err.Error())
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Errorf("Expected a JSON event, got %s", lines[0])
+	}
+	if event.Id != 1 || event.Type != TypeUserRegistered || !event.OccurredAt.Equal(testEvent().OccurredAt) {
+		t.Errorf("Expected the published event, got %v", event)
+	}
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	tests := []struct {
+		caseName      string
+		status        int
+		expectedError bool
+	}{
+		{
+			caseName:      "Delivered",
+			status:        http.StatusNoContent,
+			expectedError: false,
+		},
+		{
+			caseName:      "Receiver failed",
+			status:        http.StatusInternalServerError,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(test.status)
+			}))
+			defer receiver.Close()
+
+			p := NewWebhookPublisher(NewWebhookPublisherOptions{URL: receiver.URL})
+			err := p.Publish(context.Background(), testEvent())
+			if (err != nil) != test.expectedError {
+				t.Errorf("Expected error %v, got %v", test.expectedError, err)
+			}
+
+			if received == nil {
+				t.Fatalf("Expected a delivery")
+			}
+			if received.Header.Get(HeaderEventId) != "1" || received.Header.Get(HeaderEventType) != TypeUserRegistered {
+				t.Errorf("Expected event headers, got %v", received.Header)
+			}
+			var event Event
+			if err := json.Unmarshal(body, &event); err != nil || event.Id != 1 {
+				t.Errorf("Expected the event as body, got %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+// This file contains the interfaces for the events layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package events
+
+import "context"
+
+type EventPublisherInterface interface {
+	// Publish returns nil only once the event was delivered, the event is
+	// retried otherwise
+	Publish(ctx context.Context, event Event) error
+}
@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: events/interfaces.go
+
+// Package events is a generated GoMock package.
+package events
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockEventPublisherInterface is a mock of EventPublisherInterface interface.
+type MockEventPublisherInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventPublisherInterfaceMockRecorder
+}
+
+// MockEventPublisherInterfaceMockRecorder is the mock recorder for MockEventPublisherInterface.
+type MockEventPublisherInterfaceMockRecorder struct {
+	mock *MockEventPublisherInterface
+}
+
+// NewMockEventPublisherInterface creates a new mock instance.
+func NewMockEventPublisherInterface(ctrl *gomock.Controller) *MockEventPublisherInterface {
+	mock := &MockEventPublisherInterface{ctrl: ctrl}
+	mock.recorder = &MockEventPublisherInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventPublisherInterface) EXPECT() *MockEventPublisherInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockEventPublisherInterface) Publish(ctx context.Context, event Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockEventPublisherInterfaceMockRecorder) Publish(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockEventPublisherInterface)(nil).Publish), ctx, event)
+}
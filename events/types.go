@@ -0,0 +1,51 @@
+// This file contains the domain events published to other services.
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Values of Event.Type
+const (
+	TypeUserRegistered = "user.registered"
+	TypeUserUpdated    = "user.updated"
+	TypeUserLoggedIn   = "user.logged_in"
+)
+
+// Event is delivered at least once, consumers should ignore an id they
+// have seen before. Events of a user are not guaranteed to arrive in order
+// when a delivery is retried, OccurredAt tells the order.
+type Event struct {
+	Id         int             `json:"id"`
+	Type       string          `json:"type"`
+	UserId     int             `json:"userId"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// UserRegistered is the data of TypeUserRegistered, the user still has to
+// verify the phone number when the status is pending_verification
+type UserRegistered struct {
+	UserId      int    `json:"userId"`
+	PhoneNumber string `json:"phoneNumber"`
+	FullName    string `json:"fullName"`
+	Status      string `json:"status"`
+}
+
+// UserUpdated is the data of TypeUserUpdated, only the changed fields are
+// set
+type UserUpdated struct {
+	UserId      int     `json:"userId"`
+	PhoneNumber *string `json:"phoneNumber,omitempty"`
+	FullName    *string `json:"fullName,omitempty"`
+	Status      *string `json:"status,omitempty"`
+}
+
+// UserLoggedIn is the data of TypeUserLoggedIn
+type UserLoggedIn struct {
+	UserId int `json:"userId"`
+	// Method is the factor which completed the login
+	Method     string `json:"method"`
+	LoginCount int    `json:"loginCount"`
+}
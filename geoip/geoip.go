@@ -0,0 +1,94 @@
+// This file contains the GeoIP database used to locate the IP address of a
+// login. The database is a local CSV file in the format of the DB-IP "IP to
+// City Lite" download, so no lookup leaves the service:
+//
+//	ip_start,ip_end,continent,country,stateprov,city,latitude,longitude
+package geoip
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+)
+
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 code
+	Country   string
+	City      string
+	Latitude  float64
+	Longitude float64
+}
+
+type Database struct {
+	// ranges are sorted by start address and don't overlap
+	ranges []ipRange
+}
+
+type ipRange struct {
+	start    netip.Addr
+	end      netip.Addr
+	location Location
+}
+
+type NewDatabaseOptions struct {
+	// Path of the CSV file, an empty path gives an empty database which
+	// locates nothing.
+	Path string
+}
+
+func NewDatabase(opts NewDatabaseOptions) (*Database, error) {
+	if opts.Path == "" {
+		return &Database{}, nil
+	}
+	f, err := os.Open(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadDatabase(f)
+}
+
+func LoadDatabase(r io.Reader) (*Database, error) {
+	d := &Database{}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 8
+	reader.ReuseRecord = true
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, errStart := netip.ParseAddr(record[0])
+		end, errEnd := netip.ParseAddr(record[1])
+		latitude, errLatitude := strconv.ParseFloat(record[6], 64)
+		longitude, errLongitude := strconv.ParseFloat(record[7], 64)
+		for _, err := range []error{errStart, errEnd, errLatitude, errLongitude} {
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		d.ranges = append(d.ranges, ipRange{
+			start: start.Unmap(),
+			end:   end.Unmap(),
+			location: Location{
+				Country:   record[3],
+				City:      record[5],
+				Latitude:  latitude,
+				Longitude: longitude,
+			},
+		})
+	}
+	sort.Slice(d.ranges, func(i, j int) bool { return d.ranges[i].start.Less(d.ranges[j].start) })
+
+	return d, nil
+}
@@ -0,0 +1,29 @@
+package geoip
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// Lookup returns the location of the IP address, false for unknown and
+// invalid addresses.
+func (d *Database) Lookup(ip string) (Location, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Location{}, false
+	}
+	addr = addr.Unmap()
+
+	// First range starting after the address, the candidate is the one
+	// before it
+	i := sort.Search(len(d.ranges), func(i int) bool { return addr.Less(d.ranges[i].start) })
+	if i == 0 {
+		return Location{}, false
+	}
+	r := d.ranges[i-1]
+	if r.end.Less(addr) {
+		return Location{}, false
+	}
+
+	return r.location, true
+}
@@ -0,0 +1,60 @@
+package geoip
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDatabase = `1.0.0.0,1.0.0.255,OC,AU,Queensland,Brisbane,-27.4679,153.028
+36.64.0.0,36.95.255.255,AS,ID,Jakarta,Jakarta,-6.2146,106.845
+8.8.8.0,8.8.8.255,NA,US,California,Mountain View,37.4223,-122.085
+2001:4860::,2001:4860:ffff:ffff:ffff:ffff:ffff:ffff,NA,US,California,Mountain View,37.4223,-122.085
+`
+
+func TestLookup(t *testing.T) {
+	d, err := LoadDatabase(strings.NewReader(testDatabase))
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+
+	tests := []struct {
+		ip              string
+		expected        bool
+		expectedCountry string
+	}{
+		{ip: "36.70.1.2", expected: true, expectedCountry: "ID"},
+		{ip: "8.8.8.8", expected: true, expectedCountry: "US"},
+		{ip: "1.0.0.0", expected: true, expectedCountry: "AU"},
+		{ip: "::ffff:36.64.0.1", expected: true, expectedCountry: "ID"},
+		{ip: "2001:4860:4860::8888", expected: true, expectedCountry: "US"},
+		{ip: "8.8.9.1", expected: false},
+		{ip: "0.0.0.1", expected: false},
+		{ip: "invalid", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			location, ok := d.Lookup(test.ip)
+			if ok != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, ok)
+			}
+			if location.Country != test.expectedCountry {
+				t.Errorf("Expected %s, got %s", test.expectedCountry, location.Country)
+			}
+		})
+	}
+}
+
+func TestLoadDatabaseInvalid(t *testing.T) {
+	if _, err := LoadDatabase(strings.NewReader("1.0.0.0,invalid,OC,AU,,,0,0\n")); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	d, err := NewDatabase(NewDatabaseOptions{})
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if _, ok := d.Lookup("8.8.8.8"); ok {
+		t.Errorf("Expected empty database")
+	}
+}
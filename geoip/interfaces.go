@@ -0,0 +1,8 @@
+// This file contains the interfaces for the geoip layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package geoip
+
+type DatabaseInterface interface {
+	Lookup(ip string) (Location, bool)
+}
@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: geoip/interfaces.go
+
+// Package geoip is a generated GoMock package.
+package geoip
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDatabaseInterface is a mock of DatabaseInterface interface.
+type MockDatabaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDatabaseInterfaceMockRecorder
+}
+
+// MockDatabaseInterfaceMockRecorder is the mock recorder for MockDatabaseInterface.
+type MockDatabaseInterfaceMockRecorder struct {
+	mock *MockDatabaseInterface
+}
+
+// NewMockDatabaseInterface creates a new mock instance.
+func NewMockDatabaseInterface(ctrl *gomock.Controller) *MockDatabaseInterface {
+	mock := &MockDatabaseInterface{ctrl: ctrl}
+	mock.recorder = &MockDatabaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDatabaseInterface) EXPECT() *MockDatabaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Lookup mocks base method.
+func (m *MockDatabaseInterface) Lookup(ip string) (Location, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", ip)
+	ret0, _ := ret[0].(Location)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Lookup indicates an expected call of Lookup.
+func (mr *MockDatabaseInterfaceMockRecorder) Lookup(ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockDatabaseInterface)(nil).Lookup), ip)
+}
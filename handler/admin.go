@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultUsersLimit = 20
+	maxUsersLimit     = 100
+	// Filters longer than the columns can't match anything
+	maxPhonePrefixLength = 50
+	maxNameFilterLength  = 60
+)
+
+var userStatuses = map[string]bool{
+	repository.UserStatusActive:              true,
+	repository.UserStatusPendingVerification: true,
+	repository.UserStatusSuspended:           true,
+}
+
+// (GET /api/v1/admin/users)
+func (s *Server) ListUsers(ctx echo.Context, params generated.ListUsersParams) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	input := repository.ListUsersInput{
+		Limit:       defaultUsersLimit,
+		CreatedFrom: params.CreatedFrom,
+		CreatedTo:   params.CreatedTo,
+	}
+	if params.Limit != nil {
+		input.Limit = *params.Limit
+	}
+	if input.Limit < 1 || input.Limit > maxUsersLimit {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "limit must be between 1 and 100",
+		})
+	}
+	if params.Before != nil {
+		input.BeforeId = *params.Before
+	}
+	if input.BeforeId < 0 {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid cursor",
+		})
+	}
+	if params.PhonePrefix != nil {
+		input.PhoneNumberPrefix = *params.PhonePrefix
+	}
+	if params.Name != nil {
+		input.Name = *params.Name
+	}
+	if len(input.PhoneNumberPrefix) > maxPhonePrefixLength || len(input.Name) > maxNameFilterLength {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Filter is too long",
+		})
+	}
+	if params.Status != nil {
+		if !userStatuses[*params.Status] {
+			return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+				Message: "Invalid status",
+			})
+		}
+		input.Status = *params.Status
+	}
+
+	// Looking at users is audited too, no data is returned unrecorded
+	if _, err := s.Repository.CreateAuditEntry(ctx.Request().Context(), auditEntry(ctx, claims.UserId, repository.AuditActionUserList)); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to record audit entry", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to record audit entry",
+		})
+	}
+
+	// One more user than asked tells whether there is a next page
+	limit := input.Limit
+	input.Limit++
+	resp, err := s.Repository.ListUsers(ctx.Request().Context(), input)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to list users", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to list users",
+		})
+	}
+
+	var nextCursor *int
+	if len(resp.Users) > limit {
+		resp.Users = resp.Users[:limit]
+		nextCursor = &resp.Users[limit-1].Id
+	}
+
+	users := make([]generated.AdminUser, 0, len(resp.Users))
+	for _, user := range resp.Users {
+		users = append(users, adminUser(user))
+	}
+
+	return ctx.JSON(http.StatusOK, generated.AdminUsersResponse{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
+
+// (GET /api/v1/admin/users/{id})
+func (s *Server) GetAdminUser(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	resp, err := s.Repository.GetUserDetails(ctx.Request().Context(), repository.GetUserDetailsInput{
+		UserId: id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get user", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get user",
+		})
+	}
+
+	roles, err := s.Repository.GetUserRoles(ctx.Request().Context(), repository.GetUserRolesInput{
+		UserId: id,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get roles", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get roles",
+		})
+	}
+
+	entry := auditEntry(ctx, claims.UserId, repository.AuditActionUserView)
+	entry.SubjectId = id
+	if _, err := s.Repository.CreateAuditEntry(ctx.Request().Context(), entry); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to record audit entry", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to record audit entry",
+		})
+	}
+
+	user := adminUser(resp.User)
+	userRoles := roles.Roles
+	if userRoles == nil {
+		userRoles = []string{}
+	}
+	user.Roles = &userRoles
+
+	return ctx.JSON(http.StatusOK, user)
+}
+
+// (POST /api/v1/admin/users/{id}/suspend)
+func (s *Server) SuspendUser(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	// Nobody would be left to unsuspend the last administrator
+	if claims.UserId == id {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "You can't suspend yourself",
+		})
+	}
+
+	resp, err := s.Repository.SuspendUser(ctx.Request().Context(), repository.SuspendUserInput{
+		UserId: id,
+		Audit:  auditEntry(ctx, claims.UserId, repository.AuditActionUserSuspend),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to suspend user", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to suspend user",
+		})
+	}
+	if !resp.Suspended {
+		return ctx.JSON(http.StatusConflict, errorResponse(ErrCodeUserStatusConflict, "User is not active"))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// (POST /api/v1/admin/users/{id}/unsuspend)
+func (s *Server) UnsuspendUser(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	resp, err := s.Repository.UnsuspendUser(ctx.Request().Context(), repository.UnsuspendUserInput{
+		UserId: id,
+		Audit:  auditEntry(ctx, claims.UserId, repository.AuditActionUserUnsuspend),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to unsuspend user", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to unsuspend user",
+		})
+	}
+	if !resp.Unsuspended {
+		return ctx.JSON(http.StatusConflict, errorResponse(ErrCodeUserStatusConflict, "User is not suspended"))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// (POST /api/v1/admin/users/{id}/password-reset)
+func (s *Server) ForcePasswordReset(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	resp, err := s.Repository.ForcePasswordReset(ctx.Request().Context(), repository.ForcePasswordResetInput{
+		UserId: id,
+		Audit:  auditEntry(ctx, claims.UserId, repository.AuditActionUserPasswordReset),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to reset password", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to reset password",
+		})
+	}
+
+	if err := s.Notifier.Send(ctx.Request().Context(), notifier.Message{
+		PhoneNumber: resp.PhoneNumber,
+		Kind:        notifier.KindSecurityNotice,
+		Text:        "Your password was reset by our support team. Login with a code sent to this number and choose a new password.",
+	}); err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to send password reset notice", "error", err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// (POST /api/v1/admin/users/{id}/sessions/revoke)
+func (s *Server) RevokeUserSessions(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	if _, err := s.Repository.RevokeUserSessions(ctx.Request().Context(), repository.RevokeUserSessionsInput{
+		UserId: id,
+		Audit:  auditEntry(ctx, claims.UserId, repository.AuditActionUserSessionsRevoke),
+	}); errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	} else if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to revoke sessions", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to revoke sessions",
+		})
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func adminUser(user repository.User) generated.AdminUser {
+	return generated.AdminUser{
+		Id:                    user.Id,
+		PhoneNumber:           user.PhoneNumber,
+		FullName:              user.FullName,
+		Status:                user.Status,
+		MfaEnabled:            user.MfaEnabled,
+		PasswordResetRequired: user.PasswordResetRequired,
+		SuccessLoginCount:     user.SuccessLoginCount,
+		CreatedAt:             user.CreatedAt,
+		UpdatedAt:             user.UpdatedAt,
+	}
+}
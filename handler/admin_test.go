@@ -0,0 +1,389 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestListUsers(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, Permissions: []string{"users:read"}})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+	expectAudit := func() {
+		m.
+			EXPECT().
+			CreateAuditEntry(gomock.Any(), repository.AuditEntry{ActorId: 1, Action: repository.AuditActionUserList, IpAddress: "192.0.2.1"}).
+			Return(repository.CreateAuditEntryOutput{Id: 1}, nil)
+	}
+
+	// Test cases
+	tests := []struct {
+		caseName           string
+		query              string
+		mockFunc           func()
+		expectedCode       int
+		expectedUsers      int
+		expectedNextCursor *int
+	}{
+		{
+			caseName: "Positive case",
+			query:    "?limit=2&phonePrefix=%2B62812&status=active",
+			mockFunc: func() {
+				expectAudit()
+				m.
+					EXPECT().
+					ListUsers(gomock.Any(), repository.ListUsersInput{
+						PhoneNumberPrefix: "+62812",
+						Status:            repository.UserStatusActive,
+						Limit:             3,
+					}).
+					Return(repository.ListUsersOutput{
+						Users: []repository.User{{Id: 9}, {Id: 7}, {Id: 5}},
+					}, nil)
+			},
+			expectedCode:       http.StatusOK,
+			expectedUsers:      2,
+			expectedNextCursor: func() *int { id := 7; return &id }(),
+		},
+		{
+			caseName: "Last page",
+			query:    "?before=5",
+			mockFunc: func() {
+				expectAudit()
+				m.
+					EXPECT().
+					ListUsers(gomock.Any(), repository.ListUsersInput{BeforeId: 5, Limit: 21}).
+					Return(repository.ListUsersOutput{
+						Users: []repository.User{{Id: 3}},
+					}, nil)
+			},
+			expectedCode:  http.StatusOK,
+			expectedUsers: 1,
+		},
+		{
+			caseName:     "Invalid status",
+			query:        "?status=deleted",
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName:     "Invalid limit",
+			query:        "?limit=1000",
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Audit entry not recorded",
+			query:    "",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					CreateAuditEntry(gomock.Any(), gomock.Any()).
+					Return(repository.CreateAuditEntryOutput{}, errors.New("database is down"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users"+test.query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			test.mockFunc()
+
+			e.ServeHTTP(rec, req)
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp generated.AdminUsersResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if len(resp.Users) != test.expectedUsers {
+				t.Errorf("Expected %d users, got %d", test.expectedUsers, len(resp.Users))
+			}
+			if (resp.NextCursor == nil) != (test.expectedNextCursor == nil) ||
+				(resp.NextCursor != nil && *resp.NextCursor != *test.expectedNextCursor) {
+				t.Errorf("Expected cursor %v, got %v", test.expectedNextCursor, resp.NextCursor)
+			}
+		})
+	}
+}
+
+func TestGetAdminUser(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, Permissions: []string{"users:read"}})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserDetails(gomock.Any(), repository.GetUserDetailsInput{UserId: 2}).
+					Return(repository.GetUserDetailsOutput{
+						User: repository.User{Id: 2, PhoneNumber: "+628123456789", Status: repository.UserStatusActive},
+					}, nil)
+				m.
+					EXPECT().
+					GetUserRoles(gomock.Any(), repository.GetUserRolesInput{UserId: 2}).
+					Return(repository.GetUserRolesOutput{Roles: []string{"support"}}, nil)
+				m.
+					EXPECT().
+					CreateAuditEntry(gomock.Any(), repository.AuditEntry{ActorId: 1, SubjectId: 2, Action: repository.AuditActionUserView, IpAddress: "192.0.2.1"}).
+					Return(repository.CreateAuditEntryOutput{Id: 1}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "User not found",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserDetails(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserDetailsOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.GetAdminUser(c, 2); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, Permissions: []string{"users:write"}})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		userId       int
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			userId:   2,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					SuspendUser(gomock.Any(), repository.SuspendUserInput{
+						UserId: 2,
+						Audit:  repository.AuditEntry{ActorId: 1, Action: repository.AuditActionUserSuspend, IpAddress: "192.0.2.1"},
+					}).
+					Return(repository.SuspendUserOutput{Suspended: true}, nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			caseName:     "Suspend yourself",
+			userId:       1,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "User not active",
+			userId:   2,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					SuspendUser(gomock.Any(), gomock.Any()).
+					Return(repository.SuspendUserOutput{Suspended: false}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			caseName: "User not found",
+			userId:   2,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					SuspendUser(gomock.Any(), gomock.Any()).
+					Return(repository.SuspendUserOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.SuspendUser(c, test.userId); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestForcePasswordReset(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, Permissions: []string{"users:write"}})
+
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+	m.
+		EXPECT().
+		ForcePasswordReset(gomock.Any(), repository.ForcePasswordResetInput{
+			UserId: 2,
+			Audit:  repository.AuditEntry{ActorId: 1, Action: repository.AuditActionUserPasswordReset, IpAddress: "192.0.2.1"},
+		}).
+		Return(repository.ForcePasswordResetOutput{PhoneNumber: "+628123456789"}, nil)
+
+	e := echo.New()
+	n := notifier.NewCapturingNotifier()
+	server := NewServer(NewServerOptions{
+		Repository: m,
+		Helper:     h,
+		Notifier:   n,
+		Echo:       e,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := server.ForcePasswordReset(c, 2); err != nil {
+		t.Errorf("Error: %v", err)
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+
+	// The user learns the password can't be used anymore
+	messages := n.Messages()
+	if len(messages) != 1 || messages[0].PhoneNumber != "+628123456789" || messages[0].Kind != notifier.KindSecurityNotice {
+		t.Errorf("Expected a security notice to +628123456789, got %v", messages)
+	}
+}
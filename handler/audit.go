@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/asrul10/UserService/logging"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// Request ids from clients longer than this are replaced
+const maxRequestIdLength = 64
+
+// RequestID gives every request an id, returned in the X-Request-ID header
+// and recorded with the audit entries and log records of the request. An id
+// set by a proxy in front of the service is kept.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			id := ctx.Request().Header.Get(echo.HeaderXRequestID)
+			if id == "" || len(id) > maxRequestIdLength || !isPrintable(id) {
+				b := make([]byte, 16)
+				if _, err := rand.Read(b); err != nil {
+					return err
+				}
+				id = hex.EncodeToString(b)
+			}
+			ctx.Response().Header().Set(echo.HeaderXRequestID, id)
+			req := ctx.Request()
+			ctx.SetRequest(req.WithContext(logging.WithRequestId(req.Context(), id)))
+			return next(ctx)
+		}
+	}
+}
+
+func isPrintable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// auditEntry records an action of the user, actorId is zero for anonymous
+// requests
+func auditEntry(ctx echo.Context, actorId int, action string) repository.AuditEntry {
+	return repository.AuditEntry{
+		ActorId:   actorId,
+		Action:    action,
+		RequestId: ctx.Response().Header().Get(echo.HeaderXRequestID),
+		IpAddress: ctx.RealIP(),
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asrul10/UserService/logging"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		caseName     string
+		requestId    string
+		expectedKept bool
+	}{
+		{
+			caseName:     "Generated id",
+			requestId:    "",
+			expectedKept: false,
+		},
+		{
+			caseName:     "Id of a proxy",
+			requestId:    "f3b0c9a2-proxy",
+			expectedKept: true,
+		},
+		{
+			caseName:     "Too long id",
+			requestId:    strings.Repeat("a", maxRequestIdLength+1),
+			expectedKept: false,
+		},
+		{
+			caseName:     "Id with spaces",
+			requestId:    "an id",
+			expectedKept: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			e := echo.New()
+			var entry repository.AuditEntry
+			var logged string
+			e.Use(RequestID())
+			e.GET("/", func(ctx echo.Context) error {
+				entry = auditEntry(ctx, 1, repository.AuditActionUserUpdate)
+				logged = logging.RequestId(ctx.Request().Context())
+				return ctx.NoContent(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.requestId != "" {
+				req.Header.Set(echo.HeaderXRequestID, test.requestId)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			requestId := rec.Header().Get(echo.HeaderXRequestID)
+			if (requestId == test.requestId) != test.expectedKept {
+				t.Errorf("Expected kept %v, got id %q", test.expectedKept, requestId)
+			}
+			if requestId == "" || len(requestId) > maxRequestIdLength || !isPrintable(requestId) {
+				t.Errorf("Expected a valid id, got %q", requestId)
+			}
+			if entry.RequestId != requestId {
+				t.Errorf("Expected audit entry with id %q, got %q", requestId, entry.RequestId)
+			}
+			if logged != requestId {
+				t.Errorf("Expected request context with id %q, got %q", requestId, logged)
+			}
+		})
+	}
+}
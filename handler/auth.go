@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errTokenRevoked = errors.New("token revoked")
+	errTokenReused  = errors.New("token reused")
+)
+
+// authenticate verifies the bearer token of the request and that it was
+// not revoked since it was issued.
+func (s *Server) authenticate(ctx echo.Context) (helper.TokenClaims, error) {
+	token := s.Helper.GetToken(ctx.Request().Header.Get("Authorization"))
+	claims, err := s.Helper.VerifyToken(ctx.Request().Context(), token)
+	if err != nil {
+		s.tokenVerificationFailed(helper.AccessTokenType, err)
+		return claims, err
+	}
+
+	state, err := s.Repository.GetTokenVersion(ctx.Request().Context(), repository.GetTokenVersionInput{
+		UserId: claims.UserId,
+	})
+	if err != nil {
+		return claims, err
+	}
+	if state.TokenVersion != claims.TokenVersion {
+		s.tokenVerificationFailed(helper.AccessTokenType, errTokenRevoked)
+		return claims, errTokenRevoked
+	}
+
+	// Tokens issued before sessions existed have no session to check
+	if claims.SessionId != 0 {
+		if err := s.checkSession(ctx, claims); err != nil {
+			if errors.Is(err, errTokenRevoked) {
+				s.tokenVerificationFailed(helper.AccessTokenType, err)
+			}
+			return claims, err
+		}
+	}
+
+	return claims, nil
+}
@@ -1,13 +1,14 @@
 package handler
 
 import (
-	"context"
-	"log"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/password"
 	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/risk"
 	"github.com/labstack/echo/v4"
 )
 
@@ -28,34 +29,97 @@ func (s *Server) RegisterUser(ctx echo.Context) error {
 		})
 	}
 
+	// Normalize phone number to E.164, new users are limited to the
+	// allowed regions
+	number, err := s.PhoneParser.Parse(user.PhoneNumber)
+	if err == nil {
+		err = s.PhoneParser.CheckRegion(number)
+	}
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, phoneNumberErrorResponse(err))
+	}
+	user.PhoneNumber = number.E164
+
+	// Validate password against the policy
+	if violations := s.PasswordPolicy.Validate(password.ValidateInput{
+		Password:    user.Password,
+		PhoneNumber: user.PhoneNumber,
+		FullName:    user.FullName,
+	}); len(violations) > 0 {
+		return ctx.JSON(http.StatusBadRequest, passwordPolicyErrorResponse(violations))
+	}
+	if s.PasswordBlocklist.IsBlocked(user.Password) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse(ErrCodePasswordBreached, "Password is too common or has appeared in a data breach"))
+	}
+
 	// Check if phone number already registered
-	if _, err := s.Repository.GetUserByPhoneNumber(context.Background(), repository.GetUserByPhoneNumberInput{
+	if existing, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), repository.GetUserByPhoneNumberInput{
 		PhoneNumber: user.PhoneNumber,
 	}); err == nil {
-		return ctx.JSON(http.StatusConflict, generated.ErrorResponse{
-			Message: "Phone number already registered",
-		})
+		if !s.isExpiredRegistration(existing) {
+			return ctx.JSON(http.StatusConflict, generated.ErrorResponse{
+				Message: "Phone number already registered",
+			})
+		}
+		// The previous registration was never verified, the number can be
+		// claimed again.
+		if _, err := s.Repository.DeletePendingUser(ctx.Request().Context(), repository.DeletePendingUserInput{
+			UserId: existing.UserId,
+		}); err != nil {
+			s.Logger.ErrorContext(ctx.Request().Context(), "Failed to release expired registration", "error", err)
+			return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+				Message: "Failed to release expired registration",
+			})
+		}
 	}
 
 	// Create user
-	hashPassword, err := s.Helper.HashPassword(user.Password)
+	hashPassword, err := s.Helper.HashPassword(ctx.Request().Context(), user.Password)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
 			Message: "Failed to hash password",
 		})
 	}
+	code, err := s.Helper.GenerateOTP(ctx.Request().Context(), otpLength)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate verification code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate verification code",
+		})
+	}
+	codeHash, err := s.Helper.HashPassword(ctx.Request().Context(), code)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to hash verification code",
+		})
+	}
+	codeExpiresAt := time.Now().Add(otpExpiration)
 	resp, err := s.Repository.CreateUser(ctx.Request().Context(), repository.CreateUserInput{
-		PhoneNumber: user.PhoneNumber,
-		FullName:    user.FullName,
-		Password:    hashPassword,
+		PhoneNumber:           user.PhoneNumber,
+		FullName:              user.FullName,
+		Password:              hashPassword,
+		Status:                repository.UserStatusPendingVerification,
+		VerificationCodeHash:  codeHash,
+		VerificationExpiresAt: codeExpiresAt,
+		Audit:                 auditEntry(ctx, 0, repository.AuditActionUserCreate),
 	})
 
 	if err != nil {
-		log.Println(err)
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create user", "error", err)
 		return ctx.JSON(http.StatusInternalServerError, err)
 	}
+	s.Metrics.IncRegistrations()
 
-	return ctx.JSON(http.StatusOK, resp)
+	// The user can request a new code if this one is not delivered
+	if err := s.sendVerificationCode(ctx, user.PhoneNumber, code); err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to send verification code", "error", err)
+	}
+
+	return ctx.JSON(http.StatusOK, generated.RegisterUserResponse{
+		UserId:                resp.UserId,
+		Status:                repository.UserStatusPendingVerification,
+		VerificationExpiresAt: &codeExpiresAt,
+	})
 }
 
 // (POST /users/login)
@@ -75,58 +139,126 @@ func (s *Server) LoginUser(ctx echo.Context) error {
 		})
 	}
 
+	// Normalize phone number to E.164
+	number, err := s.PhoneParser.Parse(user.PhoneNumber)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, phoneNumberErrorResponse(err))
+	}
+	user.PhoneNumber = number.E164
+
 	// Get user by phone number
 	resp, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), repository.GetUserByPhoneNumberInput{
 		PhoneNumber: user.PhoneNumber,
 	})
 	if err != nil {
+		s.recordLoginEvent(ctx, 0, repository.LoginMethodPassword, repository.LoginFailureUserNotFound)
 		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
 			Message: "User not found",
 		})
 	}
 
 	// Check if password is correct
-	if err := s.Helper.ComparePassword(user.Password, resp.Password); err != nil {
+	if err := s.Helper.ComparePassword(ctx.Request().Context(), user.Password, resp.Password); err != nil {
+		s.recordLoginEvent(ctx, resp.UserId, repository.LoginMethodPassword, repository.LoginFailureInvalidPassword)
 		return ctx.JSON(http.StatusUnauthorized, generated.ErrorResponse{
 			Message: "Invalid password",
 		})
 	}
 
-	// Generate token
-	token := ""
-	if err := s.Helper.GenerateAccessToken(&token, resp.UserId); err != nil {
-		log.Println(err)
+	// Unverified accounts can't login until the phone number is verified
+	if resp.Status == repository.UserStatusPendingVerification {
+		s.recordLoginEvent(ctx, resp.UserId, repository.LoginMethodPassword, repository.LoginFailureAccountNotVerified)
+		return ctx.JSON(http.StatusForbidden, errorResponse(ErrCodeAccountNotVerified, "Phone number is not verified"))
+	}
+	if resp.Status == repository.UserStatusSuspended {
+		s.recordLoginEvent(ctx, resp.UserId, repository.LoginMethodPassword, repository.LoginFailureAccountSuspended)
+		return ctx.JSON(http.StatusForbidden, errorResponse(ErrCodeAccountSuspended, "Account is suspended"))
+	}
+
+	// A password reset by support can't be used anymore, the user logs in
+	// with a code and changes it
+	if resp.PasswordResetRequired {
+		s.recordLoginEvent(ctx, resp.UserId, repository.LoginMethodPassword, repository.LoginFailurePasswordReset)
+		return ctx.JSON(http.StatusForbidden, errorResponse(ErrCodePasswordResetRequired, "Password must be changed, login with a code sent to the phone number"))
+	}
+
+	tokenClaims := helper.TokenClaims{
+		UserId:       resp.UserId,
+		TokenVersion: resp.TokenVersion,
+	}
+
+	// The user is told about a login from an unfamiliar device whichever
+	// step comes next
+	assessment := s.assessLogin(ctx, resp.UserId)
+	if assessment.Decision != risk.DecisionAllow {
+		s.notifyRiskyLogin(ctx, user.PhoneNumber, assessment)
+	}
+
+	// The real tokens are only issued after the second factor, which
+	// already proves more than the step-up code
+	if resp.MfaEnabled {
+		s.recordLoginEvent(ctx, resp.UserId, repository.LoginMethodPassword, repository.LoginFailureMfaRequired)
+		return s.mfaChallenge(ctx, tokenClaims)
+	}
+	if assessment.Decision == risk.DecisionStepUp {
+		return s.stepUpChallenge(ctx, resp.UserId, user.PhoneNumber)
+	}
+
+	return s.completeLogin(ctx, tokenClaims, repository.LoginMethodPassword)
+}
+
+// completeLogin starts a session for the device and issues its access and
+// refresh tokens. The method is the factor which completed the login.
+func (s *Server) completeLogin(ctx echo.Context, tokenClaims helper.TokenClaims, method string) error {
+	refreshTokenId, err := s.Helper.GenerateTokenId(ctx.Request().Context())
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate refresh token", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate refresh token",
+		})
+	}
+
+	session, err := s.Repository.CreateSession(ctx.Request().Context(), repository.CreateSessionInput{
+		UserId:         tokenClaims.UserId,
+		UserAgent:      truncate(ctx.Request().UserAgent(), maxUserAgentLength),
+		IpAddress:      ctx.RealIP(),
+		RefreshTokenId: refreshTokenId,
+		ExpiresAt:      time.Now().Add(s.RefreshTokenTTL),
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create session", "error", err)
 		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
-			Message: "Failed to generate token",
+			Message: "Failed to create session",
 		})
 	}
-	refreshToken := ""
-	if err := s.Helper.GenerateRefreshToken(&refreshToken, resp.UserId); err != nil {
-		log.Println(err)
+	tokenClaims.SessionId = session.Id
+	tokenClaims.RefreshTokenId = refreshTokenId
+
+	tokens, err := s.issueTokens(ctx, tokenClaims)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate token", "error", err)
 		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
-			Message: "Failed to generate refresh token",
+			Message: "Failed to generate token",
 		})
 	}
 
 	// Update success login
 	_, err = s.Repository.SuccessLoginCount(ctx.Request().Context(), repository.SuccessLoginCountInput{
-		UserId: resp.UserId,
+		UserId: tokenClaims.UserId,
+		Method: method,
+		Audit:  auditEntry(ctx, tokenClaims.UserId, repository.AuditActionUserLogin),
 	})
 	if err != nil {
-		log.Println(err)
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to record successful login", "error", err)
 	}
+	s.recordLoginEvent(ctx, tokenClaims.UserId, method, "")
 
-	return ctx.JSON(http.StatusOK, generated.LoginUserResponse{
-		UserId:       resp.UserId,
-		AccessToken:  token,
-		RefreshToken: refreshToken,
-	})
+	return ctx.JSON(http.StatusOK, tokens)
 }
 
 // (GET /users/{id})
 func (s *Server) GetUser(ctx echo.Context) error {
-	token := s.Helper.GetToken(ctx.Request().Header.Get("Authorization"))
-	userIdStr, err := s.Helper.VerifyToken(token)
+	claims, err := s.authenticate(ctx)
 	if err != nil {
 		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
 			Message: "Unauthorized",
@@ -134,14 +266,8 @@ func (s *Server) GetUser(ctx echo.Context) error {
 	}
 
 	// Get user by id
-	userId, err := strconv.Atoi(userIdStr)
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Message: "Invalid user id",
-		})
-	}
 	resp, err := s.Repository.GetUserById(ctx.Request().Context(), repository.GetUserByIdInput{
-		UserId: userId,
+		UserId: claims.UserId,
 	})
 	if err != nil {
 		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
@@ -158,20 +284,13 @@ func (s *Server) GetUser(ctx echo.Context) error {
 
 // (PUT /users/{id})
 func (s *Server) UpdateUser(ctx echo.Context) error {
-	token := s.Helper.GetToken(ctx.Request().Header.Get("Authorization"))
-	userIdStr, err := s.Helper.VerifyToken(token)
+	claims, err := s.authenticate(ctx)
 	if err != nil {
 		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
 			Message: "Unauthorized",
 		})
 	}
-
-	userId, err := strconv.Atoi(userIdStr)
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Message: "Invalid user id",
-		})
-	}
+	userId := claims.UserId
 
 	user := new(generated.UpdateUserJSONRequestBody)
 	if err := ctx.Bind(user); err != nil {
@@ -187,6 +306,13 @@ func (s *Server) UpdateUser(ctx echo.Context) error {
 		})
 	}
 
+	// Normalize phone number to E.164
+	number, err := s.PhoneParser.Parse(user.PhoneNumber)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, phoneNumberErrorResponse(err))
+	}
+	user.PhoneNumber = number.E164
+
 	// Prevent updating phone number
 	isChanged, err := s.Repository.IsPhoneNumberChanged(ctx.Request().Context(), repository.IsPhoneNumberChangedInput{
 		UserId:      userId,
@@ -196,9 +322,10 @@ func (s *Server) UpdateUser(ctx echo.Context) error {
 		return ctx.JSON(http.StatusInternalServerError, err)
 	}
 	if isChanged.IsChanged {
-		return ctx.JSON(http.StatusConflict, generated.ErrorResponse{
-			Message: "Phone number cannot be changed",
-		})
+		return ctx.JSON(http.StatusConflict, errorResponse(
+			ErrCodePhoneChangeUnverified,
+			"Phone number cannot be changed directly, use the phone number change verification",
+		))
 	}
 
 	// Update user
@@ -206,6 +333,7 @@ func (s *Server) UpdateUser(ctx echo.Context) error {
 		UserId:      userId,
 		PhoneNumber: user.PhoneNumber,
 		FullName:    user.FullName,
+		Audit:       auditEntry(ctx, userId, repository.AuditActionUserUpdate),
 	})
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, err)
@@ -213,3 +341,134 @@ func (s *Server) UpdateUser(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, resp)
 }
+
+// (PUT /api/v1/users/password)
+func (s *Server) ChangePassword(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+	userId := claims.UserId
+
+	payload := new(generated.ChangePasswordJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	user, err := s.Repository.GetUserById(ctx.Request().Context(), repository.GetUserByIdInput{
+		UserId: userId,
+	})
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	}
+
+	// Check if current password is correct
+	if err := s.Helper.ComparePassword(ctx.Request().Context(), payload.CurrentPassword, user.Password); err != nil {
+		return ctx.JSON(http.StatusUnauthorized, generated.ErrorResponse{
+			Message: "Invalid password",
+		})
+	}
+
+	// Validate new password against the policy
+	if violations := s.PasswordPolicy.Validate(password.ValidateInput{
+		Password:    payload.NewPassword,
+		PhoneNumber: user.PhoneNumber,
+		FullName:    user.FullName,
+	}); len(violations) > 0 {
+		return ctx.JSON(http.StatusBadRequest, passwordPolicyErrorResponse(violations))
+	}
+	if s.PasswordBlocklist.IsBlocked(payload.NewPassword) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse(ErrCodePasswordBreached, "Password is too common or has appeared in a data breach"))
+	}
+
+	hashPassword, err := s.Helper.HashPassword(ctx.Request().Context(), payload.NewPassword)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to hash password",
+		})
+	}
+
+	resp, err := s.Repository.UpdatePasswordById(ctx.Request().Context(), repository.UpdatePasswordByIdInput{
+		UserId:   userId,
+		Password: hashPassword,
+		Audit:    auditEntry(ctx, userId, repository.AuditActionUserPasswordChange),
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to change password", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to change password",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.ChangePasswordResponse{
+		UserId: resp.UserId,
+	})
+}
+
+// (POST /api/v1/password/strength)
+func (s *Server) PasswordStrength(ctx echo.Context) error {
+	payload := new(generated.PasswordStrengthJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	var phoneNumber, fullName string
+	if payload.PhoneNumber != nil {
+		phoneNumber = *payload.PhoneNumber
+	}
+	if payload.FullName != nil {
+		fullName = *payload.FullName
+	}
+
+	estimate := s.PasswordEstimator.Estimate(password.EstimateInput{
+		Password:   payload.Password,
+		UserInputs: []string{phoneNumber, fullName},
+	})
+	violations := s.PasswordPolicy.Validate(password.ValidateInput{
+		Password:    payload.Password,
+		PhoneNumber: phoneNumber,
+		FullName:    fullName,
+	})
+	if s.PasswordBlocklist.IsBlocked(payload.Password) {
+		violations = append(violations, password.Violation{
+			Rule:    password.RuleBlocklisted,
+			Message: "password is too common or has appeared in a data breach",
+		})
+	}
+
+	suggestions := estimate.Suggestions
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+
+	return ctx.JSON(http.StatusOK, generated.PasswordStrengthResponse{
+		Score:        estimate.Score,
+		GuessesLog10: float32(estimate.GuessesLog10),
+		Warning:      estimate.Warning,
+		Suggestions:  suggestions,
+		Patterns:     estimate.Patterns,
+		Violations:   violationDetails(violations),
+	})
+}
@@ -1,15 +1,24 @@
 package handler
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/asrul10/UserService/config"
 	"github.com/asrul10/UserService/generated"
 	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/password"
+	"github.com/asrul10/UserService/phone"
 	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/risk"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 )
@@ -24,6 +33,13 @@ func TestRegisterUser(t *testing.T) {
 		JwtPrivateKeyPath: "../storage/key.pem",
 		JwtPublicKeyPath:  "../storage/key.pem.pub",
 	})
+	p := password.NewPolicy(password.NewPolicyOptions{
+		Config: password.DefaultPolicyConfig(),
+	})
+	b, _ := password.LoadBlocklist(strings.NewReader("password1!"))
+	est := password.NewEstimator(password.NewEstimatorOptions{})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID", AllowedRegions: []string{"ID"}})
+	n := notifier.NewMockNotifierInterface(ctrl)
 
 	// Test cases
 	tests := []struct {
@@ -38,9 +54,15 @@ func TestRegisterUser(t *testing.T) {
 			mockFunc:     func() {},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			caseName:     "Region not allowed",
+			payload:      `{"phoneNumber":"+6591234567","fullName":"test","password":"Test123/"}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			caseName: "Positive case",
-			payload:  `{"phoneNumber":"+62123456789","fullName":"test","password":"Test123/"}`,
+			payload:  `{"phoneNumber":"+628123456789","fullName":"test","password":"Test123/"}`,
 			mockFunc: func() {
 				m.
 					EXPECT().
@@ -50,12 +72,16 @@ func TestRegisterUser(t *testing.T) {
 					EXPECT().
 					CreateUser(gomock.Any(), gomock.Any()).
 					Return(repository.CreateUserOutput{UserId: 1}, nil)
+				n.
+					EXPECT().
+					Send(gomock.Any(), notifierMessageTo("+628123456789")).
+					Return(nil)
 			},
 			expectedCode: http.StatusOK,
 		},
 		{
 			caseName: "Duplicate phone number",
-			payload:  `{"phoneNumber":"+62123456789","fullName":"test","password":"Test123/"}`,
+			payload:  `{"phoneNumber":"+628123456789","fullName":"test","password":"Test123/"}`,
 			mockFunc: func() {
 				m.
 					EXPECT().
@@ -64,6 +90,48 @@ func TestRegisterUser(t *testing.T) {
 			},
 			expectedCode: http.StatusConflict,
 		},
+		{
+			caseName: "Pending registration",
+			payload:  `{"phoneNumber":"+628123456789","fullName":"test","password":"Test123/"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:    1,
+						Status:    repository.UserStatusPendingVerification,
+						CreatedAt: time.Now(),
+					}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			caseName: "Expired pending registration",
+			payload:  `{"phoneNumber":"+628123456789","fullName":"test","password":"Test123/"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:    1,
+						Status:    repository.UserStatusPendingVerification,
+						CreatedAt: time.Now().Add(-config.DefaultRegistrationTTL - time.Minute),
+					}, nil)
+				m.
+					EXPECT().
+					DeletePendingUser(gomock.Any(), repository.DeletePendingUserInput{UserId: 1}).
+					Return(repository.DeletePendingUserOutput{}, nil)
+				m.
+					EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Return(repository.CreateUserOutput{UserId: 2}, nil)
+				n.
+					EXPECT().
+					Send(gomock.Any(), notifierMessageTo("+628123456789")).
+					Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
 		{
 			caseName:     "Invalid phone number",
 			payload:      `{"phoneNumber":"123","fullName":"test","password":"Test123/"}`,
@@ -72,13 +140,40 @@ func TestRegisterUser(t *testing.T) {
 		},
 		{
 			caseName:     "Invalid full name",
-			payload:      `{"phoneNumber":"+62123456789","fullName":"t","password":"Test123/"}`,
+			payload:      `{"phoneNumber":"+628123456789","fullName":"t","password":"Test123/"}`,
 			mockFunc:     func() {},
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			caseName:     "Invalid password",
-			payload:      `{"phoneNumber":"+62123456789","fullName":"test","password":"test"}`,
+			payload:      `{"phoneNumber":"+628123456789","fullName":"test","password":"test"}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "National phone number format",
+			payload:  `{"phoneNumber":"0812-345-6789","fullName":"test","password":"Test123/"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), repository.GetUserByPhoneNumberInput{
+						PhoneNumber: "+628123456789",
+					}).
+					Return(repository.GetUserByPhoneNumberOutput{}, errors.New("not found"))
+				m.
+					EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Return(repository.CreateUserOutput{UserId: 1}, nil)
+				n.
+					EXPECT().
+					Send(gomock.Any(), notifierMessageTo("+628123456789")).
+					Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName:     "Breached password",
+			payload:      `{"phoneNumber":"+628123456789","fullName":"test","password":"Password1!"}`,
 			mockFunc:     func() {},
 			expectedCode: http.StatusBadRequest,
 		},
@@ -89,9 +184,14 @@ func TestRegisterUser(t *testing.T) {
 			// Creating the server
 			e := echo.New()
 			server := NewServer(NewServerOptions{
-				Repository: m,
-				Helper:     h,
-				Echo:       e,
+				Repository:        m,
+				Helper:            h,
+				PasswordPolicy:    p,
+				PasswordBlocklist: b,
+				PasswordEstimator: est,
+				PhoneParser:       ph,
+				Notifier:          n,
+				Echo:              e,
 			})
 			generated.RegisterHandlers(e, server)
 
@@ -126,13 +226,27 @@ func TestLoginUser(t *testing.T) {
 		JwtPrivateKeyPath: "../storage/key.pem",
 		JwtPublicKeyPath:  "../storage/key.pem.pub",
 	})
+	p := password.NewPolicy(password.NewPolicyOptions{
+		Config: password.DefaultPolicyConfig(),
+	})
+	b, _ := password.LoadBlocklist(strings.NewReader("password1!"))
+	est := password.NewEstimator(password.NewEstimatorOptions{})
+	// Users registered before their region was disallowed still log in
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID", AllowedRegions: []string{"ID"}})
+	ra := risk.NewAssessor(risk.NewAssessorOptions{})
+
+	// Earlier logins of the test device, the requests come from 192.0.2.1
+	// without a user agent
+	knownDevice := repository.LoginEvent{Id: 1, Success: true, IpAddress: "192.0.2.1", CreatedAt: time.Now().Add(-time.Hour)}
+	failedAttempt := repository.LoginEvent{Id: 2, FailureReason: repository.LoginFailureInvalidPassword, IpAddress: "192.0.2.1", CreatedAt: time.Now().Add(-time.Minute)}
 
 	// Test cases
 	tests := []struct {
-		caseName     string
-		payload      string
-		mockFunc     func()
-		expectedCode int
+		caseName         string
+		payload          string
+		mockFunc         func()
+		expectedCode     int
+		expectedMessages int
 	}{
 		{
 			caseName:     "Empty payload",
@@ -142,9 +256,9 @@ func TestLoginUser(t *testing.T) {
 		},
 		{
 			caseName: "Positive case",
-			payload:  `{"phoneNumber":"+62123456789","password":"Test123/"}`,
+			payload:  `{"phoneNumber":"+628123456789","password":"Test123/"}`,
 			mockFunc: func() {
-				hashPassword, _ := h.HashPassword("Test123/")
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
 				m.
 					EXPECT().
 					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
@@ -152,31 +266,161 @@ func TestLoginUser(t *testing.T) {
 						UserId:   1,
 						Password: hashPassword,
 					}, nil)
+				m.
+					EXPECT().
+					GetLoginEvents(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginEventsOutput{
+						Events: []repository.LoginEvent{knownDevice},
+					}, nil)
+				m.
+					EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(repository.CreateSessionOutput{Id: 1}, nil)
+				m.
+					EXPECT().
+					GetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserRolesOutput{}, nil)
 				m.
 					EXPECT().
 					SuccessLoginCount(gomock.Any(), gomock.Any()).
 					Return(repository.SuccessLoginCountOutput{
 						UserId: 1,
 					}, nil)
+				expectLoginEvent(t, m, 1, repository.LoginMethodPassword, "")
 			},
 			expectedCode: http.StatusOK,
 		},
+		{
+			caseName: "Number outside of the allowed regions",
+			payload:  `{"phoneNumber":"+6591234567","password":"Test123/"}`,
+			mockFunc: func() {
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:   1,
+						Password: hashPassword,
+					}, nil)
+				m.
+					EXPECT().
+					GetLoginEvents(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginEventsOutput{
+						Events: []repository.LoginEvent{knownDevice},
+					}, nil)
+				m.
+					EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(repository.CreateSessionOutput{Id: 1}, nil)
+				m.
+					EXPECT().
+					GetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserRolesOutput{}, nil)
+				m.
+					EXPECT().
+					SuccessLoginCount(gomock.Any(), gomock.Any()).
+					Return(repository.SuccessLoginCountOutput{
+						UserId: 1,
+					}, nil)
+				expectLoginEvent(t, m, 1, repository.LoginMethodPassword, "")
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "New device",
+			payload:  `{"phoneNumber":"+628123456789","password":"Test123/"}`,
+			mockFunc: func() {
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:   1,
+						Password: hashPassword,
+					}, nil)
+				otherDevice := knownDevice
+				otherDevice.UserAgent = "Firefox"
+				m.
+					EXPECT().
+					GetLoginEvents(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginEventsOutput{
+						Events: []repository.LoginEvent{otherDevice},
+					}, nil)
+				m.
+					EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(repository.CreateSessionOutput{Id: 1}, nil)
+				m.
+					EXPECT().
+					GetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserRolesOutput{}, nil)
+				m.
+					EXPECT().
+					SuccessLoginCount(gomock.Any(), gomock.Any()).
+					Return(repository.SuccessLoginCountOutput{
+						UserId: 1,
+					}, nil)
+				expectLoginEvent(t, m, 1, repository.LoginMethodPassword, "")
+			},
+			expectedCode:     http.StatusOK,
+			expectedMessages: 1,
+		},
+		{
+			caseName: "Burst of failed attempts",
+			payload:  `{"phoneNumber":"+628123456789","password":"Test123/"}`,
+			mockFunc: func() {
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:   1,
+						Password: hashPassword,
+					}, nil)
+				m.
+					EXPECT().
+					GetLoginEvents(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginEventsOutput{
+						Events: []repository.LoginEvent{failedAttempt, failedAttempt, failedAttempt, failedAttempt, failedAttempt, knownDevice},
+					}, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginCodeOutput{}, sql.ErrNoRows)
+				m.
+					EXPECT().
+					UpsertLoginCode(gomock.Any(), gomock.Any()).
+					Return(repository.UpsertLoginCodeOutput{}, nil)
+				m.
+					EXPECT().
+					CreateLoginEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, input repository.CreateLoginEventInput) (repository.CreateLoginEventOutput, error) {
+						if input.FailureReason != repository.LoginFailureStepUpRequired || input.RiskDecision != risk.DecisionStepUp {
+							t.Errorf("Expected step-up event, got %v", input)
+						}
+						return repository.CreateLoginEventOutput{Id: 1}, nil
+					})
+			},
+			expectedCode:     http.StatusAccepted,
+			expectedMessages: 2,
+		},
 		{
 			caseName: "User not found",
-			payload:  `{"phoneNumber":"+62123456789","password":"Test123/"}`,
+			payload:  `{"phoneNumber":"+628123456789","password":"Test123/"}`,
 			mockFunc: func() {
 				m.
 					EXPECT().
 					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
 					Return(repository.GetUserByPhoneNumberOutput{}, errors.New("not found"))
+				expectLoginEvent(t, m, 0, repository.LoginMethodPassword, repository.LoginFailureUserNotFound)
 			},
 			expectedCode: http.StatusNotFound,
 		},
 		{
 			caseName: "Invalid password",
-			payload:  `{"phoneNumber":"+62123456789","password":"WrongPassword12/"}`,
+			payload:  `{"phoneNumber":"+628123456789","password":"WrongPassword12/"}`,
 			mockFunc: func() {
-				hashPassword, _ := h.HashPassword("Test123/")
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
 				m.
 					EXPECT().
 					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
@@ -184,19 +428,101 @@ func TestLoginUser(t *testing.T) {
 						UserId:   1,
 						Password: hashPassword,
 					}, nil)
+				expectLoginEvent(t, m, 1, repository.LoginMethodPassword, repository.LoginFailureInvalidPassword)
 			},
 			expectedCode: http.StatusUnauthorized,
 		},
+		{
+			caseName: "Phone number not verified",
+			payload:  `{"phoneNumber":"+628123456789","password":"Test123/"}`,
+			mockFunc: func() {
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:   1,
+						Password: hashPassword,
+						Status:   repository.UserStatusPendingVerification,
+					}, nil)
+				expectLoginEvent(t, m, 1, repository.LoginMethodPassword, repository.LoginFailureAccountNotVerified)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Account suspended",
+			payload:  `{"phoneNumber":"+628123456789","password":"Test123/"}`,
+			mockFunc: func() {
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:   1,
+						Password: hashPassword,
+						Status:   repository.UserStatusSuspended,
+					}, nil)
+				expectLoginEvent(t, m, 1, repository.LoginMethodPassword, repository.LoginFailureAccountSuspended)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Password reset required",
+			payload:  `{"phoneNumber":"+628123456789","password":"Test123/"}`,
+			mockFunc: func() {
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:                1,
+						Password:              hashPassword,
+						Status:                repository.UserStatusActive,
+						PasswordResetRequired: true,
+					}, nil)
+				expectLoginEvent(t, m, 1, repository.LoginMethodPassword, repository.LoginFailurePasswordReset)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Two-factor authentication enabled",
+			payload:  `{"phoneNumber":"+628123456789","password":"Test123/"}`,
+			mockFunc: func() {
+				hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:     1,
+						Password:   hashPassword,
+						Status:     repository.UserStatusActive,
+						MfaEnabled: true,
+					}, nil)
+				m.
+					EXPECT().
+					GetLoginEvents(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginEventsOutput{}, nil)
+				expectLoginEvent(t, m, 1, repository.LoginMethodPassword, repository.LoginFailureMfaRequired)
+			},
+			expectedCode: http.StatusAccepted,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.caseName, func(t *testing.T) {
 			// Creating the server
 			e := echo.New()
+			n := notifier.NewCapturingNotifier()
 			server := NewServer(NewServerOptions{
-				Repository: m,
-				Helper:     h,
-				Echo:       e,
+				Repository:        m,
+				Helper:            h,
+				PasswordPolicy:    p,
+				PasswordBlocklist: b,
+				PasswordEstimator: est,
+				PhoneParser:       ph,
+				Notifier:          n,
+				RiskAssessor:      ra,
+				Echo:              e,
 			})
 			generated.RegisterHandlers(e, server)
 
@@ -217,6 +543,9 @@ func TestLoginUser(t *testing.T) {
 			if rec.Code != test.expectedCode {
 				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
 			}
+			if len(n.Messages()) != test.expectedMessages {
+				t.Errorf("Expected %d messages sent, got %d", test.expectedMessages, len(n.Messages()))
+			}
 		})
 	}
 }
@@ -231,6 +560,19 @@ func TestGetUser(t *testing.T) {
 		JwtPrivateKeyPath: "../storage/key.pem",
 		JwtPublicKeyPath:  "../storage/key.pem.pub",
 	})
+	p := password.NewPolicy(password.NewPolicyOptions{
+		Config: password.DefaultPolicyConfig(),
+	})
+	b, _ := password.LoadBlocklist(strings.NewReader("password1!"))
+	est := password.NewEstimator(password.NewEstimatorOptions{})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
 
 	// Test cases
 	tests := []struct {
@@ -243,7 +585,7 @@ func TestGetUser(t *testing.T) {
 			caseName: "Positive case",
 			token: func() string {
 				token := ""
-				h.GenerateAccessToken(&token, 1)
+				h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
 				return token
 			},
 			mockFunc: func() {
@@ -252,7 +594,7 @@ func TestGetUser(t *testing.T) {
 					GetUserById(gomock.Any(), gomock.Any()).
 					Return(repository.GetUserByIdOutput{
 						UserId:      1,
-						PhoneNumber: "+62123456789",
+						PhoneNumber: "+628123456789",
 						FullName:    "test",
 					}, nil)
 			},
@@ -266,6 +608,16 @@ func TestGetUser(t *testing.T) {
 			mockFunc:     func() {},
 			expectedCode: http.StatusForbidden,
 		},
+		{
+			caseName: "Revoked token",
+			token: func() string {
+				token := ""
+				h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, TokenVersion: 1})
+				return token
+			},
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+		},
 	}
 
 	for _, test := range tests {
@@ -273,9 +625,13 @@ func TestGetUser(t *testing.T) {
 			// Creating the server
 			e := echo.New()
 			server := NewServer(NewServerOptions{
-				Repository: m,
-				Helper:     h,
-				Echo:       e,
+				Repository:        m,
+				Helper:            h,
+				PasswordPolicy:    p,
+				PasswordBlocklist: b,
+				PasswordEstimator: est,
+				PhoneParser:       ph,
+				Echo:              e,
 			})
 			generated.RegisterHandlers(e, server)
 
@@ -311,6 +667,19 @@ func TestUpdateUser(t *testing.T) {
 		JwtPrivateKeyPath: "../storage/key.pem",
 		JwtPublicKeyPath:  "../storage/key.pem.pub",
 	})
+	p := password.NewPolicy(password.NewPolicyOptions{
+		Config: password.DefaultPolicyConfig(),
+	})
+	b, _ := password.LoadBlocklist(strings.NewReader("password1!"))
+	est := password.NewEstimator(password.NewEstimatorOptions{})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
 
 	// Test cases
 	tests := []struct {
@@ -322,7 +691,7 @@ func TestUpdateUser(t *testing.T) {
 	}{
 		{
 			caseName: "Unauthorized",
-			payload:  `{"phoneNumber":"+62123456789","fullName":"test"}`,
+			payload:  `{"phoneNumber":"+628123456789","fullName":"test"}`,
 			token: func() string {
 				return ""
 			},
@@ -331,10 +700,10 @@ func TestUpdateUser(t *testing.T) {
 		},
 		{
 			caseName: "Positive case",
-			payload:  `{"phoneNumber":"+62123456789","fullName":"test"}`,
+			payload:  `{"phoneNumber":"+628123456789","fullName":"test"}`,
 			token: func() string {
 				token := ""
-				h.GenerateAccessToken(&token, 1)
+				h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
 				return token
 			},
 			mockFunc: func() {
@@ -347,7 +716,7 @@ func TestUpdateUser(t *testing.T) {
 					UpdateUserById(gomock.Any(), gomock.Any()).
 					Return(repository.UpdateUserByIdOutput{
 						UserId:      1,
-						PhoneNumber: "+62123456789",
+						PhoneNumber: "+628123456789",
 						FullName:    "test",
 					}, nil)
 			},
@@ -358,7 +727,7 @@ func TestUpdateUser(t *testing.T) {
 			payload:  "",
 			token: func() string {
 				token := ""
-				h.GenerateAccessToken(&token, 1)
+				h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
 				return token
 			},
 			mockFunc:     func() {},
@@ -366,10 +735,10 @@ func TestUpdateUser(t *testing.T) {
 		},
 		{
 			caseName: "Invalid full name",
-			payload:  `{"phoneNumber":"+62123456789","fullName":"t"}`,
+			payload:  `{"phoneNumber":"+628123456789","fullName":"t"}`,
 			token: func() string {
 				token := ""
-				h.GenerateAccessToken(&token, 1)
+				h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
 				return token
 			},
 			mockFunc:     func() {},
@@ -377,10 +746,10 @@ func TestUpdateUser(t *testing.T) {
 		},
 		{
 			caseName: "Update phone number",
-			payload:  `{"phoneNumber":"+62123456789","fullName":"test"}`,
+			payload:  `{"phoneNumber":"+628123456789","fullName":"test"}`,
 			token: func() string {
 				token := ""
-				h.GenerateAccessToken(&token, 1)
+				h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
 				return token
 			},
 			mockFunc: func() {
@@ -398,9 +767,13 @@ func TestUpdateUser(t *testing.T) {
 			// Creating the server
 			e := echo.New()
 			server := NewServer(NewServerOptions{
-				Repository: m,
-				Helper:     h,
-				Echo:       e,
+				Repository:        m,
+				Helper:            h,
+				PasswordPolicy:    p,
+				PasswordBlocklist: b,
+				PasswordEstimator: est,
+				PhoneParser:       ph,
+				Echo:              e,
 			})
 			generated.RegisterHandlers(e, server)
 
@@ -425,3 +798,214 @@ func TestUpdateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestChangePassword(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	p := password.NewPolicy(password.NewPolicyOptions{
+		Config: password.DefaultPolicyConfig(),
+	})
+	b, _ := password.LoadBlocklist(strings.NewReader("password1!"))
+	est := password.NewEstimator(password.NewEstimatorOptions{})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+	validToken := func() string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+	hashPassword, _ := h.HashPassword(context.Background(), "Test123/")
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		token        func() string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Unauthorized",
+			payload:  `{"currentPassword":"Test123/","newPassword":"NewTest123/"}`,
+			token: func() string {
+				return ""
+			},
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Positive case",
+			payload:  `{"currentPassword":"Test123/","newPassword":"NewTest123/"}`,
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByIdOutput{
+						UserId:   1,
+						Password: hashPassword,
+					}, nil)
+				m.
+					EXPECT().
+					UpdatePasswordById(gomock.Any(), gomock.Any()).
+					Return(repository.UpdatePasswordByIdOutput{UserId: 1}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Wrong current password",
+			payload:  `{"currentPassword":"Wrong123/","newPassword":"NewTest123/"}`,
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByIdOutput{
+						UserId:   1,
+						Password: hashPassword,
+					}, nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			caseName: "Policy violation",
+			payload:  `{"currentPassword":"Test123/","newPassword":"weak"}`,
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByIdOutput{
+						UserId:   1,
+						Password: hashPassword,
+					}, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:        m,
+				Helper:            h,
+				PasswordPolicy:    p,
+				PasswordBlocklist: b,
+				PasswordEstimator: est,
+				PhoneParser:       ph,
+				Echo:              e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPut,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+test.token())
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.ChangePassword(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestPasswordStrength(t *testing.T) {
+	p := password.NewPolicy(password.NewPolicyOptions{
+		Config: password.DefaultPolicyConfig(),
+	})
+	b, _ := password.LoadBlocklist(strings.NewReader("password1!"))
+	est := password.NewEstimator(password.NewEstimatorOptions{})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+
+	// Test cases
+	tests := []struct {
+		caseName           string
+		payload            string
+		expectedCode       int
+		expectedViolations int
+	}{
+		{
+			caseName:     "Empty payload",
+			payload:      "",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName:           "Strong password",
+			payload:            `{"password":"Correct horse battery staple 1!"}`,
+			expectedCode:       http.StatusOK,
+			expectedViolations: 0,
+		},
+		{
+			caseName:           "Breached password",
+			payload:            `{"password":"Password1!","fullName":"test"}`,
+			expectedCode:       http.StatusOK,
+			expectedViolations: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				PasswordPolicy:    p,
+				PasswordBlocklist: b,
+				PasswordEstimator: est,
+				PhoneParser:       ph,
+				Echo:              e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			if err := server.PasswordStrength(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var resp generated.PasswordStrengthResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if len(resp.Violations) != test.expectedViolations {
+				t.Errorf("Expected %d violations, got %v", test.expectedViolations, resp.Violations)
+			}
+		})
+	}
+}
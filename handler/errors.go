@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/password"
+	"github.com/asrul10/UserService/phone"
+)
+
+// Machine readable codes returned in ErrorResponse.Code
+const (
+	ErrCodePasswordPolicy         = "password_policy_violation"
+	ErrCodePasswordBreached       = "password_breached"
+	ErrCodeInvalidPhone           = "invalid_phone_number"
+	ErrCodePhoneNotAllowed        = "phone_region_not_allowed"
+	ErrCodePhoneTaken             = "phone_number_taken"
+	ErrCodePhoneChangeUnverified  = "phone_change_requires_verification"
+	ErrCodeInvalidCode            = "invalid_verification_code"
+	ErrCodeCodeExpired            = "verification_code_expired"
+	ErrCodeTooManyAttempts        = "too_many_attempts"
+	ErrCodeAccountNotVerified     = "account_not_verified"
+	ErrCodeAlreadyVerified        = "already_verified"
+	ErrCodeMfaAlreadyEnabled      = "mfa_already_enabled"
+	ErrCodeWebAuthnFailed         = "webauthn_failed"
+	ErrCodePermissionDenied       = "permission_denied"
+	ErrCodeRoleNotFound           = "role_not_found"
+	ErrCodeAccountSuspended       = "account_suspended"
+	ErrCodePasswordResetRequired  = "password_reset_required"
+	ErrCodeUserStatusConflict     = "user_status_conflict"
+	ErrCodeWebhookDeliveryPending = "webhook_delivery_pending"
+)
+
+func errorResponse(code string, message string) generated.ErrorResponse {
+	return generated.ErrorResponse{
+		Message: message,
+		Code:    &code,
+	}
+}
+
+func passwordPolicyErrorResponse(violations []password.Violation) generated.ErrorResponse {
+	code := ErrCodePasswordPolicy
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, v.Message)
+	}
+	details := violationDetails(violations)
+	return generated.ErrorResponse{
+		Message: strings.Join(messages, ", "),
+		Code:    &code,
+		Details: &details,
+	}
+}
+
+func violationDetails(violations []password.Violation) []generated.ErrorDetail {
+	details := make([]generated.ErrorDetail, 0, len(violations))
+	for _, v := range violations {
+		details = append(details, generated.ErrorDetail{
+			Rule:    v.Rule,
+			Message: v.Message,
+		})
+	}
+	return details
+}
+
+func phoneNumberErrorResponse(err error) generated.ErrorResponse {
+	if errors.Is(err, phone.ErrRegionNotAllowed) {
+		return errorResponse(ErrCodePhoneNotAllowed, "Phone number region is not allowed")
+	}
+	return errorResponse(ErrCodeInvalidPhone, "Invalid phone number")
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// readinessTimeout bounds all checks of a readiness probe, a database
+// which doesn't answer in time is not ready
+const readinessTimeout = time.Second * 2
+
+const (
+	healthCheckDatabase   = "database"
+	healthCheckMigrations = "migrations"
+	healthCheckJwtKeys    = "jwt_keys"
+)
+
+// (GET /healthz)
+func (s *Server) GetLiveness(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, generated.HealthResponse{
+		Status: "ok",
+	})
+}
+
+// (GET /readyz)
+func (s *Server) GetReadiness(ctx echo.Context) error {
+	checkCtx, cancel := context.WithTimeout(ctx.Request().Context(), readinessTimeout)
+	defer cancel()
+
+	ping, err := s.Repository.PingDatabase(checkCtx, repository.PingDatabaseInput{})
+	checks := []generated.HealthCheck{
+		healthCheck(healthCheckDatabase, ping.Latency, err),
+	}
+
+	// An older schema lacks what the service needs, a newer one is
+	// expected while the service is deployed after the migration
+	start := time.Now()
+	schema, err := s.Repository.GetSchemaVersion(checkCtx, repository.GetSchemaVersionInput{})
+	if err == nil && schema.Version < repository.SchemaVersion {
+		err = fmt.Errorf("schema version %d, expected %d", schema.Version, repository.SchemaVersion)
+	}
+	checks = append(checks, healthCheck(healthCheckMigrations, time.Since(start), err))
+
+	start = time.Now()
+	err = s.Helper.CheckKeys(checkCtx)
+	checks = append(checks, healthCheck(healthCheckJwtKeys, time.Since(start), err))
+
+	status, code := "ready", http.StatusOK
+	for _, check := range checks {
+		if !check.Healthy {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+	}
+	return ctx.JSON(code, generated.ReadinessResponse{
+		Status: status,
+		Checks: checks,
+	})
+}
+
+func healthCheck(name string, latency time.Duration, err error) generated.HealthCheck {
+	check := generated.HealthCheck{
+		Name:      name,
+		Healthy:   err == nil,
+		LatencyMs: float32(latency.Seconds() * 1000),
+	}
+	if err != nil {
+		message := err.Error()
+		check.Error = &message
+	}
+	return check
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestGetReadiness(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	missingKeys := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/not-exists.pem",
+		JwtPublicKeyPath:  "../storage/not-exists.pem.pub",
+	})
+
+	// Test cases
+	tests := []struct {
+		caseName       string
+		helper         helper.HelperInterface
+		mockFunc       func()
+		expectedCode   int
+		expectedFailed []string
+	}{
+		{
+			caseName: "Ready",
+			helper:   h,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					PingDatabase(gomock.Any(), repository.PingDatabaseInput{}).
+					Return(repository.PingDatabaseOutput{}, nil)
+				m.
+					EXPECT().
+					GetSchemaVersion(gomock.Any(), repository.GetSchemaVersionInput{}).
+					Return(repository.GetSchemaVersionOutput{Version: repository.SchemaVersion}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Database down",
+			helper:   h,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					PingDatabase(gomock.Any(), repository.PingDatabaseInput{}).
+					Return(repository.PingDatabaseOutput{}, errors.New("connection refused"))
+				m.
+					EXPECT().
+					GetSchemaVersion(gomock.Any(), repository.GetSchemaVersionInput{}).
+					Return(repository.GetSchemaVersionOutput{}, errors.New("connection refused"))
+			},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedFailed: []string{healthCheckDatabase, healthCheckMigrations},
+		},
+		{
+			caseName: "Schema not migrated",
+			helper:   h,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					PingDatabase(gomock.Any(), repository.PingDatabaseInput{}).
+					Return(repository.PingDatabaseOutput{}, nil)
+				m.
+					EXPECT().
+					GetSchemaVersion(gomock.Any(), repository.GetSchemaVersionInput{}).
+					Return(repository.GetSchemaVersionOutput{Version: repository.SchemaVersion - 1}, nil)
+			},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedFailed: []string{healthCheckMigrations},
+		},
+		{
+			caseName: "Newer schema",
+			helper:   h,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					PingDatabase(gomock.Any(), repository.PingDatabaseInput{}).
+					Return(repository.PingDatabaseOutput{}, nil)
+				m.
+					EXPECT().
+					GetSchemaVersion(gomock.Any(), repository.GetSchemaVersionInput{}).
+					Return(repository.GetSchemaVersionOutput{Version: repository.SchemaVersion + 1}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "JWT keys missing",
+			helper:   missingKeys,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					PingDatabase(gomock.Any(), repository.PingDatabaseInput{}).
+					Return(repository.PingDatabaseOutput{}, nil)
+				m.
+					EXPECT().
+					GetSchemaVersion(gomock.Any(), repository.GetSchemaVersionInput{}).
+					Return(repository.GetSchemaVersionOutput{Version: repository.SchemaVersion}, nil)
+			},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedFailed: []string{healthCheckJwtKeys},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     test.helper,
+				Echo:       e,
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.GetReadiness(ctx); err != nil {
+				t.Fatalf("Expected nil, got %s", err.Error())
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+
+			var resp generated.ReadinessResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if len(resp.Checks) != 3 {
+				t.Fatalf("Expected 3 checks, got %v", resp.Checks)
+			}
+			var failed []string
+			for _, check := range resp.Checks {
+				if !check.Healthy {
+					failed = append(failed, check.Name)
+					if check.Error == nil {
+						t.Errorf("Expected error of failed check %s", check.Name)
+					}
+				}
+			}
+			if !reflect.DeepEqual(failed, test.expectedFailed) {
+				t.Errorf("Expected failed checks %v, got %v", test.expectedFailed, failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// loginCodeResendInterval is the minimum time between two login codes sent
+// to the same user.
+const loginCodeResendInterval = time.Minute
+
+// (POST /api/v1/users/login/otp)
+func (s *Server) RequestLoginCode(ctx echo.Context) error {
+	payload := new(generated.RequestLoginCodeJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// Normalize phone number to E.164
+	number, err := s.PhoneParser.Parse(payload.PhoneNumber)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, phoneNumberErrorResponse(err))
+	}
+
+	expiresAt := time.Now().Add(otpExpiration)
+	accepted := generated.CodeSentResponse{
+		PhoneNumber: number.E164,
+		ExpiresAt:   expiresAt,
+	}
+
+	// Unknown phone numbers get the same answer, the endpoint must not
+	// tell which numbers are registered.
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), repository.GetUserByPhoneNumberInput{
+		PhoneNumber: number.E164,
+	})
+	if err != nil || user.Status != repository.UserStatusActive {
+		return ctx.JSON(http.StatusAccepted, accepted)
+	}
+
+	previous, err := s.Repository.GetLoginCode(ctx.Request().Context(), repository.GetLoginCodeInput{
+		UserId: user.UserId,
+	})
+	if err == nil && time.Since(previous.CreatedAt) < loginCodeResendInterval {
+		return ctx.JSON(http.StatusTooManyRequests, errorResponse(ErrCodeTooManyAttempts, "Please wait before requesting a new code"))
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get login code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get login code",
+		})
+	}
+
+	if err := s.sendLoginCode(ctx, user.UserId, number.E164, expiresAt); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to send login code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to send login code",
+		})
+	}
+
+	return ctx.JSON(http.StatusAccepted, accepted)
+}
+
+// (POST /api/v1/users/login/otp/verify)
+func (s *Server) LoginWithCode(ctx echo.Context) error {
+	payload := new(generated.LoginWithCodeJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// Normalize phone number to E.164
+	number, err := s.PhoneParser.Parse(payload.PhoneNumber)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, phoneNumberErrorResponse(err))
+	}
+
+	// An unknown phone number looks like a wrong code
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), repository.GetUserByPhoneNumberInput{
+		PhoneNumber: number.E164,
+	})
+	if err != nil {
+		s.recordLoginEvent(ctx, 0, repository.LoginMethodOTP, repository.LoginFailureUserNotFound)
+		return ctx.JSON(codeErrorResponse(errCodeInvalid))
+	}
+	loginCode, err := s.Repository.GetLoginCode(ctx.Request().Context(), repository.GetLoginCodeInput{
+		UserId: user.UserId,
+	})
+	if err != nil {
+		s.recordLoginEvent(ctx, user.UserId, repository.LoginMethodOTP, repository.LoginFailureInvalidCode)
+		return ctx.JSON(codeErrorResponse(errCodeInvalid))
+	}
+
+	var codeHash string
+	if err := s.checkCode(ctx, payload.Code, pendingCode{
+		Attempts:  loginCode.Attempts,
+		ExpiresAt: loginCode.ExpiresAt,
+	}, func() (string, error) {
+		reserved, err := s.Repository.ReserveLoginCodeAttempt(ctx.Request().Context(), repository.ReserveLoginCodeAttemptInput{
+			UserId:      user.UserId,
+			MaxAttempts: otpMaxAttempts,
+		})
+		codeHash = reserved.CodeHash
+		return reserved.CodeHash, err
+	}); err != nil {
+		s.recordLoginFailure(ctx, user.UserId, repository.LoginMethodOTP, err)
+		return ctx.JSON(codeErrorResponse(err))
+	}
+
+	// The code which was checked is consumed, even if it replaced the one
+	// read before
+	consumed, err := s.Repository.ConsumeLoginCode(ctx.Request().Context(), repository.ConsumeLoginCodeInput{
+		UserId:   user.UserId,
+		CodeHash: codeHash,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to use login code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to use login code",
+		})
+	}
+	if !consumed.Accepted {
+		s.recordLoginEvent(ctx, user.UserId, repository.LoginMethodOTP, repository.LoginFailureCodeReused)
+		return ctx.JSON(codeErrorResponse(errCodeInvalid))
+	}
+
+	// Unverified accounts can't login until the phone number is verified
+	if user.Status == repository.UserStatusPendingVerification {
+		s.recordLoginEvent(ctx, user.UserId, repository.LoginMethodOTP, repository.LoginFailureAccountNotVerified)
+		return ctx.JSON(http.StatusForbidden, errorResponse(ErrCodeAccountNotVerified, "Phone number is not verified"))
+	}
+	if user.Status == repository.UserStatusSuspended {
+		s.recordLoginEvent(ctx, user.UserId, repository.LoginMethodOTP, repository.LoginFailureAccountSuspended)
+		return ctx.JSON(http.StatusForbidden, errorResponse(ErrCodeAccountSuspended, "Account is suspended"))
+	}
+
+	tokenClaims := helper.TokenClaims{
+		UserId:       user.UserId,
+		TokenVersion: user.TokenVersion,
+	}
+
+	// The code replaces the password, not the second factor
+	if user.MfaEnabled {
+		return s.mfaChallenge(ctx, tokenClaims)
+	}
+
+	return s.completeLogin(ctx, tokenClaims, repository.LoginMethodOTP)
+}
+
+// sendLoginCode replaces the login code of the user with a new one and
+// sends it to the phone number.
+func (s *Server) sendLoginCode(ctx echo.Context, userId int, phoneNumber string, expiresAt time.Time) error {
+	code, err := s.Helper.GenerateOTP(ctx.Request().Context(), otpLength)
+	if err != nil {
+		return err
+	}
+	codeHash, err := s.Helper.HashPassword(ctx.Request().Context(), code)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Repository.UpsertLoginCode(ctx.Request().Context(), repository.UpsertLoginCodeInput{
+		UserId:    userId,
+		CodeHash:  codeHash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	return s.Notifier.Send(ctx.Request().Context(), notifier.Message{
+		PhoneNumber: phoneNumber,
+		Kind:        notifier.KindOTP,
+		Text:        fmt.Sprintf("Your login code is %s. It expires in %d minutes. Never share it with anyone.", code, int(otpExpiration.Minutes())),
+		Code:        code,
+	})
+}
@@ -0,0 +1,457 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/phone"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequestLoginCode(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+
+	// Test cases
+	tests := []struct {
+		caseName      string
+		payload       string
+		mockFunc      func()
+		expectedCode  int
+		expectedCodes int
+	}{
+		{
+			caseName:     "Invalid phone number",
+			payload:      `{"phoneNumber":"123"}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Positive case",
+			payload:  `{"phoneNumber":"0812-345-6789"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), repository.GetUserByPhoneNumberInput{
+						PhoneNumber: "+628123456789",
+					}).
+					Return(repository.GetUserByPhoneNumberOutput{UserId: 1, Status: repository.UserStatusActive}, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginCodeOutput{}, sql.ErrNoRows)
+				m.
+					EXPECT().
+					UpsertLoginCode(gomock.Any(), gomock.Any()).
+					Return(repository.UpsertLoginCodeOutput{UserId: 1}, nil)
+			},
+			expectedCode:  http.StatusAccepted,
+			expectedCodes: 1,
+		},
+		{
+			caseName: "Unknown phone number",
+			payload:  `{"phoneNumber":"+628123456789"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{}, sql.ErrNoRows)
+			},
+			expectedCode:  http.StatusAccepted,
+			expectedCodes: 0,
+		},
+		{
+			caseName: "Requested again too soon",
+			payload:  `{"phoneNumber":"+628123456789"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{UserId: 1, Status: repository.UserStatusActive}, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginCodeOutput{UserId: 1, CreatedAt: time.Now()}, nil)
+			},
+			expectedCode:  http.StatusTooManyRequests,
+			expectedCodes: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			n := notifier.NewCapturingNotifier()
+			server := NewServer(NewServerOptions{
+				Repository:  m,
+				Helper:      h,
+				PhoneParser: ph,
+				Notifier:    n,
+				Echo:        e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.RequestLoginCode(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if len(n.Messages()) != test.expectedCodes {
+				t.Errorf("Expected %d codes sent, got %d", test.expectedCodes, len(n.Messages()))
+			}
+		})
+	}
+}
+
+func TestLoginWithCode(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	// Login attempts are recorded, the events are checked in TestLoginUser
+	m.
+		EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		Return(repository.CreateLoginEventOutput{}, nil).
+		AnyTimes()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+	codeHash, _ := h.HashPassword(context.Background(), "123456")
+	activeUser := repository.GetUserByPhoneNumberOutput{UserId: 1, Status: repository.UserStatusActive}
+	pendingLoginCode := repository.GetLoginCodeOutput{
+		UserId:    1,
+		CodeHash:  codeHash,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(activeUser, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(pendingLoginCode, nil)
+				m.
+					EXPECT().
+					ReserveLoginCodeAttempt(gomock.Any(), repository.ReserveLoginCodeAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(repository.ReserveLoginCodeAttemptOutput{CodeHash: codeHash, Attempts: 1}, nil)
+				m.
+					EXPECT().
+					ConsumeLoginCode(gomock.Any(), repository.ConsumeLoginCodeInput{UserId: 1, CodeHash: codeHash}).
+					Return(repository.ConsumeLoginCodeOutput{Accepted: true}, nil)
+				m.
+					EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(repository.CreateSessionOutput{Id: 1}, nil)
+				m.
+					EXPECT().
+					GetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserRolesOutput{}, nil)
+				m.
+					EXPECT().
+					SuccessLoginCount(gomock.Any(), gomock.Any()).
+					Return(repository.SuccessLoginCountOutput{UserId: 1}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Two-factor authentication enabled",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{UserId: 1, Status: repository.UserStatusActive, MfaEnabled: true}, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(pendingLoginCode, nil)
+				m.
+					EXPECT().
+					ReserveLoginCodeAttempt(gomock.Any(), repository.ReserveLoginCodeAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(repository.ReserveLoginCodeAttemptOutput{CodeHash: codeHash, Attempts: 1}, nil)
+				m.
+					EXPECT().
+					ConsumeLoginCode(gomock.Any(), gomock.Any()).
+					Return(repository.ConsumeLoginCodeOutput{Accepted: true}, nil)
+			},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			caseName: "Unknown phone number",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Invalid code",
+			payload:  `{"phoneNumber":"+628123456789","code":"654321"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(activeUser, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(pendingLoginCode, nil)
+				m.
+					EXPECT().
+					ReserveLoginCodeAttempt(gomock.Any(), repository.ReserveLoginCodeAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(repository.ReserveLoginCodeAttemptOutput{CodeHash: codeHash, Attempts: 1}, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Code already used",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(activeUser, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(pendingLoginCode, nil)
+				m.
+					EXPECT().
+					ReserveLoginCodeAttempt(gomock.Any(), repository.ReserveLoginCodeAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(repository.ReserveLoginCodeAttemptOutput{CodeHash: codeHash, Attempts: 1}, nil)
+				m.
+					EXPECT().
+					ConsumeLoginCode(gomock.Any(), gomock.Any()).
+					Return(repository.ConsumeLoginCodeOutput{Accepted: false}, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Too many attempts",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(activeUser, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(repository.GetLoginCodeOutput{
+						UserId:    1,
+						CodeHash:  codeHash,
+						Attempts:  otpMaxAttempts,
+						ExpiresAt: time.Now().Add(time.Minute),
+					}, nil)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			caseName: "Attempts used up by parallel requests",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(activeUser, nil)
+				m.
+					EXPECT().
+					GetLoginCode(gomock.Any(), gomock.Any()).
+					Return(pendingLoginCode, nil)
+				m.
+					EXPECT().
+					ReserveLoginCodeAttempt(gomock.Any(), gomock.Any()).
+					Return(repository.ReserveLoginCodeAttemptOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:  m,
+				Helper:      h,
+				PhoneParser: ph,
+				Echo:        e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.LoginWithCode(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+// TestPasswordlessLoginFlow requests a code and logs in with the code read
+// from the capturing notifier.
+func TestPasswordlessLoginFlow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	// Login attempts are recorded, the events are checked in TestLoginUser
+	m.
+		EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		Return(repository.CreateLoginEventOutput{}, nil).
+		AnyTimes()
+	n := notifier.NewCapturingNotifier()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+	e := echo.New()
+	server := NewServer(NewServerOptions{
+		Repository:  m,
+		Helper:      h,
+		PhoneParser: ph,
+		Notifier:    n,
+		Echo:        e,
+	})
+
+	// The stored code lives in the mocked repository between the requests
+	var stored repository.UpsertLoginCodeInput
+	m.
+		EXPECT().
+		GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+		Return(repository.GetUserByPhoneNumberOutput{UserId: 1, Status: repository.UserStatusActive}, nil).
+		Times(2)
+	m.
+		EXPECT().
+		GetLoginCode(gomock.Any(), gomock.Any()).
+		Return(repository.GetLoginCodeOutput{}, sql.ErrNoRows)
+	m.
+		EXPECT().
+		UpsertLoginCode(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.UpsertLoginCodeInput) (repository.UpsertLoginCodeOutput, error) {
+			stored = input
+			return repository.UpsertLoginCodeOutput{UserId: input.UserId}, nil
+		})
+	m.
+		EXPECT().
+		GetLoginCode(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.GetLoginCodeInput) (repository.GetLoginCodeOutput, error) {
+			return repository.GetLoginCodeOutput{
+				UserId:    stored.UserId,
+				CodeHash:  stored.CodeHash,
+				ExpiresAt: stored.ExpiresAt,
+			}, nil
+		})
+	m.
+		EXPECT().
+		ReserveLoginCodeAttempt(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.ReserveLoginCodeAttemptInput) (repository.ReserveLoginCodeAttemptOutput, error) {
+			return repository.ReserveLoginCodeAttemptOutput{CodeHash: stored.CodeHash, Attempts: 1}, nil
+		})
+	m.
+		EXPECT().
+		ConsumeLoginCode(gomock.Any(), gomock.Any()).
+		Return(repository.ConsumeLoginCodeOutput{Accepted: true}, nil)
+	m.
+		EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Return(repository.CreateSessionOutput{Id: 1}, nil)
+	m.
+		EXPECT().
+		GetUserRoles(gomock.Any(), gomock.Any()).
+		Return(repository.GetUserRolesOutput{}, nil)
+	m.
+		EXPECT().
+		SuccessLoginCount(gomock.Any(), gomock.Any()).
+		Return(repository.SuccessLoginCountOutput{UserId: 1}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"phoneNumber":"+628123456789"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := server.RequestLoginCode(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected %d, got %d", http.StatusAccepted, rec.Code)
+	}
+
+	code := n.LastCode("+628123456789")
+	if len(code) != otpLength {
+		t.Fatalf("Expected a %d digits code, got %q", otpLength, code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"phoneNumber":"+628123456789","code":"`+code+`"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	if err := server.LoginWithCode(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "accessToken") {
+		t.Errorf("Expected tokens, got %s", rec.Body.String())
+	}
+}
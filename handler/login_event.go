@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultSecurityEventsLimit = 20
+	maxSecurityEventsLimit     = 100
+)
+
+// (GET /api/v1/users/security-events)
+func (s *Server) GetSecurityEvents(ctx echo.Context, params generated.GetSecurityEventsParams) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	limit := defaultSecurityEventsLimit
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if limit < 1 || limit > maxSecurityEventsLimit {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "limit must be between 1 and 100",
+		})
+	}
+	before := 0
+	if params.Before != nil {
+		before = *params.Before
+	}
+	if before < 0 {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid cursor",
+		})
+	}
+
+	// One more event than asked tells whether there is a next page
+	resp, err := s.Repository.GetLoginEvents(ctx.Request().Context(), repository.GetLoginEventsInput{
+		UserId:   claims.UserId,
+		BeforeId: before,
+		Limit:    limit + 1,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get security events", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get security events",
+		})
+	}
+
+	var nextCursor *int
+	if len(resp.Events) > limit {
+		resp.Events = resp.Events[:limit]
+		nextCursor = &resp.Events[limit-1].Id
+	}
+
+	events := make([]generated.SecurityEvent, 0, len(resp.Events))
+	for _, event := range resp.Events {
+		var failureReason, riskDecision *string
+		if event.FailureReason != "" {
+			failureReason = &event.FailureReason
+		}
+		if event.RiskDecision != "" {
+			riskDecision = &event.RiskDecision
+		}
+		var riskReasons *[]string
+		if len(event.RiskReasons) > 0 {
+			riskReasons = &event.RiskReasons
+		}
+		events = append(events, generated.SecurityEvent{
+			Id:            event.Id,
+			Method:        event.Method,
+			Success:       event.Success,
+			FailureReason: failureReason,
+			RiskDecision:  riskDecision,
+			RiskReasons:   riskReasons,
+			IpAddress:     event.IpAddress,
+			UserAgent:     event.UserAgent,
+			CreatedAt:     event.CreatedAt,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.SecurityEventsResponse{
+		Events:     events,
+		NextCursor: nextCursor,
+	})
+}
+
+// recordLoginEvent appends the attempt to the login history of the user, an
+// empty failure reason records a successful login. Failing to record does
+// not fail the login.
+func (s *Server) recordLoginEvent(ctx echo.Context, userId int, method string, failureReason string) {
+	s.Metrics.IncLogins(method, failureReason)
+	riskDecision, riskReasons := riskOf(ctx)
+	if _, err := s.Repository.CreateLoginEvent(ctx.Request().Context(), repository.CreateLoginEventInput{
+		UserId:        userId,
+		Method:        method,
+		Success:       failureReason == "",
+		FailureReason: failureReason,
+		IpAddress:     ctx.RealIP(),
+		UserAgent:     truncate(ctx.Request().UserAgent(), maxUserAgentLength),
+		RiskDecision:  riskDecision,
+		RiskReasons:   riskReasons,
+	}); err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to record login event", "error", err)
+	}
+}
+
+// recordLoginFailure records a rejected code, internal errors are not
+// attempts of the user and are left out.
+func (s *Server) recordLoginFailure(ctx echo.Context, userId int, method string, err error) {
+	var reason string
+	switch {
+	case errors.Is(err, errCodeInvalid):
+		reason = repository.LoginFailureInvalidCode
+	case errors.Is(err, errCodeExpired):
+		reason = repository.LoginFailureCodeExpired
+	case errors.Is(err, errCodeExhausted):
+		reason = repository.LoginFailureTooManyAttempts
+	case errors.Is(err, errTOTPReplayed):
+		reason = repository.LoginFailureCodeReused
+	default:
+		return
+	}
+	s.recordLoginEvent(ctx, userId, method, reason)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+// expectLoginEvent expects one login attempt of the user to be recorded, an
+// empty reason expects a successful login.
+func expectLoginEvent(t *testing.T, m *repository.MockRepositoryInterface, userId int, method string, reason string) {
+	m.
+		EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.CreateLoginEventInput) (repository.CreateLoginEventOutput, error) {
+			if input.UserId != userId || input.Method != method || input.FailureReason != reason || input.Success != (reason == "") {
+				t.Errorf("Expected %s event of user %d with reason %q, got %v", method, userId, reason, input)
+			}
+			if input.IpAddress == "" {
+				t.Errorf("Expected IP address to be recorded")
+			}
+			return repository.CreateLoginEventOutput{Id: 1}, nil
+		})
+}
+
+func TestGetSecurityEvents(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	validToken := func() string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+	events := func(ids ...int) []repository.LoginEvent {
+		var events []repository.LoginEvent
+		for _, id := range ids {
+			events = append(events, repository.LoginEvent{
+				Id:        id,
+				UserId:    1,
+				Method:    repository.LoginMethodPassword,
+				Success:   true,
+				CreatedAt: time.Now(),
+			})
+		}
+		return events
+	}
+	intPtr := func(i int) *int {
+		return &i
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName           string
+		token              func() string
+		params             generated.GetSecurityEventsParams
+		mockFunc           func()
+		expectedCode       int
+		expectedEvents     int
+		expectedNextCursor *int
+	}{
+		{
+			caseName: "Unauthorized",
+			token: func() string {
+				return ""
+			},
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName:     "Limit too large",
+			token:        validToken,
+			params:       generated.GetSecurityEventsParams{Limit: intPtr(101)},
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "First page",
+			token:    validToken,
+			params:   generated.GetSecurityEventsParams{Limit: intPtr(2)},
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetLoginEvents(gomock.Any(), repository.GetLoginEventsInput{UserId: 1, Limit: 3}).
+					Return(repository.GetLoginEventsOutput{Events: events(9, 8, 7)}, nil)
+			},
+			expectedCode:       http.StatusOK,
+			expectedEvents:     2,
+			expectedNextCursor: intPtr(8),
+		},
+		{
+			caseName: "Last page",
+			token:    validToken,
+			params:   generated.GetSecurityEventsParams{Limit: intPtr(2), Before: intPtr(8)},
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetLoginEvents(gomock.Any(), repository.GetLoginEventsInput{UserId: 1, BeforeId: 8, Limit: 3}).
+					Return(repository.GetLoginEventsOutput{Events: events(7)}, nil)
+			},
+			expectedCode:   http.StatusOK,
+			expectedEvents: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+test.token())
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.GetSecurityEvents(c, test.params); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp generated.SecurityEventsResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if len(resp.Events) != test.expectedEvents {
+				t.Errorf("Expected %d events, got %d", test.expectedEvents, len(resp.Events))
+			}
+			if (resp.NextCursor == nil) != (test.expectedNextCursor == nil) ||
+				(resp.NextCursor != nil && *resp.NextCursor != *test.expectedNextCursor) {
+				t.Errorf("Expected next cursor %v, got %v", test.expectedNextCursor, resp.NextCursor)
+			}
+		})
+	}
+}
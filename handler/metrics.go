@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/asrul10/UserService/metrics"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// Reasons a token is rejected for
+const (
+	tokenFailureMalformed        = "malformed"
+	tokenFailureExpired          = "expired"
+	tokenFailureInvalidSignature = "invalid_signature"
+	tokenFailureInvalid          = "invalid"
+	tokenFailureRevoked          = "revoked"
+	tokenFailureReused           = "reused"
+)
+
+// RecordMetrics observes the duration of every request by the operationId
+// of the spec and the status code of the response.
+func (s *Server) RecordMetrics(swagger *openapi3.T) echo.MiddlewareFunc {
+	operations := operationIds(swagger)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := time.Now()
+			// The error is handled here so the response written for it
+			// is the one observed
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			operation, ok := operations[ctx.Request().Method+" "+ctx.Path()]
+			if !ok {
+				operation = metrics.OperationUnknown
+			}
+			s.Metrics.ObserveRequest(operation, ctx.Response().Status, time.Since(start))
+			return nil
+		}
+	}
+}
+
+// operationIds maps "<method> <echo route>" to the operationId of the
+// operation, like operationPermissions
+func operationIds(swagger *openapi3.T) map[string]string {
+	operations := map[string]string{}
+	for path, item := range swagger.Paths {
+		route := pathParameterPattern.ReplaceAllString(path, ":$1")
+		for method, operation := range item.Operations() {
+			if operation.OperationID != "" {
+				operations[method+" "+route] = operation.OperationID
+			}
+		}
+	}
+	return operations
+}
+
+// tokenVerificationFailed counts a rejected token of the type, err is the
+// reason it was rejected for
+func (s *Server) tokenVerificationFailed(tokenType string, err error) {
+	var reason string
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		reason = tokenFailureMalformed
+	case errors.Is(err, jwt.ErrTokenExpired):
+		reason = tokenFailureExpired
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		reason = tokenFailureInvalidSignature
+	case errors.Is(err, errTokenRevoked):
+		reason = tokenFailureRevoked
+	case errors.Is(err, errTokenReused):
+		reason = tokenFailureReused
+	default:
+		reason = tokenFailureInvalid
+	}
+	s.Metrics.IncTokenVerificationFailures(tokenType, reason)
+}
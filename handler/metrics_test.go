@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/metrics"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRecordMetrics(t *testing.T) {
+	// Mocking the repository and the metrics
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	mm := metrics.NewMockMetricsInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := func(claims helper.TokenClaims) string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, claims)
+		return token
+	}
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		path         string
+		token        string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Without token",
+			path:     "/api/v1/roles",
+			mockFunc: func() {
+				mm.
+					EXPECT().
+					IncTokenVerificationFailures(helper.AccessTokenType, tokenFailureMalformed)
+				mm.
+					EXPECT().
+					ObserveRequest("GetRoles", http.StatusForbidden, gomock.Any())
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Revoked token",
+			path:     "/api/v1/users/sessions",
+			token:    token(helper.TokenClaims{UserId: 1, TokenVersion: 1}),
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), repository.GetTokenVersionInput{UserId: 1}).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 2}, nil)
+				mm.
+					EXPECT().
+					IncTokenVerificationFailures(helper.AccessTokenType, tokenFailureRevoked)
+				mm.
+					EXPECT().
+					ObserveRequest("GetSessions", http.StatusForbidden, gomock.Any())
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Successful request",
+			path:     "/api/v1/roles",
+			token:    token(helper.TokenClaims{UserId: 1, Permissions: []string{"roles:read"}}),
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), repository.GetTokenVersionInput{UserId: 1}).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+				m.
+					EXPECT().
+					GetRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetRolesOutput{}, nil)
+				mm.
+					EXPECT().
+					ObserveRequest("GetRoles", http.StatusOK, gomock.Any())
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Route outside of the spec",
+			path:     "/not-found",
+			mockFunc: func() {
+				mm.
+					EXPECT().
+					ObserveRequest(metrics.OperationUnknown, http.StatusNotFound, gomock.Any())
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Metrics:    mm,
+				Echo:       e,
+			})
+			swagger, err := generated.GetSwagger()
+			if err != nil {
+				t.Fatalf("Expected nil, got %s", err.Error())
+			}
+			e.Use(server.RecordMetrics(swagger))
+			permissions, err := server.RequirePermissions(swagger)
+			if err != nil {
+				t.Fatalf("Expected nil, got %s", err.Error())
+			}
+			e.Use(permissions)
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set("Authorization", "Bearer "+test.token)
+			rec := httptest.NewRecorder()
+
+			test.mockFunc()
+
+			e.ServeHTTP(rec, req)
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// totpLockDuration is how long the second factor is refused after
+// otpMaxAttempts codes in a row without an accepted one.
+const totpLockDuration = time.Minute * 15
+
+var errTOTPReplayed = errors.New("code already used")
+
+// (POST /api/v1/users/login/mfa)
+func (s *Server) LoginMfa(ctx echo.Context) error {
+	payload := new(generated.LoginMfaJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	claims, err := s.Helper.VerifyMfaToken(ctx.Request().Context(), payload.MfaToken)
+	if err != nil {
+		s.tokenVerificationFailed(helper.MfaTokenType, err)
+		return ctx.JSON(http.StatusUnauthorized, generated.ErrorResponse{
+			Message: "Invalid mfa token",
+		})
+	}
+
+	// The password may have changed since the mfa token was issued
+	state, err := s.Repository.GetTokenVersion(ctx.Request().Context(), repository.GetTokenVersionInput{
+		UserId: claims.UserId,
+	})
+	if err != nil || state.TokenVersion != claims.TokenVersion {
+		if err == nil {
+			s.tokenVerificationFailed(helper.MfaTokenType, errTokenRevoked)
+		}
+		return ctx.JSON(http.StatusUnauthorized, generated.ErrorResponse{
+			Message: "Invalid mfa token",
+		})
+	}
+
+	totpState, err := s.Repository.GetTOTP(ctx.Request().Context(), repository.GetTOTPInput{
+		UserId: claims.UserId,
+	})
+	if err != nil || !totpState.Enabled {
+		return ctx.JSON(http.StatusUnauthorized, generated.ErrorResponse{
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+
+	// A recovery code replaces the code of a lost authenticator app
+	if payload.RecoveryCode != nil && *payload.RecoveryCode != "" {
+		remaining, err := s.checkRecoveryCode(ctx, totpState, *payload.RecoveryCode)
+		if err != nil {
+			s.recordLoginFailure(ctx, claims.UserId, repository.LoginMethodRecoveryCode, err)
+			return ctx.JSON(s.totpErrorResponse(ctx, err))
+		}
+		s.notifyRecoveryCodeUsed(ctx, claims.UserId, remaining)
+		return s.completeLogin(ctx, claims, repository.LoginMethodRecoveryCode)
+	}
+
+	// An empty recovery code may pass the validation without a code
+	if payload.Code == nil || *payload.Code == "" {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Code or recovery code is required",
+		})
+	}
+	if err := s.checkTOTP(ctx, totpState, *payload.Code, false); err != nil {
+		s.recordLoginFailure(ctx, claims.UserId, repository.LoginMethodTOTP, err)
+		return ctx.JSON(s.totpErrorResponse(ctx, err))
+	}
+
+	return s.completeLogin(ctx, claims, repository.LoginMethodTOTP)
+}
+
+// (POST /api/v1/users/mfa/totp)
+func (s *Server) EnrollTOTP(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	totpState, err := s.Repository.GetTOTP(ctx.Request().Context(), repository.GetTOTPInput{
+		UserId: claims.UserId,
+	})
+	if err == nil && totpState.Enabled {
+		return ctx.JSON(http.StatusConflict, errorResponse(ErrCodeMfaAlreadyEnabled, "Two-factor authentication is already enabled"))
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get two-factor authentication", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get two-factor authentication",
+		})
+	}
+
+	user, err := s.Repository.GetUserById(ctx.Request().Context(), repository.GetUserByIdInput{
+		UserId: claims.UserId,
+	})
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	}
+
+	key, err := s.TOTP.GenerateKey(user.PhoneNumber)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate secret", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate secret",
+		})
+	}
+	encryptedSecret, err := s.SecretCipher.Encrypt(key.Secret)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to encrypt secret", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to encrypt secret",
+		})
+	}
+
+	if _, err := s.Repository.UpsertTOTPSecret(ctx.Request().Context(), repository.UpsertTOTPSecretInput{
+		UserId:          claims.UserId,
+		EncryptedSecret: encryptedSecret,
+	}); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to save secret", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to save secret",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.TOTPEnrollmentResponse{
+		Secret:     key.Secret,
+		OtpauthUri: key.URI,
+	})
+}
+
+// (POST /api/v1/users/mfa/totp/confirm)
+func (s *Server) ConfirmTOTP(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	payload := new(generated.ConfirmTOTPJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	totpState, err := s.Repository.GetTOTP(ctx.Request().Context(), repository.GetTOTPInput{
+		UserId: claims.UserId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "No pending two-factor enrollment",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get two-factor authentication", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get two-factor authentication",
+		})
+	}
+	if totpState.Enabled {
+		return ctx.JSON(http.StatusConflict, errorResponse(ErrCodeMfaAlreadyEnabled, "Two-factor authentication is already enabled"))
+	}
+
+	if err := s.checkTOTP(ctx, totpState, payload.Code, true); err != nil {
+		return ctx.JSON(s.totpErrorResponse(ctx, err))
+	}
+
+	// Two-factor authentication is enabled at this point, failing to
+	// create recovery codes only means they have to be regenerated.
+	recoveryCodes, err := s.generateRecoveryCodes(ctx, claims.UserId)
+	if err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to generate recovery codes", "error", err)
+	}
+
+	return ctx.JSON(http.StatusOK, generated.MfaStatusResponse{
+		MfaEnabled:    true,
+		RecoveryCodes: &recoveryCodes,
+	})
+}
+
+// (POST /api/v1/users/mfa/totp/disable)
+func (s *Server) DisableTOTP(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	payload := new(generated.DisableTOTPJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	totpState, err := s.Repository.GetTOTP(ctx.Request().Context(), repository.GetTOTPInput{
+		UserId: claims.UserId,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !totpState.Enabled) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get two-factor authentication", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get two-factor authentication",
+		})
+	}
+
+	if err := s.checkTOTP(ctx, totpState, payload.Code, false); err != nil {
+		return ctx.JSON(s.totpErrorResponse(ctx, err))
+	}
+
+	if _, err := s.Repository.DeleteTOTP(ctx.Request().Context(), repository.DeleteTOTPInput{
+		UserId: claims.UserId,
+	}); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to disable two-factor authentication", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to disable two-factor authentication",
+		})
+	}
+
+	// Let the owner know in case the session was hijacked
+	if user, err := s.Repository.GetUserById(ctx.Request().Context(), repository.GetUserByIdInput{
+		UserId: claims.UserId,
+	}); err == nil {
+		if err := s.Notifier.Send(ctx.Request().Context(), notifier.Message{
+			PhoneNumber: user.PhoneNumber,
+			Kind:        notifier.KindSecurityNotice,
+			Text:        "Two-factor authentication was disabled on your account. If this wasn't you, change your password now.",
+		}); err != nil {
+			s.Logger.WarnContext(ctx.Request().Context(), "Failed to send two-factor disabled notice", "error", err)
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, generated.MfaStatusResponse{
+		MfaEnabled: false,
+	})
+}
+
+// mfaChallenge answers a login with a correct password on an account with
+// two-factor authentication.
+func (s *Server) mfaChallenge(ctx echo.Context, tokenClaims helper.TokenClaims) error {
+	mfaToken := ""
+	if err := s.Helper.GenerateMfaToken(ctx.Request().Context(), &mfaToken, tokenClaims); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate token", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate token",
+		})
+	}
+
+	return ctx.JSON(http.StatusAccepted, generated.MfaChallengeResponse{
+		MfaRequired: true,
+		MfaToken:    mfaToken,
+		ExpiresAt:   time.Now().Add(s.MfaTokenTTL),
+	})
+}
+
+// checkTOTP validates a code of the user's authenticator app. Every code
+// is counted before it is compared, the count is reset when it is
+// accepted, and an accepted code can't be used again.
+func (s *Server) checkTOTP(ctx echo.Context, state repository.GetTOTPOutput, code string, enable bool) error {
+	if err := s.reserveTOTPAttempt(ctx, state); err != nil {
+		return err
+	}
+
+	secret, err := s.SecretCipher.Decrypt(state.EncryptedSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := s.TOTP.Validate(secret, code, time.Now())
+	if !ok {
+		return errCodeInvalid
+	}
+
+	used, err := s.Repository.UseTOTPStep(ctx.Request().Context(), repository.UseTOTPStepInput{
+		UserId: state.UserId,
+		Step:   step,
+		Enable: enable,
+	})
+	if err != nil {
+		return err
+	}
+	if !used.Accepted {
+		return errTOTPReplayed
+	}
+
+	return nil
+}
+
+// reserveTOTPAttempt counts an attempt at the second factor before the
+// code is compared, so parallel guesses can't exceed otpMaxAttempts.
+// errCodeExhausted is returned while it is locked.
+func (s *Server) reserveTOTPAttempt(ctx echo.Context, state repository.GetTOTPOutput) error {
+	if isTOTPLocked(state) {
+		return errCodeExhausted
+	}
+	_, err := s.Repository.ReserveTOTPAttempt(ctx.Request().Context(), repository.ReserveTOTPAttemptInput{
+		UserId:       state.UserId,
+		MaxAttempts:  otpMaxAttempts,
+		LockDuration: totpLockDuration,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errCodeExhausted
+	}
+	return err
+}
+
+// isTOTPLocked reports whether the second factor is refused after too
+// many wrong codes in a row.
+func isTOTPLocked(state repository.GetTOTPOutput) bool {
+	return state.FailedAttempts >= otpMaxAttempts &&
+		state.LastFailedAt != nil && time.Since(*state.LastFailedAt) < totpLockDuration
+}
+
+func (s *Server) totpErrorResponse(ctx echo.Context, err error) (int, generated.ErrorResponse) {
+	switch {
+	case errors.Is(err, errCodeExhausted):
+		return http.StatusTooManyRequests, errorResponse(ErrCodeTooManyAttempts, "Too many attempts, try again later")
+	case errors.Is(err, errCodeInvalid):
+		return http.StatusBadRequest, errorResponse(ErrCodeInvalidCode, "Invalid verification code")
+	case errors.Is(err, errTOTPReplayed):
+		return http.StatusBadRequest, errorResponse(ErrCodeInvalidCode, "Verification code already used, wait for the next one")
+	default:
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to verify code", "error", err)
+		return http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to verify code",
+		}
+	}
+}
@@ -0,0 +1,638 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/encryption"
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/totp"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func newTestCipher() *encryption.Cipher {
+	c, _ := encryption.NewCipher(encryption.NewCipherOptions{
+		Key: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", encryption.KeySize))),
+	})
+	return c
+}
+
+func TestLoginMfa(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	// Login attempts are recorded, the events are checked in TestLoginUser
+	m.
+		EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		Return(repository.CreateLoginEventOutput{}, nil).
+		AnyTimes()
+	tp := totp.NewMockTOTPInterface(ctrl)
+	c := newTestCipher()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	encryptedSecret, _ := c.Encrypt("JBSWY3DPEHPK3PXP")
+	mfaToken := func() string {
+		token := ""
+		h.GenerateMfaToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+	enabledTOTP := repository.GetTOTPOutput{
+		UserId:          1,
+		EncryptedSecret: encryptedSecret,
+		Enabled:         true,
+	}
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      func() string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			payload: func() string {
+				return `{"mfaToken":"` + mfaToken() + `","code":"123456"}`
+			},
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), repository.GetTOTPInput{UserId: 1}).
+					Return(enabledTOTP, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				tp.
+					EXPECT().
+					Validate("JBSWY3DPEHPK3PXP", "123456", gomock.Any()).
+					Return(int64(100), true)
+				m.
+					EXPECT().
+					UseTOTPStep(gomock.Any(), repository.UseTOTPStepInput{UserId: 1, Step: 100}).
+					Return(repository.UseTOTPStepOutput{Accepted: true}, nil)
+				m.
+					EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(repository.CreateSessionOutput{Id: 1}, nil)
+				m.
+					EXPECT().
+					GetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserRolesOutput{}, nil)
+				m.
+					EXPECT().
+					SuccessLoginCount(gomock.Any(), gomock.Any()).
+					Return(repository.SuccessLoginCountOutput{UserId: 1}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Access token instead of mfa token",
+			payload: func() string {
+				token := ""
+				h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+				return `{"mfaToken":"` + token + `","code":"123456"}`
+			},
+			mockFunc:     func() {},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			caseName: "Revoked mfa token",
+			payload: func() string {
+				return `{"mfaToken":"` + mfaToken() + `","code":"123456"}`
+			},
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 1}, nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			caseName: "Invalid code",
+			payload: func() string {
+				return `{"mfaToken":"` + mfaToken() + `","code":"654321"}`
+			},
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(enabledTOTP, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				tp.
+					EXPECT().
+					Validate(gomock.Any(), "654321", gomock.Any()).
+					Return(int64(0), false)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Code already used",
+			payload: func() string {
+				return `{"mfaToken":"` + mfaToken() + `","code":"123456"}`
+			},
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(enabledTOTP, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				tp.
+					EXPECT().
+					Validate(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(100), true)
+				m.
+					EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Return(repository.UseTOTPStepOutput{Accepted: false}, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Attempts used up by parallel requests",
+			payload: func() string {
+				return `{"mfaToken":"` + mfaToken() + `","code":"123456"}`
+			},
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(enabledTOTP, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), gomock.Any()).
+					Return(repository.ReserveTOTPAttemptOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			caseName: "Too many attempts",
+			payload: func() string {
+				return `{"mfaToken":"` + mfaToken() + `","code":"123456"}`
+			},
+			mockFunc: func() {
+				lastFailedAt := time.Now()
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{
+						UserId:          1,
+						EncryptedSecret: encryptedSecret,
+						Enabled:         true,
+						FailedAttempts:  otpMaxAttempts,
+						LastFailedAt:    &lastFailedAt,
+					}, nil)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:   m,
+				Helper:       h,
+				TOTP:         tp,
+				SecretCipher: c,
+				Echo:         e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload()),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.LoginMfa(ctx); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	c := newTestCipher()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	validToken := func() string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		token        func() string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Unauthorized",
+			token: func() string {
+				return ""
+			},
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Positive case",
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{}, sql.ErrNoRows)
+				m.
+					EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByIdOutput{UserId: 1, PhoneNumber: "+628123456789"}, nil)
+				m.
+					EXPECT().
+					UpsertTOTPSecret(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, input repository.UpsertTOTPSecretInput) (repository.UpsertTOTPSecretOutput, error) {
+						// The secret is stored encrypted
+						if _, err := c.Decrypt(input.EncryptedSecret); err != nil {
+							t.Errorf("Expected encrypted secret, got %s", input.EncryptedSecret)
+						}
+						return repository.UpsertTOTPSecretOutput{UserId: 1}, nil
+					})
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Already enabled",
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, Enabled: true}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:   m,
+				Helper:       h,
+				TOTP:         totp.NewTOTP(totp.NewTOTPOptions{Issuer: "UserService"}),
+				SecretCipher: c,
+				Echo:         e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+test.token())
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.EnrollTOTP(ctx); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	tp := totp.NewMockTOTPInterface(ctrl)
+	c := newTestCipher()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	encryptedSecret, _ := c.Encrypt("JBSWY3DPEHPK3PXP")
+	validToken := func() string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, EncryptedSecret: encryptedSecret}, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				tp.
+					EXPECT().
+					Validate("JBSWY3DPEHPK3PXP", "123456", gomock.Any()).
+					Return(int64(100), true)
+				m.
+					EXPECT().
+					UseTOTPStep(gomock.Any(), repository.UseTOTPStepInput{UserId: 1, Step: 100, Enable: true}).
+					Return(repository.UseTOTPStepOutput{Accepted: true}, nil)
+				m.
+					EXPECT().
+					ReplaceRecoveryCodes(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, input repository.ReplaceRecoveryCodesInput) (repository.ReplaceRecoveryCodesOutput, error) {
+						if len(input.CodeHashes) != recoveryCodeCount {
+							t.Errorf("Expected %d recovery codes, got %d", recoveryCodeCount, len(input.CodeHashes))
+						}
+						return repository.ReplaceRecoveryCodesOutput{Remaining: len(input.CodeHashes)}, nil
+					})
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "No pending enrollment",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			caseName: "Already enabled",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, Enabled: true}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			caseName:     "Invalid payload",
+			payload:      `{"code":"abc"}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:   m,
+				Helper:       h,
+				TOTP:         tp,
+				SecretCipher: c,
+				Echo:         e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+validToken())
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.ConfirmTOTP(ctx); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	n := notifier.NewMockNotifierInterface(ctrl)
+	tp := totp.NewMockTOTPInterface(ctrl)
+	c := newTestCipher()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	encryptedSecret, _ := c.Encrypt("JBSWY3DPEHPK3PXP")
+	validToken := func() string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, EncryptedSecret: encryptedSecret, Enabled: true}, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				tp.
+					EXPECT().
+					Validate("JBSWY3DPEHPK3PXP", "123456", gomock.Any()).
+					Return(int64(100), true)
+				m.
+					EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Return(repository.UseTOTPStepOutput{Accepted: true}, nil)
+				m.
+					EXPECT().
+					DeleteTOTP(gomock.Any(), repository.DeleteTOTPInput{UserId: 1}).
+					Return(repository.DeleteTOTPOutput{UserId: 1}, nil)
+				m.
+					EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByIdOutput{UserId: 1, PhoneNumber: "+628123456789"}, nil)
+				n.
+					EXPECT().
+					Send(gomock.Any(), notifierMessageTo("+628123456789")).
+					Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Not enabled",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, EncryptedSecret: encryptedSecret}, nil)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			caseName: "Invalid code",
+			payload:  `{"code":"654321"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, EncryptedSecret: encryptedSecret, Enabled: true}, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				tp.
+					EXPECT().
+					Validate(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(0), false)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:   m,
+				Helper:       h,
+				Notifier:     n,
+				TOTP:         tp,
+				SecretCipher: c,
+				Echo:         e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+validToken())
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.DisableTOTP(ctx); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
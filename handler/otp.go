@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	otpLength      = 6
+	otpExpiration  = time.Minute * 10
+	otpMaxAttempts = 5
+)
+
+var (
+	errCodeExhausted = errors.New("too many attempts")
+	errCodeExpired   = errors.New("code expired")
+	errCodeInvalid   = errors.New("invalid code")
+)
+
+type pendingCode struct {
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+// checkCode compares a submitted one-time code with the pending one. The
+// attempt is counted by reserve before the comparison, so parallel guesses
+// can't exceed otpMaxAttempts. reserve returns the hash of the code, or
+// sql.ErrNoRows when no attempt is left or the code expired.
+func (s *Server) checkCode(ctx echo.Context, code string, pending pendingCode, reserve func() (codeHash string, err error)) error {
+	if pending.Attempts >= otpMaxAttempts {
+		return errCodeExhausted
+	}
+	if time.Now().After(pending.ExpiresAt) {
+		return errCodeExpired
+	}
+
+	codeHash, err := reserve()
+	if errors.Is(err, sql.ErrNoRows) {
+		// Used up by parallel attempts since the code was read
+		if time.Now().After(pending.ExpiresAt) {
+			return errCodeExpired
+		}
+		return errCodeExhausted
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to count the code attempt", "error", err)
+		return err
+	}
+
+	if err := s.Helper.ComparePassword(ctx.Request().Context(), code, codeHash); err != nil {
+		return errCodeInvalid
+	}
+	return nil
+}
+
+func codeErrorResponse(err error) (int, generated.ErrorResponse) {
+	switch {
+	case errors.Is(err, errCodeExhausted):
+		return http.StatusTooManyRequests, errorResponse(ErrCodeTooManyAttempts, "Too many attempts, request a new code")
+	case errors.Is(err, errCodeExpired):
+		return http.StatusBadRequest, errorResponse(ErrCodeCodeExpired, "Verification code expired, request a new code")
+	case errors.Is(err, errCodeInvalid):
+		return http.StatusBadRequest, errorResponse(ErrCodeInvalidCode, "Invalid verification code")
+	default:
+		return http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to verify code",
+		}
+	}
+}
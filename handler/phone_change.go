@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// phoneChangeResendInterval is the minimum time between two phone number
+// change codes sent for the same user.
+const phoneChangeResendInterval = time.Minute
+
+// (POST /api/v1/users/phone-number/change)
+func (s *Server) RequestPhoneNumberChange(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	payload := new(generated.RequestPhoneNumberChangeJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// Normalize phone number to E.164, the new number is limited to the
+	// allowed regions
+	number, err := s.PhoneParser.Parse(payload.PhoneNumber)
+	if err == nil {
+		err = s.PhoneParser.CheckRegion(number)
+	}
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, phoneNumberErrorResponse(err))
+	}
+
+	// Check if phone number already registered
+	if _, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), repository.GetUserByPhoneNumberInput{
+		PhoneNumber: number.E164,
+	}); err == nil {
+		return ctx.JSON(http.StatusConflict, errorResponse(ErrCodePhoneTaken, "Phone number already registered"))
+	}
+
+	// Throttle the codes, the pending request is replaced by the new one
+	previous, err := s.Repository.GetPhoneChangeRequest(ctx.Request().Context(), repository.GetPhoneChangeRequestInput{
+		UserId: claims.UserId,
+	})
+	if err == nil && time.Since(previous.CreatedAt) < phoneChangeResendInterval {
+		return ctx.JSON(http.StatusTooManyRequests, errorResponse(ErrCodeTooManyAttempts, "Please wait before requesting a new code"))
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get phone number change request", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get phone number change request",
+		})
+	}
+
+	code, err := s.Helper.GenerateOTP(ctx.Request().Context(), otpLength)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate verification code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate verification code",
+		})
+	}
+	codeHash, err := s.Helper.HashPassword(ctx.Request().Context(), code)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to hash verification code",
+		})
+	}
+
+	expiresAt := time.Now().Add(otpExpiration)
+	if _, err := s.Repository.CreatePhoneChangeRequest(ctx.Request().Context(), repository.CreatePhoneChangeRequestInput{
+		UserId:      claims.UserId,
+		PhoneNumber: number.E164,
+		CodeHash:    codeHash,
+		ExpiresAt:   expiresAt,
+	}); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create phone number change request", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to create phone number change request",
+		})
+	}
+
+	if err := s.Notifier.Send(ctx.Request().Context(), notifier.Message{
+		PhoneNumber: number.E164,
+		Kind:        notifier.KindOTP,
+		Text:        fmt.Sprintf("Your phone number change code is %s. It expires in %d minutes.", code, int(otpExpiration.Minutes())),
+		Code:        code,
+	}); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to send verification code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to send verification code",
+		})
+	}
+
+	return ctx.JSON(http.StatusAccepted, generated.CodeSentResponse{
+		PhoneNumber: number.E164,
+		ExpiresAt:   expiresAt,
+	})
+}
+
+// (POST /api/v1/users/phone-number/confirm)
+func (s *Server) ConfirmPhoneNumberChange(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	payload := new(generated.ConfirmPhoneNumberChangeJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	request, err := s.Repository.GetPhoneChangeRequest(ctx.Request().Context(), repository.GetPhoneChangeRequestInput{
+		UserId: claims.UserId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "No pending phone number change",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get phone number change request", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get phone number change request",
+		})
+	}
+
+	// The request may have been replaced since it was read, the number of
+	// the code which is checked is the one changed to
+	var reserved repository.ReservePhoneChangeAttemptOutput
+	if err := s.checkCode(ctx, payload.Code, pendingCode{
+		Attempts:  request.Attempts,
+		ExpiresAt: request.ExpiresAt,
+	}, func() (string, error) {
+		var err error
+		reserved, err = s.Repository.ReservePhoneChangeAttempt(ctx.Request().Context(), repository.ReservePhoneChangeAttemptInput{
+			UserId:      claims.UserId,
+			MaxAttempts: otpMaxAttempts,
+		})
+		return reserved.CodeHash, err
+	}); err != nil {
+		return ctx.JSON(codeErrorResponse(err))
+	}
+
+	resp, err := s.Repository.ConfirmPhoneChange(ctx.Request().Context(), repository.ConfirmPhoneChangeInput{
+		UserId:      claims.UserId,
+		PhoneNumber: reserved.PhoneNumber,
+		Audit:       auditEntry(ctx, claims.UserId, repository.AuditActionUserPhoneChange),
+	})
+	if errors.Is(err, repository.ErrPhoneNumberTaken) {
+		return ctx.JSON(http.StatusConflict, errorResponse(ErrCodePhoneTaken, "Phone number already registered"))
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to change phone number", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to change phone number",
+		})
+	}
+
+	// Let the owner of the old number know, in case the change was not
+	// made by them.
+	if err := s.Notifier.Send(ctx.Request().Context(), notifier.Message{
+		PhoneNumber: resp.OldPhoneNumber,
+		Kind:        notifier.KindSecurityNotice,
+		Text:        fmt.Sprintf("The phone number of your account was changed to %s. If this was not you, contact support.", maskPhoneNumber(resp.PhoneNumber)),
+	}); err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to send phone change notice", "error", err)
+	}
+
+	user, err := s.Repository.GetUserById(ctx.Request().Context(), repository.GetUserByIdInput{
+		UserId: claims.UserId,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get user", "error", err)
+	}
+
+	return ctx.JSON(http.StatusOK, generated.UpdateUserResponse{
+		UserId:      resp.UserId,
+		PhoneNumber: resp.PhoneNumber,
+		FullName:    user.FullName,
+	})
+}
+
+// maskPhoneNumber keeps the calling code and the last digits only.
+func maskPhoneNumber(phoneNumber string) string {
+	const visibleHead, visibleTail = 3, 3
+	if len(phoneNumber) <= visibleHead+visibleTail {
+		return phoneNumber
+	}
+	masked := []byte(phoneNumber)
+	for i := visibleHead; i < len(masked)-visibleTail; i++ {
+		masked[i] = '*'
+	}
+	return string(masked)
+}
@@ -0,0 +1,402 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/phone"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequestPhoneNumberChange(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	n := notifier.NewMockNotifierInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+	validToken := func() string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		token        func() string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Unauthorized",
+			payload:  `{"phoneNumber":"+628123456780"}`,
+			token: func() string {
+				return ""
+			},
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Positive case",
+			payload:  `{"phoneNumber":"0812-345-6780"}`,
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), repository.GetUserByPhoneNumberInput{
+						PhoneNumber: "+628123456780",
+					}).
+					Return(repository.GetUserByPhoneNumberOutput{}, sql.ErrNoRows)
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), repository.GetPhoneChangeRequestInput{UserId: 1}).
+					Return(repository.GetPhoneChangeRequestOutput{}, sql.ErrNoRows)
+				m.
+					EXPECT().
+					CreatePhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(repository.CreatePhoneChangeRequestOutput{UserId: 1}, nil)
+				n.
+					EXPECT().
+					Send(gomock.Any(), gomock.Any()).
+					Return(nil)
+			},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			caseName: "New code after the resend interval",
+			payload:  `{"phoneNumber":"+628123456780"}`,
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{}, sql.ErrNoRows)
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(repository.GetPhoneChangeRequestOutput{
+						UserId:    1,
+						Attempts:  2,
+						CreatedAt: time.Now().Add(-phoneChangeResendInterval - time.Second),
+					}, nil)
+				m.
+					EXPECT().
+					CreatePhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(repository.CreatePhoneChangeRequestOutput{UserId: 1}, nil)
+				n.
+					EXPECT().
+					Send(gomock.Any(), gomock.Any()).
+					Return(nil)
+			},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			caseName: "Resend too soon",
+			payload:  `{"phoneNumber":"+628123456780"}`,
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{}, sql.ErrNoRows)
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(repository.GetPhoneChangeRequestOutput{UserId: 1, CreatedAt: time.Now()}, nil)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			caseName:     "Invalid phone number",
+			payload:      `{"phoneNumber":"123"}`,
+			token:        validToken,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Phone number taken",
+			payload:  `{"phoneNumber":"+628123456780"}`,
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{UserId: 2}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:  m,
+				Helper:      h,
+				PhoneParser: ph,
+				Notifier:    n,
+				Echo:        e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+test.token())
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.RequestPhoneNumberChange(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestConfirmPhoneNumberChange(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	n := notifier.NewMockNotifierInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	validToken := func() string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+	codeHash, _ := h.HashPassword(context.Background(), "123456")
+	pendingRequest := repository.GetPhoneChangeRequestOutput{
+		UserId:      1,
+		PhoneNumber: "+628123456780",
+		CodeHash:    codeHash,
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+	reservedRequest := repository.ReservePhoneChangeAttemptOutput{
+		PhoneNumber: pendingRequest.PhoneNumber,
+		CodeHash:    codeHash,
+		Attempts:    1,
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(pendingRequest, nil)
+				m.
+					EXPECT().
+					ReservePhoneChangeAttempt(gomock.Any(), repository.ReservePhoneChangeAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(reservedRequest, nil)
+				m.
+					EXPECT().
+					ConfirmPhoneChange(gomock.Any(), repository.ConfirmPhoneChangeInput{
+						UserId:      1,
+						PhoneNumber: "+628123456780",
+						Audit: repository.AuditEntry{
+							ActorId:   1,
+							Action:    repository.AuditActionUserPhoneChange,
+							IpAddress: "192.0.2.1",
+						},
+					}).
+					Return(repository.ConfirmPhoneChangeOutput{
+						UserId:         1,
+						OldPhoneNumber: "+628123456789",
+						PhoneNumber:    "+628123456780",
+					}, nil)
+				n.
+					EXPECT().
+					Send(gomock.Any(), notifierMessageTo("+628123456789")).
+					Return(nil)
+				m.
+					EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByIdOutput{UserId: 1, FullName: "test"}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "No pending request",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(repository.GetPhoneChangeRequestOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			caseName: "Invalid code",
+			payload:  `{"code":"654321"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(pendingRequest, nil)
+				m.
+					EXPECT().
+					ReservePhoneChangeAttempt(gomock.Any(), repository.ReservePhoneChangeAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(reservedRequest, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Expired code",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				expired := pendingRequest
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(expired, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Too many attempts",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				exhausted := pendingRequest
+				exhausted.Attempts = otpMaxAttempts
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(exhausted, nil)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			caseName: "Attempts used up by parallel requests",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(pendingRequest, nil)
+				m.
+					EXPECT().
+					ReservePhoneChangeAttempt(gomock.Any(), gomock.Any()).
+					Return(repository.ReservePhoneChangeAttemptOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			caseName: "Phone number taken in the meantime",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetPhoneChangeRequest(gomock.Any(), gomock.Any()).
+					Return(pendingRequest, nil)
+				m.
+					EXPECT().
+					ReservePhoneChangeAttempt(gomock.Any(), repository.ReservePhoneChangeAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(reservedRequest, nil)
+				m.
+					EXPECT().
+					ConfirmPhoneChange(gomock.Any(), gomock.Any()).
+					Return(repository.ConfirmPhoneChangeOutput{}, repository.ErrPhoneNumberTaken)
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Notifier:   n,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+validToken())
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.ConfirmPhoneNumberChange(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+type notifierMessageTo string
+
+func (to notifierMessageTo) Matches(x interface{}) bool {
+	message, ok := x.(notifier.Message)
+	return ok && message.PhoneNumber == string(to)
+}
+
+func (to notifierMessageTo) String() string {
+	return "is a message to " + string(to)
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+// permissionsExtension lists the permissions an operation of api.yml
+// requires, the access token must carry all of them
+const permissionsExtension = "x-permissions"
+
+var pathParameterPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// (GET /api/v1/roles)
+func (s *Server) GetRoles(ctx echo.Context) error {
+	if _, err := s.authenticate(ctx); err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	resp, err := s.Repository.GetRoles(ctx.Request().Context(), repository.GetRolesInput{})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get roles", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get roles",
+		})
+	}
+
+	roles := make([]generated.Role, 0, len(resp.Roles))
+	for _, role := range resp.Roles {
+		permissions := role.Permissions
+		if permissions == nil {
+			permissions = []string{}
+		}
+		roles = append(roles, generated.Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: permissions,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.RolesResponse{
+		Roles: roles,
+	})
+}
+
+// (PUT /api/v1/users/{id}/roles)
+func (s *Server) SetUserRoles(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	payload := new(generated.SetUserRolesJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	resp, err := s.Repository.SetUserRoles(ctx.Request().Context(), repository.SetUserRolesInput{
+		UserId: id,
+		Roles:  payload.Roles,
+		Audit:  auditEntry(ctx, claims.UserId, repository.AuditActionUserRolesSet),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	}
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse(ErrCodeRoleNotFound, "Unknown role"))
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to set roles", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to set roles",
+		})
+	}
+
+	roles := resp.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return ctx.JSON(http.StatusOK, generated.UserRolesResponse{
+		UserId: id,
+		Roles:  roles,
+	})
+}
+
+// RequirePermissions enforces the x-permissions of the operations in the
+// spec. Operations without the extension are left to their handler, which
+// still authenticates the request itself.
+func (s *Server) RequirePermissions(swagger *openapi3.T) (echo.MiddlewareFunc, error) {
+	required, err := operationPermissions(swagger)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			permissions := required[ctx.Request().Method+" "+ctx.Path()]
+			if len(permissions) == 0 {
+				return next(ctx)
+			}
+
+			token := s.Helper.GetToken(ctx.Request().Header.Get("Authorization"))
+			claims, err := s.Helper.VerifyToken(ctx.Request().Context(), token)
+			if err != nil {
+				s.tokenVerificationFailed(helper.AccessTokenType, err)
+				return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+					Message: "Unauthorized",
+				})
+			}
+
+			granted := make(map[string]bool, len(claims.Permissions))
+			for _, permission := range claims.Permissions {
+				granted[permission] = true
+			}
+			for _, permission := range permissions {
+				if !granted[permission] {
+					return ctx.JSON(http.StatusForbidden, errorResponse(ErrCodePermissionDenied, "Missing permission "+permission))
+				}
+			}
+
+			return next(ctx)
+		}
+	}, nil
+}
+
+// operationPermissions maps "<method> <echo route>" to the permissions of
+// the operation, the routes are registered by generated.RegisterHandlers
+// with ":name" path parameters.
+func operationPermissions(swagger *openapi3.T) (map[string][]string, error) {
+	required := map[string][]string{}
+	for path, item := range swagger.Paths {
+		route := pathParameterPattern.ReplaceAllString(path, ":$1")
+		for method, operation := range item.Operations() {
+			extension, ok := operation.Extensions[permissionsExtension]
+			if !ok {
+				continue
+			}
+			// Extensions are decoded JSON, or raw JSON in older versions of
+			// kin-openapi
+			raw, ok := extension.(json.RawMessage)
+			if !ok {
+				var err error
+				if raw, err = json.Marshal(extension); err != nil {
+					return nil, err
+				}
+			}
+			var permissions []string
+			if err := json.Unmarshal(raw, &permissions); err != nil {
+				return nil, err
+			}
+			required[method+" "+route] = permissions
+		}
+	}
+	return required, nil
+}
+
+// withRoles adds the current roles of the user to the claims of the tokens
+// about to be issued.
+func (s *Server) withRoles(ctx echo.Context, tokenClaims helper.TokenClaims) (helper.TokenClaims, error) {
+	resp, err := s.Repository.GetUserRoles(ctx.Request().Context(), repository.GetUserRolesInput{
+		UserId: tokenClaims.UserId,
+	})
+	if err != nil {
+		return tokenClaims, err
+	}
+	tokenClaims.Roles = resp.Roles
+	tokenClaims.Permissions = resp.Permissions
+	return tokenClaims, nil
+}
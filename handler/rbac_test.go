@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequirePermissions(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := func(permissions ...string) string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, Permissions: permissions})
+		return token
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		method       string
+		path         string
+		token        string
+		mockFunc     func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			caseName:     "Without token",
+			method:       http.MethodGet,
+			path:         "/api/v1/roles",
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName:     "Missing permission",
+			method:       http.MethodGet,
+			path:         "/api/v1/roles",
+			token:        token("users:read"),
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+			expectedErr:  ErrCodePermissionDenied,
+		},
+		{
+			caseName:     "Missing permission of a route with path parameters",
+			method:       http.MethodPut,
+			path:         "/api/v1/users/2/roles",
+			token:        token("roles:read"),
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+			expectedErr:  ErrCodePermissionDenied,
+		},
+		{
+			caseName: "Permission granted",
+			method:   http.MethodGet,
+			path:     "/api/v1/roles",
+			token:    token("roles:read"),
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetRolesOutput{
+						Roles: []repository.Role{{Id: 1, Name: "admin", Permissions: []string{"roles:read"}}},
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Operation without permissions",
+			method:   http.MethodGet,
+			path:     "/api/v1/users/sessions",
+			token:    token(),
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetSessions(gomock.Any(), gomock.Any()).
+					Return(repository.GetSessionsOutput{}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			swagger, err := generated.GetSwagger()
+			if err != nil {
+				t.Fatalf("Expected nil, got %s", err.Error())
+			}
+			middleware, err := server.RequirePermissions(swagger)
+			if err != nil {
+				t.Fatalf("Expected nil, got %s", err.Error())
+			}
+			e.Use(middleware)
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(`{"roles":[]}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+test.token)
+			rec := httptest.NewRecorder()
+
+			test.mockFunc()
+
+			e.ServeHTTP(rec, req)
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			var resp generated.ErrorResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if test.expectedErr != "" && (resp.Code == nil || *resp.Code != test.expectedErr) {
+				t.Errorf("Expected %s, got %v", test.expectedErr, resp.Code)
+			}
+		})
+	}
+}
+
+func TestSetUserRoles(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, Permissions: []string{"roles:write"}})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName:     "Invalid payload",
+			payload:      `{"roles":[""]}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Positive case",
+			payload:  `{"roles":["support"]}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					SetUserRoles(gomock.Any(), repository.SetUserRolesInput{
+						UserId: 2,
+						Roles:  []string{"support"},
+						Audit:  repository.AuditEntry{ActorId: 1, Action: repository.AuditActionUserRolesSet, IpAddress: "192.0.2.1"},
+					}).
+					Return(repository.SetUserRolesOutput{Roles: []string{"support"}}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Unknown role",
+			payload:  `{"roles":["superuser"]}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					SetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.SetUserRolesOutput{}, repository.ErrRoleNotFound)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "User not found",
+			payload:  `{"roles":[]}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					SetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.SetUserRolesOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(test.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.SetUserRoles(c, 2); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// recoveryCodeCount is the number of codes generated at once
+const recoveryCodeCount = 10
+
+// (GET /api/v1/users/mfa/recovery-codes)
+func (s *Server) GetRecoveryCodes(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	totpState, err := s.Repository.GetTOTP(ctx.Request().Context(), repository.GetTOTPInput{
+		UserId: claims.UserId,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !totpState.Enabled) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get two-factor authentication", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get two-factor authentication",
+		})
+	}
+
+	codes, err := s.Repository.GetRecoveryCodes(ctx.Request().Context(), repository.GetRecoveryCodesInput{
+		UserId: claims.UserId,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get recovery codes", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get recovery codes",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.RecoveryCodesResponse{
+		Remaining: len(codes.Codes),
+	})
+}
+
+// (POST /api/v1/users/mfa/recovery-codes)
+func (s *Server) RegenerateRecoveryCodes(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	payload := new(generated.RegenerateRecoveryCodesJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	totpState, err := s.Repository.GetTOTP(ctx.Request().Context(), repository.GetTOTPInput{
+		UserId: claims.UserId,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !totpState.Enabled) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get two-factor authentication", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get two-factor authentication",
+		})
+	}
+
+	// A stolen access token alone can't replace the codes
+	if err := s.checkTOTP(ctx, totpState, payload.Code, false); err != nil {
+		return ctx.JSON(s.totpErrorResponse(ctx, err))
+	}
+
+	recoveryCodes, err := s.generateRecoveryCodes(ctx, claims.UserId)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate recovery codes", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate recovery codes",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.RecoveryCodesResponse{
+		Remaining:     len(recoveryCodes),
+		RecoveryCodes: &recoveryCodes,
+	})
+}
+
+// generateRecoveryCodes replaces the recovery codes of the user. The codes
+// are only stored hashed, they can't be shown again.
+func (s *Server) generateRecoveryCodes(ctx echo.Context, userId int) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	codeHashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := s.Helper.GenerateRecoveryCode(ctx.Request().Context())
+		if err != nil {
+			return nil, err
+		}
+		codeHash, err := s.Helper.HashPassword(ctx.Request().Context(), normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		codeHashes = append(codeHashes, codeHash)
+	}
+
+	if _, err := s.Repository.ReplaceRecoveryCodes(ctx.Request().Context(), repository.ReplaceRecoveryCodesInput{
+		UserId:     userId,
+		CodeHashes: codeHashes,
+	}); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// checkRecoveryCode consumes a recovery code of the user and returns the
+// number of codes left. Every code counts as an attempt of the second
+// factor, only an accepted TOTP code resets the count.
+func (s *Server) checkRecoveryCode(ctx echo.Context, state repository.GetTOTPOutput, code string) (int, error) {
+	if err := s.reserveTOTPAttempt(ctx, state); err != nil {
+		return 0, err
+	}
+
+	codes, err := s.Repository.GetRecoveryCodes(ctx.Request().Context(), repository.GetRecoveryCodesInput{
+		UserId: state.UserId,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	code = normalizeRecoveryCode(code)
+	for _, c := range codes.Codes {
+		if err := s.Helper.ComparePassword(ctx.Request().Context(), code, c.CodeHash); err != nil {
+			continue
+		}
+
+		used, err := s.Repository.UseRecoveryCode(ctx.Request().Context(), repository.UseRecoveryCodeInput{
+			Id: c.Id,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if !used.Accepted {
+			return 0, errTOTPReplayed
+		}
+		return len(codes.Codes) - 1, nil
+	}
+
+	return 0, errCodeInvalid
+}
+
+func (s *Server) notifyRecoveryCodeUsed(ctx echo.Context, userId int, remaining int) {
+	user, err := s.Repository.GetUserById(ctx.Request().Context(), repository.GetUserByIdInput{
+		UserId: userId,
+	})
+	if err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to get user", "error", err)
+		return
+	}
+
+	if err := s.Notifier.Send(ctx.Request().Context(), notifier.Message{
+		PhoneNumber: user.PhoneNumber,
+		Kind:        notifier.KindSecurityNotice,
+		Text:        fmt.Sprintf("A recovery code was used to sign in to your account, %d codes left.", remaining),
+	}); err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to send recovery code notice", "error", err)
+	}
+}
+
+// normalizeRecoveryCode accepts codes typed in upper case or without the
+// dash.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
@@ -0,0 +1,393 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/totp"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestLoginMfaRecoveryCode(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	// Login attempts are recorded, the events are checked in TestLoginUser
+	m.
+		EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		Return(repository.CreateLoginEventOutput{}, nil).
+		AnyTimes()
+	n := notifier.NewMockNotifierInterface(ctrl)
+	c := newTestCipher()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	encryptedSecret, _ := c.Encrypt("JBSWY3DPEHPK3PXP")
+	codeHash, _ := h.HashPassword(context.Background(), "abcdefghjk")
+	mfaToken := ""
+	h.GenerateMfaToken(context.Background(), &mfaToken, helper.TokenClaims{UserId: 1})
+
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+	m.
+		EXPECT().
+		GetTOTP(gomock.Any(), gomock.Any()).
+		Return(repository.GetTOTPOutput{UserId: 1, EncryptedSecret: encryptedSecret, Enabled: true}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName:     "Neither code nor recovery code",
+			payload:      `{"mfaToken":"` + mfaToken + `"}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName:     "Empty recovery code without code",
+			payload:      `{"mfaToken":"` + mfaToken + `","recoveryCode":""}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName:     "Empty code and recovery code",
+			payload:      `{"mfaToken":"` + mfaToken + `","code":"","recoveryCode":""}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Positive case",
+			payload:  `{"mfaToken":"` + mfaToken + `","recoveryCode":"ABCDE-FGHJK"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				m.
+					EXPECT().
+					GetRecoveryCodes(gomock.Any(), repository.GetRecoveryCodesInput{UserId: 1}).
+					Return(repository.GetRecoveryCodesOutput{
+						Codes: []repository.RecoveryCode{{Id: 7, CodeHash: codeHash}},
+					}, nil)
+				m.
+					EXPECT().
+					UseRecoveryCode(gomock.Any(), repository.UseRecoveryCodeInput{Id: 7}).
+					Return(repository.UseRecoveryCodeOutput{Accepted: true}, nil)
+				m.
+					EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByIdOutput{UserId: 1, PhoneNumber: "+628123456789"}, nil)
+				n.
+					EXPECT().
+					Send(gomock.Any(), notifierMessageTo("+628123456789")).
+					Return(nil)
+				m.
+					EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(repository.CreateSessionOutput{Id: 1}, nil)
+				m.
+					EXPECT().
+					GetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserRolesOutput{}, nil)
+				m.
+					EXPECT().
+					SuccessLoginCount(gomock.Any(), gomock.Any()).
+					Return(repository.SuccessLoginCountOutput{UserId: 1}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Wrong recovery code",
+			payload:  `{"mfaToken":"` + mfaToken + `","recoveryCode":"zzzzz-zzzzz"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), gomock.Any()).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				m.
+					EXPECT().
+					GetRecoveryCodes(gomock.Any(), gomock.Any()).
+					Return(repository.GetRecoveryCodesOutput{
+						Codes: []repository.RecoveryCode{{Id: 7, CodeHash: codeHash}},
+					}, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Recovery code used in the meantime",
+			payload:  `{"mfaToken":"` + mfaToken + `","recoveryCode":"abcdefghjk"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), gomock.Any()).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				m.
+					EXPECT().
+					GetRecoveryCodes(gomock.Any(), gomock.Any()).
+					Return(repository.GetRecoveryCodesOutput{
+						Codes: []repository.RecoveryCode{{Id: 7, CodeHash: codeHash}},
+					}, nil)
+				m.
+					EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Any()).
+					Return(repository.UseRecoveryCodeOutput{Accepted: false}, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:   m,
+				Helper:       h,
+				Notifier:     n,
+				TOTP:         totp.NewTOTP(totp.NewTOTPOptions{}),
+				SecretCipher: c,
+				Echo:         e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.LoginMfa(ctx); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGetRecoveryCodes(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		mockFunc     func()
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			caseName: "Positive case",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, Enabled: true}, nil)
+				m.
+					EXPECT().
+					GetRecoveryCodes(gomock.Any(), gomock.Any()).
+					Return(repository.GetRecoveryCodesOutput{
+						Codes: []repository.RecoveryCode{{Id: 1}, {Id: 2}},
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"remaining":2}`,
+		},
+		{
+			caseName: "Not enabled",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.GetRecoveryCodes(ctx); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if test.expectedBody != "" && strings.TrimSpace(rec.Body.String()) != test.expectedBody {
+				t.Errorf("Expected %s, got %s", test.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	tp := totp.NewMockTOTPInterface(ctrl)
+	c := newTestCipher()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	encryptedSecret, _ := c.Encrypt("JBSWY3DPEHPK3PXP")
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			payload:  `{"code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, EncryptedSecret: encryptedSecret, Enabled: true}, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				tp.
+					EXPECT().
+					Validate("JBSWY3DPEHPK3PXP", "123456", gomock.Any()).
+					Return(int64(100), true)
+				m.
+					EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Return(repository.UseTOTPStepOutput{Accepted: true}, nil)
+				m.
+					EXPECT().
+					ReplaceRecoveryCodes(gomock.Any(), gomock.Any()).
+					Return(repository.ReplaceRecoveryCodesOutput{Remaining: recoveryCodeCount}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Invalid code",
+			payload:  `{"code":"654321"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTOTP(gomock.Any(), gomock.Any()).
+					Return(repository.GetTOTPOutput{UserId: 1, EncryptedSecret: encryptedSecret, Enabled: true}, nil)
+				m.
+					EXPECT().
+					ReserveTOTPAttempt(gomock.Any(), repository.ReserveTOTPAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts, LockDuration: totpLockDuration}).
+					Return(repository.ReserveTOTPAttemptOutput{FailedAttempts: 1}, nil)
+				tp.
+					EXPECT().
+					Validate(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(0), false)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:   m,
+				Helper:       h,
+				TOTP:         tp,
+				SecretCipher: c,
+				Echo:         e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.RegenerateRecoveryCodes(ctx); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
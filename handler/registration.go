@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// (POST /api/v1/users/verify)
+func (s *Server) VerifyUser(ctx echo.Context) error {
+	payload := new(generated.VerifyUserJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	user, status, errResp := s.getPendingUser(ctx, payload.PhoneNumber)
+	if errResp != nil {
+		return ctx.JSON(status, errResp)
+	}
+
+	verification, err := s.Repository.GetRegistrationVerification(ctx.Request().Context(), repository.GetRegistrationVerificationInput{
+		UserId: user.UserId,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get verification", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get verification",
+		})
+	}
+
+	if err := s.checkCode(ctx, payload.Code, pendingCode{
+		Attempts:  verification.Attempts,
+		ExpiresAt: verification.ExpiresAt,
+	}, func() (string, error) {
+		reserved, err := s.Repository.ReserveRegistrationVerificationAttempt(ctx.Request().Context(), repository.ReserveRegistrationVerificationAttemptInput{
+			UserId:      user.UserId,
+			MaxAttempts: otpMaxAttempts,
+		})
+		return reserved.CodeHash, err
+	}); err != nil {
+		return ctx.JSON(codeErrorResponse(err))
+	}
+
+	resp, err := s.Repository.ActivateUser(ctx.Request().Context(), repository.ActivateUserInput{
+		UserId: user.UserId,
+		Audit:  auditEntry(ctx, user.UserId, repository.AuditActionUserActivate),
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to activate user", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to activate user",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.VerifyUserResponse{
+		UserId: resp.UserId,
+		Status: resp.Status,
+	})
+}
+
+// verificationResendInterval is the minimum time between two verification
+// codes sent for the same registration.
+const verificationResendInterval = time.Minute
+
+// (POST /api/v1/users/verify/resend)
+func (s *Server) ResendVerificationCode(ctx echo.Context) error {
+	payload := new(generated.ResendVerificationCodeJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	user, status, errResp := s.getPendingUser(ctx, payload.PhoneNumber)
+	if errResp != nil {
+		return ctx.JSON(status, errResp)
+	}
+
+	// The attempts are not reset by a new code, once they are used up the
+	// registration can only expire
+	previous, err := s.Repository.GetRegistrationVerification(ctx.Request().Context(), repository.GetRegistrationVerificationInput{
+		UserId: user.UserId,
+	})
+	if err == nil && previous.Attempts >= otpMaxAttempts {
+		return ctx.JSON(http.StatusTooManyRequests, errorResponse(ErrCodeTooManyAttempts, "Too many attempts, register again once the registration expires"))
+	}
+	if err == nil && time.Since(previous.CreatedAt) < verificationResendInterval {
+		return ctx.JSON(http.StatusTooManyRequests, errorResponse(ErrCodeTooManyAttempts, "Please wait before requesting a new code"))
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get verification", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get verification",
+		})
+	}
+
+	code, err := s.Helper.GenerateOTP(ctx.Request().Context(), otpLength)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate verification code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate verification code",
+		})
+	}
+	codeHash, err := s.Helper.HashPassword(ctx.Request().Context(), code)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to hash verification code",
+		})
+	}
+
+	expiresAt := time.Now().Add(otpExpiration)
+	if _, err := s.Repository.UpsertRegistrationVerification(ctx.Request().Context(), repository.UpsertRegistrationVerificationInput{
+		UserId:    user.UserId,
+		CodeHash:  codeHash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create verification", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to create verification",
+		})
+	}
+
+	if err := s.sendVerificationCode(ctx, user.PhoneNumber, code); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to send verification code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to send verification code",
+		})
+	}
+
+	return ctx.JSON(http.StatusAccepted, generated.CodeSentResponse{
+		PhoneNumber: user.PhoneNumber,
+		ExpiresAt:   expiresAt,
+	})
+}
+
+type pendingUser struct {
+	UserId      int
+	PhoneNumber string
+}
+
+// getPendingUser finds the unverified registration of a phone number. The
+// status code and error response are set when there is none.
+func (s *Server) getPendingUser(ctx echo.Context, rawPhoneNumber string) (pendingUser, int, *generated.ErrorResponse) {
+	number, err := s.PhoneParser.Parse(rawPhoneNumber)
+	if err != nil {
+		errResp := phoneNumberErrorResponse(err)
+		return pendingUser{}, http.StatusBadRequest, &errResp
+	}
+
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), repository.GetUserByPhoneNumberInput{
+		PhoneNumber: number.E164,
+	})
+	if err != nil || s.isExpiredRegistration(user) {
+		return pendingUser{}, http.StatusNotFound, &generated.ErrorResponse{
+			Message: "User not found",
+		}
+	}
+	if user.Status != repository.UserStatusPendingVerification {
+		errResp := errorResponse(ErrCodeAlreadyVerified, "Phone number is already verified")
+		return pendingUser{}, http.StatusConflict, &errResp
+	}
+
+	return pendingUser{
+		UserId:      user.UserId,
+		PhoneNumber: number.E164,
+	}, 0, nil
+}
+
+// isExpiredRegistration reports whether the user is an unverified
+// registration older than the registration TTL.
+func (s *Server) isExpiredRegistration(user repository.GetUserByPhoneNumberOutput) bool {
+	return user.Status == repository.UserStatusPendingVerification &&
+		time.Since(user.CreatedAt) > s.RegistrationTTL
+}
+
+func (s *Server) sendVerificationCode(ctx echo.Context, phoneNumber string, code string) error {
+	return s.Notifier.Send(ctx.Request().Context(), notifier.Message{
+		PhoneNumber: phoneNumber,
+		Kind:        notifier.KindOTP,
+		Text:        fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(otpExpiration.Minutes())),
+		Code:        code,
+	})
+}
@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/config"
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/phone"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestVerifyUser(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+	codeHash, _ := h.HashPassword(context.Background(), "123456")
+	pendingUser := repository.GetUserByPhoneNumberOutput{
+		UserId:    1,
+		Status:    repository.UserStatusPendingVerification,
+		CreatedAt: time.Now(),
+	}
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName:     "Empty payload",
+			payload:      "",
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Positive case",
+			payload:  `{"phoneNumber":"0812-345-6789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), repository.GetUserByPhoneNumberInput{
+						PhoneNumber: "+628123456789",
+					}).
+					Return(pendingUser, nil)
+				m.
+					EXPECT().
+					GetRegistrationVerification(gomock.Any(), gomock.Any()).
+					Return(repository.GetRegistrationVerificationOutput{
+						UserId:    1,
+						CodeHash:  codeHash,
+						ExpiresAt: time.Now().Add(time.Minute),
+					}, nil)
+				m.
+					EXPECT().
+					ReserveRegistrationVerificationAttempt(gomock.Any(), repository.ReserveRegistrationVerificationAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(repository.ReserveRegistrationVerificationAttemptOutput{CodeHash: codeHash, Attempts: 1}, nil)
+				m.
+					EXPECT().
+					ActivateUser(gomock.Any(), repository.ActivateUserInput{
+						UserId: 1,
+						Audit: repository.AuditEntry{
+							ActorId:   1,
+							Action:    repository.AuditActionUserActivate,
+							IpAddress: "192.0.2.1",
+						},
+					}).
+					Return(repository.ActivateUserOutput{
+						UserId: 1,
+						Status: repository.UserStatusActive,
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "User not found",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			caseName: "Already verified",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId: 1,
+						Status: repository.UserStatusActive,
+					}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			caseName: "Registration expired",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:    1,
+						Status:    repository.UserStatusPendingVerification,
+						CreatedAt: time.Now().Add(-config.DefaultRegistrationTTL - time.Minute),
+					}, nil)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			caseName: "Invalid code",
+			payload:  `{"phoneNumber":"+628123456789","code":"654321"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(pendingUser, nil)
+				m.
+					EXPECT().
+					GetRegistrationVerification(gomock.Any(), gomock.Any()).
+					Return(repository.GetRegistrationVerificationOutput{
+						UserId:    1,
+						CodeHash:  codeHash,
+						ExpiresAt: time.Now().Add(time.Minute),
+					}, nil)
+				m.
+					EXPECT().
+					ReserveRegistrationVerificationAttempt(gomock.Any(), repository.ReserveRegistrationVerificationAttemptInput{UserId: 1, MaxAttempts: otpMaxAttempts}).
+					Return(repository.ReserveRegistrationVerificationAttemptOutput{CodeHash: codeHash, Attempts: 1}, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Code expired",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(pendingUser, nil)
+				m.
+					EXPECT().
+					GetRegistrationVerification(gomock.Any(), gomock.Any()).
+					Return(repository.GetRegistrationVerificationOutput{
+						UserId:    1,
+						CodeHash:  codeHash,
+						ExpiresAt: time.Now().Add(-time.Minute),
+					}, nil)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Too many attempts",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(pendingUser, nil)
+				m.
+					EXPECT().
+					GetRegistrationVerification(gomock.Any(), gomock.Any()).
+					Return(repository.GetRegistrationVerificationOutput{
+						UserId:    1,
+						CodeHash:  codeHash,
+						Attempts:  otpMaxAttempts,
+						ExpiresAt: time.Now().Add(time.Minute),
+					}, nil)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			caseName: "Attempts used up by parallel requests",
+			payload:  `{"phoneNumber":"+628123456789","code":"123456"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(pendingUser, nil)
+				m.
+					EXPECT().
+					GetRegistrationVerification(gomock.Any(), gomock.Any()).
+					Return(repository.GetRegistrationVerificationOutput{
+						UserId:    1,
+						CodeHash:  codeHash,
+						ExpiresAt: time.Now().Add(time.Minute),
+					}, nil)
+				m.
+					EXPECT().
+					ReserveRegistrationVerificationAttempt(gomock.Any(), gomock.Any()).
+					Return(repository.ReserveRegistrationVerificationAttemptOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:  m,
+				Helper:      h,
+				PhoneParser: ph,
+				Echo:        e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.VerifyUser(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestResendVerificationCode(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	n := notifier.NewMockNotifierInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	ph, _ := phone.NewParser(phone.NewParserOptions{DefaultRegion: "ID"})
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		payload      string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName:     "Invalid phone number",
+			payload:      `{"phoneNumber":"123"}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName: "Positive case",
+			payload:  `{"phoneNumber":"0812-3456-789"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:    1,
+						Status:    repository.UserStatusPendingVerification,
+						CreatedAt: time.Now(),
+					}, nil)
+				m.
+					EXPECT().
+					GetRegistrationVerification(gomock.Any(), repository.GetRegistrationVerificationInput{UserId: 1}).
+					Return(repository.GetRegistrationVerificationOutput{
+						UserId:    1,
+						Attempts:  2,
+						CreatedAt: time.Now().Add(-verificationResendInterval - time.Second),
+					}, nil)
+				m.
+					EXPECT().
+					UpsertRegistrationVerification(gomock.Any(), gomock.Any()).
+					Return(repository.UpsertRegistrationVerificationOutput{UserId: 1}, nil)
+				// The code is sent to the normalized phone number
+				n.
+					EXPECT().
+					Send(gomock.Any(), notifierMessageTo("+628123456789")).
+					Return(nil)
+			},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			caseName: "Resend too soon",
+			payload:  `{"phoneNumber":"+628123456789"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:    1,
+						Status:    repository.UserStatusPendingVerification,
+						CreatedAt: time.Now(),
+					}, nil)
+				m.
+					EXPECT().
+					GetRegistrationVerification(gomock.Any(), gomock.Any()).
+					Return(repository.GetRegistrationVerificationOutput{UserId: 1, CreatedAt: time.Now()}, nil)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			caseName: "Attempts used up",
+			payload:  `{"phoneNumber":"+628123456789"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId:    1,
+						Status:    repository.UserStatusPendingVerification,
+						CreatedAt: time.Now(),
+					}, nil)
+				m.
+					EXPECT().
+					GetRegistrationVerification(gomock.Any(), gomock.Any()).
+					Return(repository.GetRegistrationVerificationOutput{
+						UserId:    1,
+						Attempts:  otpMaxAttempts,
+						CreatedAt: time.Now().Add(-time.Hour),
+					}, nil)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			caseName: "Already verified",
+			payload:  `{"phoneNumber":"+628123456789"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserByPhoneNumber(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByPhoneNumberOutput{
+						UserId: 1,
+						Status: repository.UserStatusActive,
+					}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:  m,
+				Helper:      h,
+				PhoneParser: ph,
+				Notifier:    n,
+				Echo:        e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(test.payload),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.ResendVerificationCode(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
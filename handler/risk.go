@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/risk"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// riskHistoryLimit is the number of latest login events the login is
+	// compared with
+	riskHistoryLimit = 100
+	// riskAssessmentKey holds the assessment of the login in the echo
+	// context, the login events of the request are recorded with it.
+	riskAssessmentKey = "riskAssessment"
+)
+
+// assessLogin compares the login with the login history of the user. The
+// assessment fails open, a login is not blocked because the history is
+// unavailable.
+func (s *Server) assessLogin(ctx echo.Context, userId int) risk.Assessment {
+	allow := risk.Assessment{Decision: risk.DecisionAllow}
+	if s.RiskAssessor == nil {
+		return allow
+	}
+
+	resp, err := s.Repository.GetLoginEvents(ctx.Request().Context(), repository.GetLoginEventsInput{
+		UserId: userId,
+		Limit:  riskHistoryLimit,
+	})
+	if err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to get login history", "error", err)
+		return allow
+	}
+
+	history := make([]risk.Attempt, 0, len(resp.Events))
+	for _, event := range resp.Events {
+		// A correct password waiting for a second step is neither a known
+		// device nor a failed attempt
+		if event.FailureReason == repository.LoginFailureMfaRequired ||
+			event.FailureReason == repository.LoginFailureStepUpRequired {
+			continue
+		}
+		history = append(history, risk.Attempt{
+			IpAddress: event.IpAddress,
+			UserAgent: event.UserAgent,
+			Success:   event.Success,
+			At:        event.CreatedAt,
+		})
+	}
+
+	assessment := s.RiskAssessor.Assess(risk.AssessInput{
+		Current: risk.Attempt{
+			IpAddress: ctx.RealIP(),
+			UserAgent: truncate(ctx.Request().UserAgent(), maxUserAgentLength),
+			At:        time.Now(),
+		},
+		History: history,
+	})
+	ctx.Set(riskAssessmentKey, assessment)
+
+	return assessment
+}
+
+// notifyRiskyLogin tells the owner of the phone number about a login which
+// doesn't look like their usual ones.
+func (s *Server) notifyRiskyLogin(ctx echo.Context, phoneNumber string, assessment risk.Assessment) {
+	where := ctx.RealIP()
+	if assessment.Location != nil && assessment.Location.City != "" {
+		where = fmt.Sprintf("%s, %s (%s)", assessment.Location.City, assessment.Location.Country, where)
+	}
+	if err := s.Notifier.Send(ctx.Request().Context(), notifier.Message{
+		PhoneNumber: phoneNumber,
+		Kind:        notifier.KindSecurityNotice,
+		Text:        fmt.Sprintf("New login to your account from %s. If this was not you, change your password.", where),
+	}); err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Failed to send new login notice", "error", err)
+	}
+}
+
+// stepUpChallenge asks for the login code sent to the phone number before
+// a risky login is completed. A code sent moments ago is not sent again.
+func (s *Server) stepUpChallenge(ctx echo.Context, userId int, phoneNumber string) error {
+	expiresAt := time.Now().Add(otpExpiration)
+	previous, err := s.Repository.GetLoginCode(ctx.Request().Context(), repository.GetLoginCodeInput{
+		UserId: userId,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get login code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get login code",
+		})
+	}
+
+	if err == nil && time.Since(previous.CreatedAt) < loginCodeResendInterval {
+		expiresAt = previous.ExpiresAt
+	} else if err := s.sendLoginCode(ctx, userId, phoneNumber, expiresAt); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to send login code", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to send login code",
+		})
+	}
+	s.recordLoginEvent(ctx, userId, repository.LoginMethodPassword, repository.LoginFailureStepUpRequired)
+
+	return ctx.JSON(http.StatusAccepted, generated.StepUpChallengeResponse{
+		StepUpRequired: true,
+		PhoneNumber:    maskPhoneNumber(phoneNumber),
+		ExpiresAt:      expiresAt,
+	})
+}
+
+// riskOf returns the assessment of the request, if any, in the form it is
+// recorded.
+func riskOf(ctx echo.Context) (decision string, reasons []string) {
+	assessment, ok := ctx.Get(riskAssessmentKey).(risk.Assessment)
+	if !ok {
+		return "", nil
+	}
+	return assessment.Decision, assessment.Reasons
+}
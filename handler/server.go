@@ -1,21 +1,80 @@
 package handler
 
 import (
+	"log/slog"
+	"time"
+
+	"github.com/asrul10/UserService/config"
+	"github.com/asrul10/UserService/encryption"
 	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/metrics"
+	"github.com/asrul10/UserService/notifier"
+	"github.com/asrul10/UserService/password"
+	"github.com/asrul10/UserService/phone"
 	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/risk"
+	"github.com/asrul10/UserService/totp"
+	"github.com/asrul10/UserService/tracing"
+	"github.com/asrul10/UserService/webauthn"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Server struct {
-	Repository repository.RepositoryInterface
-	Helper     helper.HelperInterface
+	Repository        repository.RepositoryInterface
+	Helper            helper.HelperInterface
+	PasswordPolicy    password.PolicyInterface
+	PasswordBlocklist password.BlocklistInterface
+	PasswordEstimator password.EstimatorInterface
+	PhoneParser       phone.ParserInterface
+	Notifier          notifier.NotifierInterface
+	TOTP              totp.TOTPInterface
+	WebAuthn          webauthn.WebAuthnInterface
+	// RiskAssessor flags password logins which don't look like the usual
+	// ones of the user, nil disables the assessment
+	RiskAssessor risk.AssessorInterface
+	// SecretCipher encrypts secrets stored in the database
+	SecretCipher encryption.CipherInterface
+	// RegistrationTTL is how long an unverified registration holds the
+	// phone number.
+	RegistrationTTL time.Duration
+	// RefreshTokenTTL and MfaTokenTTL are the lifetimes of the sessions
+	// and the pending logins, they match the tokens of the Helper
+	RefreshTokenTTL time.Duration
+	MfaTokenTTL     time.Duration
+	Logger          *slog.Logger
+	Metrics         metrics.MetricsInterface
+	Tracer          trace.Tracer
 }
 
 type NewServerOptions struct {
-	Repository repository.RepositoryInterface
-	Helper     helper.HelperInterface
-	Echo       *echo.Echo
+	Repository        repository.RepositoryInterface
+	Helper            helper.HelperInterface
+	PasswordPolicy    password.PolicyInterface
+	PasswordBlocklist password.BlocklistInterface
+	PasswordEstimator password.EstimatorInterface
+	PhoneParser       phone.ParserInterface
+	Notifier          notifier.NotifierInterface
+	TOTP              totp.TOTPInterface
+	WebAuthn          webauthn.WebAuthnInterface
+	RiskAssessor      risk.AssessorInterface
+	SecretCipher      encryption.CipherInterface
+	// RegistrationTTL is the one of the config, defaults to
+	// config.DefaultRegistrationTTL
+	RegistrationTTL time.Duration
+	// RefreshTokenTTL defaults to helper.RefreshTokenExpireDuration
+	RefreshTokenTTL time.Duration
+	// MfaTokenTTL defaults to helper.MfaTokenExpireDuration
+	MfaTokenTTL time.Duration
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+	// Metrics defaults to metrics which are not served
+	Metrics metrics.MetricsInterface
+	// Tracer defaults to the tracer of the global provider
+	Tracer trace.Tracer
+	Echo   *echo.Echo
 }
 
 func NewServer(opts NewServerOptions) *Server {
@@ -24,8 +83,52 @@ func NewServer(opts NewServerOptions) *Server {
 		validator: validator.New(),
 	}
 
+	registrationTTL := opts.RegistrationTTL
+	if registrationTTL == 0 {
+		registrationTTL = config.DefaultRegistrationTTL
+	}
+
+	refreshTokenTTL := opts.RefreshTokenTTL
+	if refreshTokenTTL <= 0 {
+		refreshTokenTTL = helper.RefreshTokenExpireDuration
+	}
+	mfaTokenTTL := opts.MfaTokenTTL
+	if mfaTokenTTL <= 0 {
+		mfaTokenTTL = helper.MfaTokenExpireDuration
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	m := opts.Metrics
+	if m == nil {
+		m = metrics.NewMetrics(metrics.NewMetricsOptions{})
+	}
+
+	tracer := opts.Tracer
+	if tracer == nil {
+		tracer = otel.Tracer(tracing.TracerName)
+	}
+
 	return &Server{
-		Repository: opts.Repository,
-		Helper:     opts.Helper,
+		Repository:        opts.Repository,
+		Helper:            opts.Helper,
+		PasswordPolicy:    opts.PasswordPolicy,
+		PasswordBlocklist: opts.PasswordBlocklist,
+		PasswordEstimator: opts.PasswordEstimator,
+		PhoneParser:       opts.PhoneParser,
+		Notifier:          opts.Notifier,
+		TOTP:              opts.TOTP,
+		WebAuthn:          opts.WebAuthn,
+		RiskAssessor:      opts.RiskAssessor,
+		SecretCipher:      opts.SecretCipher,
+		RegistrationTTL:   registrationTTL,
+		RefreshTokenTTL:   refreshTokenTTL,
+		MfaTokenTTL:       mfaTokenTTL,
+		Logger:            logger,
+		Metrics:           m,
+		Tracer:            tracer,
 	}
 }
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxUserAgentLength = 255
+	// sessionTouchInterval limits how often a request updates the last
+	// seen time of its session.
+	sessionTouchInterval = time.Minute * 5
+)
+
+// (POST /api/v1/users/token/refresh)
+func (s *Server) RefreshToken(ctx echo.Context) error {
+	payload := new(generated.RefreshTokenJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	unauthorized := generated.ErrorResponse{
+		Message: "Invalid refresh token",
+	}
+
+	claims, err := s.Helper.VerifyRefreshToken(ctx.Request().Context(), payload.RefreshToken)
+	if err != nil {
+		s.tokenVerificationFailed(helper.RefreshTokenType, err)
+		return ctx.JSON(http.StatusUnauthorized, unauthorized)
+	}
+	// Refresh tokens issued before sessions existed can't be rotated
+	if claims.SessionId == 0 {
+		s.Metrics.IncTokenVerificationFailures(helper.RefreshTokenType, tokenFailureInvalid)
+		return ctx.JSON(http.StatusUnauthorized, unauthorized)
+	}
+
+	state, err := s.Repository.GetTokenVersion(ctx.Request().Context(), repository.GetTokenVersionInput{
+		UserId: claims.UserId,
+	})
+	if err != nil || state.TokenVersion != claims.TokenVersion {
+		if err == nil {
+			s.tokenVerificationFailed(helper.RefreshTokenType, errTokenRevoked)
+		}
+		return ctx.JSON(http.StatusUnauthorized, unauthorized)
+	}
+
+	refreshTokenId, err := s.Helper.GenerateTokenId(ctx.Request().Context())
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate refresh token", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate refresh token",
+		})
+	}
+
+	rotated, err := s.Repository.RotateSessionRefreshToken(ctx.Request().Context(), repository.RotateSessionRefreshTokenInput{
+		Id:                     claims.SessionId,
+		PreviousRefreshTokenId: claims.RefreshTokenId,
+		RefreshTokenId:         refreshTokenId,
+		ExpiresAt:              time.Now().Add(s.RefreshTokenTTL),
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to refresh session", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to refresh session",
+		})
+	}
+	if !rotated.Accepted {
+		// A refresh token which was already rotated is used again, either
+		// the client or an attacker holds a stolen copy. The whole session
+		// is revoked since it's unknown which one is legitimate.
+		if _, err := s.Repository.RevokeSession(ctx.Request().Context(), repository.RevokeSessionInput{
+			Id:     claims.SessionId,
+			UserId: claims.UserId,
+		}); err != nil {
+			s.Logger.ErrorContext(ctx.Request().Context(), "Failed to revoke session", "error", err)
+		}
+		s.tokenVerificationFailed(helper.RefreshTokenType, errTokenReused)
+		return ctx.JSON(http.StatusUnauthorized, unauthorized)
+	}
+
+	claims.RefreshTokenId = refreshTokenId
+	tokens, err := s.issueTokens(ctx, claims)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate token", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to generate token",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, tokens)
+}
+
+// (GET /api/v1/users/sessions)
+func (s *Server) GetSessions(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	resp, err := s.Repository.GetSessions(ctx.Request().Context(), repository.GetSessionsInput{
+		UserId: claims.UserId,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get sessions", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get sessions",
+		})
+	}
+
+	sessions := make([]generated.Session, 0, len(resp.Sessions))
+	for _, session := range resp.Sessions {
+		sessions = append(sessions, generated.Session{
+			Id:         session.Id,
+			UserAgent:  session.UserAgent,
+			IpAddress:  session.IpAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.Id == claims.SessionId,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.SessionsResponse{
+		Sessions: sessions,
+	})
+}
+
+// (DELETE /api/v1/users/sessions/{id})
+func (s *Server) RevokeSession(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	resp, err := s.Repository.RevokeSession(ctx.Request().Context(), repository.RevokeSessionInput{
+		Id:     id,
+		UserId: claims.UserId,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to revoke session", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to revoke session",
+		})
+	}
+	if !resp.Revoked {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "Session not found",
+		})
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// issueTokens signs the access and refresh tokens of a session, the access
+// token carries the current roles of the user.
+func (s *Server) issueTokens(ctx echo.Context, tokenClaims helper.TokenClaims) (generated.LoginUserResponse, error) {
+	tokenClaims, err := s.withRoles(ctx, tokenClaims)
+	if err != nil {
+		return generated.LoginUserResponse{}, err
+	}
+
+	token := ""
+	if err := s.Helper.GenerateAccessToken(ctx.Request().Context(), &token, tokenClaims); err != nil {
+		return generated.LoginUserResponse{}, err
+	}
+	refreshToken := ""
+	if err := s.Helper.GenerateRefreshToken(ctx.Request().Context(), &refreshToken, tokenClaims); err != nil {
+		return generated.LoginUserResponse{}, err
+	}
+
+	return generated.LoginUserResponse{
+		UserId:       tokenClaims.UserId,
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// checkSession makes sure the session of the token is not revoked or
+// expired, and records the activity of the session.
+func (s *Server) checkSession(ctx echo.Context, claims helper.TokenClaims) error {
+	resp, err := s.Repository.GetSession(ctx.Request().Context(), repository.GetSessionInput{
+		Id: claims.SessionId,
+	})
+	if err != nil {
+		return err
+	}
+	session := resp.Session
+	if session.UserId != claims.UserId || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return errTokenRevoked
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		if _, err := s.Repository.TouchSession(ctx.Request().Context(), repository.TouchSessionInput{
+			Id: session.Id,
+		}); err != nil {
+			s.Logger.WarnContext(ctx.Request().Context(), "Failed to touch session", "error", err)
+		}
+	}
+
+	return nil
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length]
+}
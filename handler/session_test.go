@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRefreshToken(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	refreshToken := func(claims helper.TokenClaims) string {
+		token := ""
+		h.GenerateRefreshToken(context.Background(), &token, claims)
+		return token
+	}
+	sessionClaims := helper.TokenClaims{UserId: 1, SessionId: 2, RefreshTokenId: "first"}
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		token        string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName:     "Invalid token",
+			token:        "invalid",
+			mockFunc:     func() {},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			caseName:     "Token issued before sessions",
+			token:        refreshToken(helper.TokenClaims{UserId: 1}),
+			mockFunc:     func() {},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			caseName: "Positive case",
+			token:    refreshToken(sessionClaims),
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+				m.
+					EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, input repository.RotateSessionRefreshTokenInput) (repository.RotateSessionRefreshTokenOutput, error) {
+						if input.Id != 2 || input.PreviousRefreshTokenId != "first" || input.RefreshTokenId == "first" {
+							t.Errorf("Unexpected rotation %v", input)
+						}
+						return repository.RotateSessionRefreshTokenOutput{Accepted: true}, nil
+					})
+				m.
+					EXPECT().
+					GetUserRoles(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserRolesOutput{}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName: "Reused token revokes the session",
+			token:    refreshToken(sessionClaims),
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil)
+				m.
+					EXPECT().
+					RotateSessionRefreshToken(gomock.Any(), gomock.Any()).
+					Return(repository.RotateSessionRefreshTokenOutput{Accepted: false}, nil)
+				m.
+					EXPECT().
+					RevokeSession(gomock.Any(), repository.RevokeSessionInput{Id: 2, UserId: 1}).
+					Return(repository.RevokeSessionOutput{Revoked: true}, nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			caseName: "Token version bumped",
+			token:    refreshToken(sessionClaims),
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetTokenVersion(gomock.Any(), gomock.Any()).
+					Return(repository.GetTokenVersionOutput{TokenVersion: 1}, nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(`{"refreshToken":"`+test.token+`"}`),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.RefreshToken(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			// The new refresh token belongs to the same session
+			var resp generated.LoginUserResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			claims, err := h.VerifyRefreshToken(context.Background(), resp.RefreshToken)
+			if err != nil || claims.SessionId != 2 || claims.RefreshTokenId == "first" {
+				t.Errorf("Expected rotated refresh token of session 2, got %v", claims)
+			}
+		})
+	}
+}
+
+func TestGetSessions(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, SessionId: 2})
+	revokedAt := time.Now()
+	activeSession := repository.Session{
+		Id:         2,
+		UserId:     1,
+		ExpiresAt:  time.Now().Add(time.Hour),
+		LastSeenAt: time.Now(),
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName         string
+		mockFunc         func()
+		expectedCode     int
+		expectedSessions int
+	}{
+		{
+			caseName: "Positive case",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetSession(gomock.Any(), repository.GetSessionInput{Id: 2}).
+					Return(repository.GetSessionOutput{Session: activeSession}, nil)
+				m.
+					EXPECT().
+					GetSessions(gomock.Any(), repository.GetSessionsInput{UserId: 1}).
+					Return(repository.GetSessionsOutput{
+						Sessions: []repository.Session{activeSession, {Id: 3, UserId: 1, UserAgent: "curl/8.0"}},
+					}, nil)
+			},
+			expectedCode:     http.StatusOK,
+			expectedSessions: 2,
+		},
+		{
+			caseName: "Stale session is touched",
+			mockFunc: func() {
+				stale := activeSession
+				stale.LastSeenAt = time.Now().Add(-time.Hour)
+				m.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Return(repository.GetSessionOutput{Session: stale}, nil)
+				m.
+					EXPECT().
+					TouchSession(gomock.Any(), repository.TouchSessionInput{Id: 2}).
+					Return(repository.TouchSessionOutput{Id: 2}, nil)
+				m.
+					EXPECT().
+					GetSessions(gomock.Any(), gomock.Any()).
+					Return(repository.GetSessionsOutput{Sessions: []repository.Session{activeSession}}, nil)
+			},
+			expectedCode:     http.StatusOK,
+			expectedSessions: 1,
+		},
+		{
+			caseName: "Revoked session",
+			mockFunc: func() {
+				revoked := activeSession
+				revoked.RevokedAt = &revokedAt
+				m.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Return(repository.GetSessionOutput{Session: revoked}, nil)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Session of another user",
+			mockFunc: func() {
+				other := activeSession
+				other.UserId = 2
+				m.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Return(repository.GetSessionOutput{Session: other}, nil)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.GetSessions(c); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp generated.SessionsResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if len(resp.Sessions) != test.expectedSessions {
+				t.Errorf("Expected %d sessions, got %d", test.expectedSessions, len(resp.Sessions))
+			}
+			if !resp.Sessions[0].Current {
+				t.Errorf("Expected session 2 to be the current one")
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		sessionId    int
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName:  "Positive case",
+			sessionId: 3,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					RevokeSession(gomock.Any(), repository.RevokeSessionInput{Id: 3, UserId: 1}).
+					Return(repository.RevokeSessionOutput{Revoked: true}, nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			caseName:  "Not found",
+			sessionId: 4,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					RevokeSession(gomock.Any(), gomock.Any()).
+					Return(repository.RevokeSessionOutput{Revoked: false}, nil)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			caseName:  "Database error",
+			sessionId: 5,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					RevokeSession(gomock.Any(), gomock.Any()).
+					Return(repository.RevokeSessionOutput{}, sql.ErrConnDone)
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.RevokeSession(c, test.sessionId); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace records a span for every request named after the operationId of
+// the spec. A W3C trace context in the request headers is continued.
+func (s *Server) Trace(swagger *openapi3.T) echo.MiddlewareFunc {
+	operations := operationIds(swagger)
+	propagator := propagation.TraceContext{}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			name, ok := operations[req.Method+" "+ctx.Path()]
+			if !ok {
+				name = req.Method
+			}
+
+			parent := propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			spanCtx, span := s.Tracer.Start(parent, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.HTTPRoute(ctx.Path()),
+				),
+			)
+			defer span.End()
+			ctx.SetRequest(req.WithContext(spanCtx))
+
+			// Like RecordMetrics the error is handled here, the status
+			// of its response is recorded
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			status := ctx.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return nil
+		}
+	}
+}
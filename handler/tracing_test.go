@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestTrace(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+		Tracer:            tracer,
+	})
+
+	// Creating the server
+	e := echo.New()
+	server := NewServer(NewServerOptions{
+		Repository: m,
+		Helper:     h,
+		Tracer:     tracer,
+		Echo:       e,
+	})
+	swagger, err := generated.GetSwagger()
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	e.Use(server.Trace(swagger))
+	generated.RegisterHandlers(e, server)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected %d, got %d", http.StatusForbidden, rec.Code)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) < 2 {
+		t.Fatalf("Expected the spans of the request and its token verification, got %d", len(spans))
+	}
+	request := spans[len(spans)-1]
+	if request.Name() != "GetRoles" {
+		t.Errorf("Expected span GetRoles, got %s", request.Name())
+	}
+	if request.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the trace of the traceparent header, got %s", request.SpanContext().TraceID())
+	}
+	if request.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the span of the traceparent header as parent, got %s", request.Parent().SpanID())
+	}
+	found := false
+	for _, attr := range request.Attributes() {
+		if attr == semconv.HTTPResponseStatusCode(http.StatusForbidden) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected status code attribute, got %v", request.Attributes())
+	}
+
+	verify := spans[len(spans)-2]
+	if verify.Name() != "Helper.VerifyToken" || verify.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("Expected Helper.VerifyToken span of the request, got %s", verify.Name())
+	}
+}
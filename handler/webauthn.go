@@ -0,0 +1,408 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/webauthn"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPasskeyName = "Passkey"
+	// Options requested from the browser, attestation is not verified so
+	// none is asked.
+	webauthnAttestation      = "none"
+	webauthnResidentKey      = "preferred"
+	webauthnUserVerification = "preferred"
+)
+
+// (POST /api/v1/users/webauthn/register/options)
+func (s *Server) BeginWebAuthnRegistration(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	user, err := s.Repository.GetUserById(ctx.Request().Context(), repository.GetUserByIdInput{
+		UserId: claims.UserId,
+	})
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "User not found",
+		})
+	}
+
+	// The same authenticator can't be registered twice
+	existing, err := s.Repository.GetWebAuthnCredentials(ctx.Request().Context(), repository.GetWebAuthnCredentialsInput{
+		UserId: claims.UserId,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get passkeys", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get passkeys",
+		})
+	}
+	exclude := make([][]byte, 0, len(existing.Credentials))
+	for _, c := range existing.Credentials {
+		exclude = append(exclude, c.CredentialId)
+	}
+
+	options, err := s.WebAuthn.BeginRegistration(webauthn.User{
+		ID:          userHandle(claims.UserId),
+		Name:        user.PhoneNumber,
+		DisplayName: user.FullName,
+	}, exclude)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create challenge", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to create challenge",
+		})
+	}
+
+	if _, err := s.Repository.CreateWebAuthnChallenge(ctx.Request().Context(), repository.CreateWebAuthnChallengeInput{
+		Challenge: options.Challenge,
+		UserId:    claims.UserId,
+		Ceremony:  repository.WebAuthnCeremonyRegistration,
+		ExpiresAt: time.Now().Add(options.Timeout),
+	}); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create challenge", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to create challenge",
+		})
+	}
+
+	params := make([]generated.WebAuthnCredentialParameter, 0, len(options.Algorithms))
+	for _, alg := range options.Algorithms {
+		params = append(params, generated.WebAuthnCredentialParameter{
+			Type: "public-key",
+			Alg:  alg,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.WebAuthnRegistrationOptions{
+		Challenge: options.Challenge,
+		Rp: generated.WebAuthnRelyingParty{
+			Id:   options.RPID,
+			Name: options.RPName,
+		},
+		User: generated.WebAuthnUser{
+			Id:          base64.RawURLEncoding.EncodeToString(options.User.ID),
+			Name:        options.User.Name,
+			DisplayName: options.User.DisplayName,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            int(options.Timeout.Milliseconds()),
+		Attestation:        webauthnAttestation,
+		ExcludeCredentials: credentialDescriptors(options.ExcludeCredentials),
+		AuthenticatorSelection: generated.WebAuthnAuthenticatorSelection{
+			ResidentKey:      webauthnResidentKey,
+			UserVerification: webauthnUserVerification,
+		},
+	})
+}
+
+// (POST /api/v1/users/webauthn/register)
+func (s *Server) FinishWebAuthnRegistration(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	payload := new(generated.FinishWebAuthnRegistrationJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	fields, err := decodeBase64URLFields(payload.Id, payload.ClientDataJSON, payload.AttestationObject)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid base64url encoding",
+		})
+	}
+	credentialID, clientDataJSON, attestationObject := fields[0], fields[1], fields[2]
+
+	challenge, err := s.consumeWebAuthnChallenge(ctx, clientDataJSON, repository.WebAuthnCeremonyRegistration)
+	if err != nil || challenge.UserId != claims.UserId {
+		return ctx.JSON(http.StatusBadRequest, errorResponse(ErrCodeWebAuthnFailed, "Invalid or expired challenge"))
+	}
+
+	credential, err := s.WebAuthn.FinishRegistration(webauthn.FinishRegistrationInput{
+		Challenge:         challenge.Challenge,
+		CredentialID:      credentialID,
+		ClientDataJSON:    clientDataJSON,
+		AttestationObject: attestationObject,
+	})
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, errorResponse(ErrCodeWebAuthnFailed, "Passkey registration failed: "+err.Error()))
+	}
+
+	name := defaultPasskeyName
+	if payload.Name != nil && strings.TrimSpace(*payload.Name) != "" {
+		name = strings.TrimSpace(*payload.Name)
+	}
+	created, err := s.Repository.CreateWebAuthnCredential(ctx.Request().Context(), repository.CreateWebAuthnCredentialInput{
+		UserId:       claims.UserId,
+		CredentialId: credential.ID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    int64(credential.SignCount),
+		AAGUID:       credential.AAGUID,
+		Name:         name,
+	})
+	if errors.Is(err, repository.ErrWebAuthnCredentialExists) {
+		return ctx.JSON(http.StatusConflict, errorResponse(ErrCodeWebAuthnFailed, "Passkey already registered"))
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to save passkey", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to save passkey",
+		})
+	}
+
+	return ctx.JSON(http.StatusCreated, generated.WebAuthnCredentialResponse{
+		Id:        base64.RawURLEncoding.EncodeToString(credential.ID),
+		Name:      name,
+		CreatedAt: created.CreatedAt,
+	})
+}
+
+// (POST /api/v1/users/login/webauthn/options)
+func (s *Server) BeginWebAuthnLogin(ctx echo.Context) error {
+	payload := new(generated.BeginWebAuthnLoginJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	// With a phone number the passkeys of the user are listed, for
+	// authenticators which can't discover their credentials.
+	allow := [][]byte{}
+	if payload.PhoneNumber != nil && *payload.PhoneNumber != "" {
+		number, err := s.PhoneParser.Parse(*payload.PhoneNumber)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, phoneNumberErrorResponse(err))
+		}
+		user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), repository.GetUserByPhoneNumberInput{
+			PhoneNumber: number.E164,
+		})
+		if err == nil {
+			existing, err := s.Repository.GetWebAuthnCredentials(ctx.Request().Context(), repository.GetWebAuthnCredentialsInput{
+				UserId: user.UserId,
+			})
+			if err != nil {
+				s.Logger.WarnContext(ctx.Request().Context(), "Failed to get passkeys", "error", err)
+			}
+			for _, c := range existing.Credentials {
+				allow = append(allow, c.CredentialId)
+			}
+		}
+	}
+
+	options, err := s.WebAuthn.BeginLogin(allow)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create challenge", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to create challenge",
+		})
+	}
+
+	if _, err := s.Repository.CreateWebAuthnChallenge(ctx.Request().Context(), repository.CreateWebAuthnChallengeInput{
+		Challenge: options.Challenge,
+		Ceremony:  repository.WebAuthnCeremonyLogin,
+		ExpiresAt: time.Now().Add(options.Timeout),
+	}); err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create challenge", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to create challenge",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.WebAuthnLoginOptions{
+		Challenge:        options.Challenge,
+		RpId:             options.RPID,
+		Timeout:          int(options.Timeout.Milliseconds()),
+		UserVerification: webauthnUserVerification,
+		AllowCredentials: credentialDescriptors(options.AllowCredentials),
+	})
+}
+
+// (POST /api/v1/users/login/webauthn)
+func (s *Server) FinishWebAuthnLogin(ctx echo.Context) error {
+	payload := new(generated.FinishWebAuthnLoginJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	fields, err := decodeBase64URLFields(payload.Id, payload.ClientDataJSON, payload.AuthenticatorData, payload.Signature)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid base64url encoding",
+		})
+	}
+	credentialID, clientDataJSON, authenticatorData, signature := fields[0], fields[1], fields[2], fields[3]
+
+	unauthorized := errorResponse(ErrCodeWebAuthnFailed, "Passkey login failed")
+
+	challenge, err := s.consumeWebAuthnChallenge(ctx, clientDataJSON, repository.WebAuthnCeremonyLogin)
+	if err != nil {
+		s.recordLoginEvent(ctx, 0, repository.LoginMethodPasskey, repository.LoginFailureInvalidPasskey)
+		return ctx.JSON(http.StatusUnauthorized, unauthorized)
+	}
+
+	stored, err := s.Repository.GetWebAuthnCredential(ctx.Request().Context(), repository.GetWebAuthnCredentialInput{
+		CredentialId: credentialID,
+	})
+	if err != nil {
+		s.recordLoginEvent(ctx, 0, repository.LoginMethodPasskey, repository.LoginFailureInvalidPasskey)
+		return ctx.JSON(http.StatusUnauthorized, unauthorized)
+	}
+
+	result, err := s.WebAuthn.FinishLogin(webauthn.FinishLoginInput{
+		Challenge: challenge.Challenge,
+		Credential: webauthn.Credential{
+			ID:        stored.Credential.CredentialId,
+			PublicKey: stored.Credential.PublicKey,
+			SignCount: uint32(stored.Credential.SignCount),
+		},
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authenticatorData,
+		Signature:         signature,
+	})
+	if err != nil {
+		s.Logger.WarnContext(ctx.Request().Context(), "Passkey login failed", "user_id", stored.Credential.UserId, "error", err)
+		s.recordLoginEvent(ctx, stored.Credential.UserId, repository.LoginMethodPasskey, repository.LoginFailureInvalidPasskey)
+		return ctx.JSON(http.StatusUnauthorized, unauthorized)
+	}
+
+	updated, err := s.Repository.UpdateWebAuthnSignCount(ctx.Request().Context(), repository.UpdateWebAuthnSignCountInput{
+		Id:                stored.Credential.Id,
+		PreviousSignCount: stored.Credential.SignCount,
+		SignCount:         int64(result.SignCount),
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to update passkey", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to update passkey",
+		})
+	}
+	if !updated.Accepted {
+		s.recordLoginEvent(ctx, stored.Credential.UserId, repository.LoginMethodPasskey, repository.LoginFailureInvalidPasskey)
+		return ctx.JSON(http.StatusUnauthorized, unauthorized)
+	}
+
+	state, err := s.Repository.GetTokenVersion(ctx.Request().Context(), repository.GetTokenVersionInput{
+		UserId: stored.Credential.UserId,
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to get user", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to get user",
+		})
+	}
+	if state.Status == repository.UserStatusSuspended {
+		s.recordLoginEvent(ctx, stored.Credential.UserId, repository.LoginMethodPasskey, repository.LoginFailureAccountSuspended)
+		return ctx.JSON(http.StatusForbidden, errorResponse(ErrCodeAccountSuspended, "Account is suspended"))
+	}
+
+	return s.completeLogin(ctx, helper.TokenClaims{
+		UserId:       stored.Credential.UserId,
+		TokenVersion: state.TokenVersion,
+	}, repository.LoginMethodPasskey)
+}
+
+type webauthnChallenge struct {
+	Challenge string
+	UserId    int
+}
+
+// consumeWebAuthnChallenge looks up the challenge signed in the client data
+// and makes sure it was issued for the ceremony and is not expired. The
+// challenge is consumed even if the ceremony fails afterwards.
+func (s *Server) consumeWebAuthnChallenge(ctx echo.Context, clientDataJSON []byte, ceremony string) (webauthnChallenge, error) {
+	challenge, err := s.WebAuthn.ChallengeOf(clientDataJSON)
+	if err != nil {
+		return webauthnChallenge{}, err
+	}
+
+	pending, err := s.Repository.ConsumeWebAuthnChallenge(ctx.Request().Context(), repository.ConsumeWebAuthnChallengeInput{
+		Challenge: challenge,
+	})
+	if err != nil {
+		return webauthnChallenge{}, err
+	}
+	if pending.Ceremony != ceremony || time.Now().After(pending.ExpiresAt) {
+		return webauthnChallenge{}, errCodeExpired
+	}
+
+	return webauthnChallenge{
+		Challenge: challenge,
+		UserId:    pending.UserId,
+	}, nil
+}
+
+// userHandle is the WebAuthn user id, the opaque user id of the service
+func userHandle(userId int) []byte {
+	return []byte(strconv.Itoa(userId))
+}
+
+func credentialDescriptors(ids [][]byte) []generated.WebAuthnCredentialDescriptor {
+	descriptors := make([]generated.WebAuthnCredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		descriptors = append(descriptors, generated.WebAuthnCredentialDescriptor{
+			Type: "public-key",
+			Id:   base64.RawURLEncoding.EncodeToString(id),
+		})
+	}
+	return descriptors
+}
+
+// decodeBase64URLFields decodes base64url values with or without padding,
+// browser libraries differ.
+func decodeBase64URLFields(values ...string) ([][]byte, error) {
+	decoded := make([][]byte, 0, len(values))
+	for _, value := range values {
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, b)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/asrul10/UserService/webauthn"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func newTestWebAuthn() *webauthn.WebAuthn {
+	return webauthn.NewWebAuthn(webauthn.NewWebAuthnOptions{
+		RPID:    "localhost",
+		RPName:  "UserService",
+		Origins: []string{"http://localhost:1323"},
+	})
+}
+
+func TestBeginWebAuthnRegistration(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	validToken := func() string {
+		token := ""
+		h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+		return token
+	}
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName        string
+		token           func() string
+		mockFunc        func()
+		expectedCode    int
+		expectedExclude int
+	}{
+		{
+			caseName: "Unauthorized",
+			token: func() string {
+				return ""
+			},
+			mockFunc:     func() {},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName: "Positive case",
+			token:    validToken,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Return(repository.GetUserByIdOutput{UserId: 1, PhoneNumber: "+628123456789", FullName: "John Doe"}, nil)
+				m.
+					EXPECT().
+					GetWebAuthnCredentials(gomock.Any(), repository.GetWebAuthnCredentialsInput{UserId: 1}).
+					Return(repository.GetWebAuthnCredentialsOutput{
+						Credentials: []repository.WebAuthnCredential{{Id: 1, UserId: 1, CredentialId: []byte("existing")}},
+					}, nil)
+				m.
+					EXPECT().
+					CreateWebAuthnChallenge(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, input repository.CreateWebAuthnChallengeInput) (repository.CreateWebAuthnChallengeOutput, error) {
+						if input.UserId != 1 || input.Ceremony != repository.WebAuthnCeremonyRegistration {
+							t.Errorf("Expected registration challenge of user 1, got %v", input)
+						}
+						return repository.CreateWebAuthnChallengeOutput{Challenge: input.Challenge}, nil
+					})
+			},
+			expectedCode:    http.StatusOK,
+			expectedExclude: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				WebAuthn:   newTestWebAuthn(),
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+test.token())
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			test.mockFunc()
+
+			if err := server.BeginWebAuthnRegistration(ctx); err != nil {
+				t.Errorf("Error: %v", err)
+			}
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var options generated.WebAuthnRegistrationOptions
+			json.Unmarshal(rec.Body.Bytes(), &options)
+			if options.Rp.Id != "localhost" || options.User.Name != "+628123456789" {
+				t.Errorf("Unexpected options %v", options)
+			}
+			if len(options.ExcludeCredentials) != test.expectedExclude {
+				t.Errorf("Expected %d excluded credentials, got %d", test.expectedExclude, len(options.ExcludeCredentials))
+			}
+		})
+	}
+}
+
+// TestWebAuthnPasskeyFlow registers a passkey with a software authenticator
+// and logs in with it, the repository keeps its state in memory.
+func TestWebAuthnPasskeyFlow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	// Login attempts are recorded, the events are checked in TestLoginUser
+	m.
+		EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		Return(repository.CreateLoginEventOutput{}, nil).
+		AnyTimes()
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	authenticator := webauthn.NewSoftwareAuthenticator(webauthn.NewSoftwareAuthenticatorOptions{
+		RPID:          "localhost",
+		Origin:        "http://localhost:1323",
+		SignCountStep: 1,
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1})
+
+	challenges := map[string]repository.CreateWebAuthnChallengeInput{}
+	var stored *repository.WebAuthnCredential
+
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+	m.
+		EXPECT().
+		GetUserById(gomock.Any(), gomock.Any()).
+		Return(repository.GetUserByIdOutput{UserId: 1, PhoneNumber: "+628123456789", FullName: "John Doe"}, nil).
+		AnyTimes()
+	m.
+		EXPECT().
+		GetWebAuthnCredentials(gomock.Any(), gomock.Any()).
+		Return(repository.GetWebAuthnCredentialsOutput{}, nil).
+		AnyTimes()
+	m.
+		EXPECT().
+		CreateWebAuthnChallenge(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.CreateWebAuthnChallengeInput) (repository.CreateWebAuthnChallengeOutput, error) {
+			challenges[input.Challenge] = input
+			return repository.CreateWebAuthnChallengeOutput{Challenge: input.Challenge}, nil
+		}).
+		AnyTimes()
+	m.
+		EXPECT().
+		ConsumeWebAuthnChallenge(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.ConsumeWebAuthnChallengeInput) (repository.ConsumeWebAuthnChallengeOutput, error) {
+			challenge, ok := challenges[input.Challenge]
+			if !ok {
+				return repository.ConsumeWebAuthnChallengeOutput{}, sql.ErrNoRows
+			}
+			delete(challenges, input.Challenge)
+			return repository.ConsumeWebAuthnChallengeOutput{
+				UserId:    challenge.UserId,
+				Ceremony:  challenge.Ceremony,
+				ExpiresAt: challenge.ExpiresAt,
+			}, nil
+		}).
+		AnyTimes()
+	m.
+		EXPECT().
+		CreateWebAuthnCredential(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.CreateWebAuthnCredentialInput) (repository.CreateWebAuthnCredentialOutput, error) {
+			stored = &repository.WebAuthnCredential{
+				Id:           1,
+				UserId:       input.UserId,
+				CredentialId: input.CredentialId,
+				PublicKey:    input.PublicKey,
+				SignCount:    input.SignCount,
+				Name:         input.Name,
+				CreatedAt:    time.Now(),
+			}
+			return repository.CreateWebAuthnCredentialOutput{Id: 1, CreatedAt: stored.CreatedAt}, nil
+		})
+	m.
+		EXPECT().
+		GetWebAuthnCredential(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.GetWebAuthnCredentialInput) (repository.GetWebAuthnCredentialOutput, error) {
+			if stored == nil || string(stored.CredentialId) != string(input.CredentialId) {
+				return repository.GetWebAuthnCredentialOutput{}, sql.ErrNoRows
+			}
+			return repository.GetWebAuthnCredentialOutput{Credential: *stored}, nil
+		}).
+		AnyTimes()
+	m.
+		EXPECT().
+		UpdateWebAuthnSignCount(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.UpdateWebAuthnSignCountInput) (repository.UpdateWebAuthnSignCountOutput, error) {
+			if stored.SignCount != input.PreviousSignCount {
+				return repository.UpdateWebAuthnSignCountOutput{}, nil
+			}
+			stored.SignCount = input.SignCount
+			return repository.UpdateWebAuthnSignCountOutput{Accepted: true}, nil
+		}).
+		AnyTimes()
+	m.
+		EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Return(repository.CreateSessionOutput{Id: 1}, nil).
+		AnyTimes()
+	m.
+		EXPECT().
+		GetUserRoles(gomock.Any(), gomock.Any()).
+		Return(repository.GetUserRolesOutput{}, nil).
+		AnyTimes()
+	m.
+		EXPECT().
+		SuccessLoginCount(gomock.Any(), gomock.Any()).
+		Return(repository.SuccessLoginCountOutput{UserId: 1}, nil).
+		AnyTimes()
+
+	e := echo.New()
+	server := NewServer(NewServerOptions{
+		Repository: m,
+		Helper:     h,
+		WebAuthn:   newTestWebAuthn(),
+		Echo:       e,
+	})
+	generated.RegisterHandlers(e, server)
+
+	call := func(handler func(echo.Context) error, payload interface{}, auth bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if auth {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		return rec
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+
+	// Registration
+	rec := call(server.BeginWebAuthnRegistration, nil, true)
+	var creation generated.WebAuthnRegistrationOptions
+	json.Unmarshal(rec.Body.Bytes(), &creation)
+	userHandle, _ := base64.RawURLEncoding.DecodeString(creation.User.Id)
+	attestation, err := authenticator.CreateCredential(creation.Challenge, userHandle)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	registration := generated.WebAuthnRegistrationPayload{
+		Id:                encode(attestation.CredentialID),
+		ClientDataJSON:    encode(attestation.ClientDataJSON),
+		AttestationObject: encode(attestation.AttestationObject),
+	}
+	rec = call(server.FinishWebAuthnRegistration, registration, true)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	if stored == nil || stored.Name != defaultPasskeyName {
+		t.Fatalf("Expected passkey to be stored with default name, got %v", stored)
+	}
+
+	// The challenge is single use
+	rec = call(server.FinishWebAuthnRegistration, registration, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected %d on reused challenge, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	login := func() generated.WebAuthnLoginPayload {
+		rec := call(server.BeginWebAuthnLogin, generated.WebAuthnLoginOptionsPayload{}, false)
+		var request generated.WebAuthnLoginOptions
+		json.Unmarshal(rec.Body.Bytes(), &request)
+		assertion, err := authenticator.GetAssertion(request.Challenge, attestation.CredentialID)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		return generated.WebAuthnLoginPayload{
+			Id:                encode(assertion.CredentialID),
+			ClientDataJSON:    encode(assertion.ClientDataJSON),
+			AuthenticatorData: encode(assertion.AuthenticatorData),
+			Signature:         encode(assertion.Signature),
+		}
+	}
+
+	// Login
+	assertion := login()
+	rec = call(server.FinishWebAuthnLogin, assertion, false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if stored.SignCount != 1 {
+		t.Errorf("Expected sign count 1, got %d", stored.SignCount)
+	}
+
+	// A replayed assertion is rejected
+	rec = call(server.FinishWebAuthnLogin, assertion, false)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected %d on replayed assertion, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	// A counter going backwards means the authenticator was cloned
+	stored.SignCount = 10
+	rec = call(server.FinishWebAuthnLogin, login(), false)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected %d on cloned authenticator, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	// A login challenge can't finish a registration
+	rec = call(server.BeginWebAuthnLogin, generated.WebAuthnLoginOptionsPayload{}, false)
+	var request generated.WebAuthnLoginOptions
+	json.Unmarshal(rec.Body.Bytes(), &request)
+	attestation, _ = authenticator.CreateCredential(request.Challenge, userHandle)
+	rec = call(server.FinishWebAuthnRegistration, generated.WebAuthnRegistrationPayload{
+		Id:                encode(attestation.CredentialID),
+		ClientDataJSON:    encode(attestation.ClientDataJSON),
+		AttestationObject: encode(attestation.AttestationObject),
+	}, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected %d on login challenge, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
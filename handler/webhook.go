@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/asrul10/UserService/events"
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultDeliveriesLimit = 20
+	maxDeliveriesLimit     = 100
+	// Random bytes of a generated webhook secret
+	webhookSecretLength = 32
+)
+
+var webhookEventTypes = map[string]bool{
+	events.TypeUserRegistered: true,
+	events.TypeUserUpdated:    true,
+	events.TypeUserLoggedIn:   true,
+}
+
+var webhookDeliveryStatuses = map[string]bool{
+	repository.WebhookDeliveryPending:   true,
+	repository.WebhookDeliveryDelivered: true,
+	repository.WebhookDeliveryDead:      true,
+}
+
+// (GET /api/v1/webhooks)
+func (s *Server) ListWebhookSubscriptions(ctx echo.Context) error {
+	if _, err := s.authenticate(ctx); err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	resp, err := s.Repository.GetWebhookSubscriptions(ctx.Request().Context(), repository.GetWebhookSubscriptionsInput{})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to list webhook subscriptions", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to list webhook subscriptions",
+		})
+	}
+
+	subscriptions := make([]generated.WebhookSubscription, 0, len(resp.Subscriptions))
+	for _, subscription := range resp.Subscriptions {
+		subscriptions = append(subscriptions, generated.WebhookSubscription{
+			Id:         subscription.Id,
+			Url:        subscription.URL,
+			EventTypes: subscription.EventTypes,
+			CreatedAt:  subscription.CreatedAt,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.WebhookSubscriptionsResponse{
+		Subscriptions: subscriptions,
+	})
+}
+
+// (POST /api/v1/webhooks)
+func (s *Server) CreateWebhookSubscription(ctx echo.Context) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	payload := new(generated.CreateWebhookSubscriptionJSONRequestBody)
+	if err := ctx.Bind(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid request body",
+		})
+	}
+
+	// Validate request body
+	if err := ctx.Validate(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+	for _, eventType := range payload.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+				Message: "Unknown event type " + eventType,
+			})
+		}
+	}
+
+	var secret string
+	if payload.Secret != nil {
+		secret = *payload.Secret
+	} else {
+		b := make([]byte, webhookSecretLength)
+		if _, err := rand.Read(b); err != nil {
+			s.Logger.ErrorContext(ctx.Request().Context(), "Failed to generate secret", "error", err)
+			return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+				Message: "Failed to generate secret",
+			})
+		}
+		secret = hex.EncodeToString(b)
+	}
+	encryptedSecret, err := s.SecretCipher.Encrypt(secret)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to encrypt secret", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to encrypt secret",
+		})
+	}
+
+	resp, err := s.Repository.CreateWebhookSubscription(ctx.Request().Context(), repository.CreateWebhookSubscriptionInput{
+		URL:             payload.Url,
+		EventTypes:      payload.EventTypes,
+		EncryptedSecret: encryptedSecret,
+		Audit:           auditEntry(ctx, claims.UserId, repository.AuditActionWebhookCreate),
+	})
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to create webhook subscription", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to create webhook subscription",
+		})
+	}
+
+	return ctx.JSON(http.StatusCreated, generated.CreateWebhookSubscriptionResponse{
+		Id:         resp.Subscription.Id,
+		Url:        resp.Subscription.URL,
+		EventTypes: resp.Subscription.EventTypes,
+		Secret:     secret,
+		CreatedAt:  resp.Subscription.CreatedAt,
+	})
+}
+
+// (DELETE /api/v1/webhooks/{id})
+func (s *Server) DeleteWebhookSubscription(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	_, err = s.Repository.DeleteWebhookSubscription(ctx.Request().Context(), repository.DeleteWebhookSubscriptionInput{
+		Id:    id,
+		Audit: auditEntry(ctx, claims.UserId, repository.AuditActionWebhookDelete),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "Webhook subscription not found",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to delete webhook subscription", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to delete webhook subscription",
+		})
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// (GET /api/v1/webhooks/{id}/deliveries)
+func (s *Server) ListWebhookDeliveries(ctx echo.Context, id int, params generated.ListWebhookDeliveriesParams) error {
+	if _, err := s.authenticate(ctx); err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	input := repository.GetWebhookDeliveriesInput{
+		SubscriptionId: id,
+		Limit:          defaultDeliveriesLimit,
+	}
+	if params.Limit != nil {
+		input.Limit = *params.Limit
+	}
+	if input.Limit < 1 || input.Limit > maxDeliveriesLimit {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "limit must be between 1 and 100",
+		})
+	}
+	if params.Before != nil {
+		input.BeforeId = *params.Before
+	}
+	if input.BeforeId < 0 {
+		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+			Message: "Invalid cursor",
+		})
+	}
+	if params.Status != nil {
+		if !webhookDeliveryStatuses[*params.Status] {
+			return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
+				Message: "Invalid status",
+			})
+		}
+		input.Status = *params.Status
+	}
+
+	// One more delivery than asked tells whether there is a next page
+	limit := input.Limit
+	input.Limit++
+	resp, err := s.Repository.GetWebhookDeliveries(ctx.Request().Context(), input)
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to list webhook deliveries", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to list webhook deliveries",
+		})
+	}
+
+	var nextCursor *int
+	if len(resp.Deliveries) > limit {
+		resp.Deliveries = resp.Deliveries[:limit]
+		nextCursor = &resp.Deliveries[limit-1].Id
+	}
+
+	deliveries := make([]generated.WebhookDelivery, 0, len(resp.Deliveries))
+	for _, delivery := range resp.Deliveries {
+		deliveries = append(deliveries, generated.WebhookDelivery{
+			Id:             delivery.Id,
+			SubscriptionId: delivery.SubscriptionId,
+			EventId:        delivery.EventId,
+			EventType:      delivery.EventType,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      delivery.LastError,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generated.WebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		NextCursor: nextCursor,
+	})
+}
+
+// (POST /api/v1/webhooks/deliveries/{id}/replay)
+func (s *Server) ReplayWebhookDelivery(ctx echo.Context, id int) error {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusForbidden, generated.ErrorResponse{
+			Message: "Unauthorized",
+		})
+	}
+
+	resp, err := s.Repository.ReplayWebhookDelivery(ctx.Request().Context(), repository.ReplayWebhookDeliveryInput{
+		Id:    id,
+		Audit: auditEntry(ctx, claims.UserId, repository.AuditActionWebhookReplay),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
+			Message: "Webhook delivery not found",
+		})
+	}
+	if err != nil {
+		s.Logger.ErrorContext(ctx.Request().Context(), "Failed to replay webhook delivery", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
+			Message: "Failed to replay webhook delivery",
+		})
+	}
+	if !resp.Replayed {
+		return ctx.JSON(http.StatusConflict, errorResponse(ErrCodeWebhookDeliveryPending, "Webhook delivery is still pending"))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asrul10/UserService/generated"
+	"github.com/asrul10/UserService/helper"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	c := newTestCipher()
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, Permissions: []string{"webhooks:write"}})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName       string
+		body           string
+		mockFunc       func()
+		expectedCode   int
+		expectedSecret string
+	}{
+		{
+			caseName: "Positive case",
+			body:     `{"url":"https://partner.example.com/hooks","eventTypes":["user.registered"],"secret":"0123456789abcdef"}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, input repository.CreateWebhookSubscriptionInput) (repository.CreateWebhookSubscriptionOutput, error) {
+						if secret, err := c.Decrypt(input.EncryptedSecret); err != nil || secret != "0123456789abcdef" {
+							t.Errorf("Expected the encrypted secret, got %s", input.EncryptedSecret)
+						}
+						if input.Audit.ActorId != 1 || input.Audit.Action != repository.AuditActionWebhookCreate {
+							t.Errorf("Expected audit entry of the actor, got %v", input.Audit)
+						}
+						return repository.CreateWebhookSubscriptionOutput{
+							Subscription: repository.WebhookSubscription{Id: 1, URL: input.URL, EventTypes: input.EventTypes},
+						}, nil
+					})
+			},
+			expectedCode:   http.StatusCreated,
+			expectedSecret: "0123456789abcdef",
+		},
+		{
+			caseName: "Generated secret",
+			body:     `{"url":"https://partner.example.com/hooks","eventTypes":["user.updated","user.logged_in"]}`,
+			mockFunc: func() {
+				m.
+					EXPECT().
+					CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+					Return(repository.CreateWebhookSubscriptionOutput{
+						Subscription: repository.WebhookSubscription{Id: 2},
+					}, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			caseName:     "Unknown event type",
+			body:         `{"url":"https://partner.example.com/hooks","eventTypes":["user.deleted"]}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName:     "Not an http URL",
+			body:         `{"url":"ftp://partner.example.com/hooks","eventTypes":["user.registered"]}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caseName:     "Short secret",
+			body:         `{"url":"https://partner.example.com/hooks","eventTypes":["user.registered"],"secret":"short"}`,
+			mockFunc:     func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository:   m,
+				Helper:       h,
+				SecretCipher: c,
+				Echo:         e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(test.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			test.mockFunc()
+
+			e.ServeHTTP(rec, req)
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if rec.Code != http.StatusCreated {
+				return
+			}
+
+			var resp generated.CreateWebhookSubscriptionResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if test.expectedSecret != "" && resp.Secret != test.expectedSecret {
+				t.Errorf("Expected secret %s, got %s", test.expectedSecret, resp.Secret)
+			}
+			if len(resp.Secret) < 16 {
+				t.Errorf("Expected a secret, got %q", resp.Secret)
+			}
+		})
+	}
+}
+
+func TestReplayWebhookDelivery(t *testing.T) {
+	// Mocking the repository
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	h := helper.NewHelper(helper.NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	token := ""
+	h.GenerateAccessToken(context.Background(), &token, helper.TokenClaims{UserId: 1, Permissions: []string{"webhooks:write"}})
+
+	// Tokens are issued with version 0, same as the stored one
+	m.
+		EXPECT().
+		GetTokenVersion(gomock.Any(), gomock.Any()).
+		Return(repository.GetTokenVersionOutput{TokenVersion: 0}, nil).
+		AnyTimes()
+
+	// Test cases
+	tests := []struct {
+		caseName     string
+		mockFunc     func()
+		expectedCode int
+	}{
+		{
+			caseName: "Positive case",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					ReplayWebhookDelivery(gomock.Any(), repository.ReplayWebhookDeliveryInput{
+						Id:    5,
+						Audit: repository.AuditEntry{ActorId: 1, Action: repository.AuditActionWebhookReplay, IpAddress: "192.0.2.1"},
+					}).
+					Return(repository.ReplayWebhookDeliveryOutput{Replayed: true}, nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			caseName: "Still pending",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					ReplayWebhookDelivery(gomock.Any(), gomock.Any()).
+					Return(repository.ReplayWebhookDeliveryOutput{Replayed: false}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			caseName: "Delivery not found",
+			mockFunc: func() {
+				m.
+					EXPECT().
+					ReplayWebhookDelivery(gomock.Any(), gomock.Any()).
+					Return(repository.ReplayWebhookDeliveryOutput{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			// Creating the server
+			e := echo.New()
+			server := NewServer(NewServerOptions{
+				Repository: m,
+				Helper:     h,
+				Echo:       e,
+			})
+			generated.RegisterHandlers(e, server)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/deliveries/5/replay", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			test.mockFunc()
+
+			e.ServeHTTP(rec, req)
+			if rec.Code != test.expectedCode {
+				t.Errorf("Expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
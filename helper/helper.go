@@ -1,23 +1,92 @@
 package helper
 
-import "github.com/labstack/echo/v4"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/asrul10/UserService/metrics"
+	"github.com/asrul10/UserService/tracing"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
 
 type Helper struct {
 	JwtPrivateKeyPath string
 	JwtPublicKeyPath  string
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+	MfaTokenTTL       time.Duration
+	Logger            *slog.Logger
+	Metrics           metrics.MetricsInterface
+	Tracer            trace.Tracer
 	echo              *echo.Echo
 }
 
 type NewHelperOptions struct {
 	JwtPrivateKeyPath string
 	JwtPublicKeyPath  string
-	echo              *echo.Echo
+	// AccessTokenTTL defaults to AccessTokenExpireDuration
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL defaults to RefreshTokenExpireDuration
+	RefreshTokenTTL time.Duration
+	// MfaTokenTTL defaults to MfaTokenExpireDuration
+	MfaTokenTTL time.Duration
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+	// Metrics records the bcrypt latency, defaults to metrics which are
+	// not served
+	Metrics metrics.MetricsInterface
+	// Tracer defaults to the tracer of the global provider
+	Tracer trace.Tracer
+	echo   *echo.Echo
 }
 
 func NewHelper(options NewHelperOptions) *Helper {
+	if options.AccessTokenTTL <= 0 {
+		options.AccessTokenTTL = AccessTokenExpireDuration
+	}
+	if options.RefreshTokenTTL <= 0 {
+		options.RefreshTokenTTL = RefreshTokenExpireDuration
+	}
+	if options.MfaTokenTTL <= 0 {
+		options.MfaTokenTTL = MfaTokenExpireDuration
+	}
+	if options.Logger == nil {
+		options.Logger = slog.Default()
+	}
+	if options.Metrics == nil {
+		options.Metrics = metrics.NewMetrics(metrics.NewMetricsOptions{})
+	}
+	if options.Tracer == nil {
+		options.Tracer = otel.Tracer(tracing.TracerName)
+	}
 	return &Helper{
 		JwtPrivateKeyPath: options.JwtPrivateKeyPath,
 		JwtPublicKeyPath:  options.JwtPublicKeyPath,
+		AccessTokenTTL:    options.AccessTokenTTL,
+		RefreshTokenTTL:   options.RefreshTokenTTL,
+		MfaTokenTTL:       options.MfaTokenTTL,
+		Logger:            options.Logger,
+		Metrics:           options.Metrics,
+		Tracer:            options.Tracer,
 		echo:              options.echo,
 	}
 }
+
+type TokenClaims struct {
+	UserId int
+	// TokenVersion is compared with users.token_version, bumping the
+	// column revokes every token issued before.
+	TokenVersion int
+	// SessionId is the row of sessions the token belongs to, revoking the
+	// session revokes its tokens. Zero for tokens issued before sessions.
+	SessionId int
+	// RefreshTokenId identifies a refresh token of the session, a refresh
+	// token is only accepted while it is the latest of its session.
+	RefreshTokenId string
+	// Roles of the user and the permissions they grant, only access
+	// tokens carry them. Role changes apply to the tokens issued after.
+	Roles       []string
+	Permissions []string
+}
@@ -1,22 +1,41 @@
 package helper
 
 import (
+	"context"
+	"crypto/rand"
 	"crypto/rsa"
+	"encoding/base64"
 	"fmt"
-	"log"
+	"math/big"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/asrul10/UserService/metrics"
+	"github.com/asrul10/UserService/tracing"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Lifetimes of the tokens unless configured otherwise
 const (
 	AccessTokenExpireDuration  = time.Hour * 24
 	RefreshTokenExpireDuration = time.Hour * 24 * 7
+	// MfaTokenExpireDuration is how long the user has to enter the second
+	// factor after the password was accepted.
+	MfaTokenExpireDuration = time.Minute * 5
 )
 
-func (h *Helper) HashPassword(password string) (string, error) {
+const (
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+	MfaTokenType     = "mfa"
+)
+
+func (h *Helper) HashPassword(ctx context.Context, password string) (_ string, err error) {
+	_, span := h.Tracer.Start(ctx, "Helper.HashPassword")
+	defer func() { tracing.End(span, err) }()
+	defer h.observePasswordHash(metrics.PasswordOperationHash, time.Now())
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return "", err
@@ -24,7 +43,12 @@ func (h *Helper) HashPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
-func (h *Helper) ComparePassword(password string, hashedPassword string) error {
+// ComparePassword returns an error when the password doesn't match, the
+// span isn't marked failed for it
+func (h *Helper) ComparePassword(ctx context.Context, password string, hashedPassword string) error {
+	_, span := h.Tracer.Start(ctx, "Helper.ComparePassword")
+	defer span.End()
+	defer h.observePasswordHash(metrics.PasswordOperationCompare, time.Now())
 	err := bcrypt.CompareHashAndPassword(
 		[]byte(hashedPassword),
 		[]byte(password),
@@ -32,7 +56,14 @@ func (h *Helper) ComparePassword(password string, hashedPassword string) error {
 	return err
 }
 
-func (h *Helper) getPrivateKey() (*rsa.PrivateKey, error) {
+func (h *Helper) observePasswordHash(operation string, start time.Time) {
+	h.Metrics.ObservePasswordHash(operation, time.Since(start))
+}
+
+func (h *Helper) getPrivateKey(ctx context.Context) (_ *rsa.PrivateKey, err error) {
+	_, span := h.Tracer.Start(ctx, "Helper.getPrivateKey")
+	defer func() { tracing.End(span, err) }()
+
 	read, err := os.ReadFile(h.JwtPrivateKeyPath)
 	if err != nil {
 		return nil, err
@@ -45,10 +76,13 @@ func (h *Helper) getPrivateKey() (*rsa.PrivateKey, error) {
 	return priv, nil
 }
 
-func (h *Helper) getPulicKey() (*rsa.PublicKey, error) {
+func (h *Helper) getPulicKey(ctx context.Context) (_ *rsa.PublicKey, err error) {
+	ctx, span := h.Tracer.Start(ctx, "Helper.getPublicKey")
+	defer func() { tracing.End(span, err) }()
+
 	read, err := os.ReadFile(h.JwtPublicKeyPath)
 	if err != nil {
-		log.Println(err)
+		h.Logger.ErrorContext(ctx, "Failed to read the JWT public key", "error", err)
 		return nil, err
 	}
 	pub, err := jwt.ParseRSAPublicKeyFromPEM(read)
@@ -59,13 +93,32 @@ func (h *Helper) getPulicKey() (*rsa.PublicKey, error) {
 	return pub, nil
 }
 
-func (h *Helper) GenerateAccessToken(token *string, id int) error {
+func (h *Helper) CheckKeys(ctx context.Context) (err error) {
+	ctx, span := h.Tracer.Start(ctx, "Helper.CheckKeys")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err = h.getPrivateKey(ctx); err != nil {
+		return err
+	}
+	_, err = h.getPulicKey(ctx)
+	return err
+}
+
+func (h *Helper) GenerateAccessToken(ctx context.Context, token *string, claims TokenClaims) (err error) {
+	ctx, span := h.Tracer.Start(ctx, "Helper.GenerateAccessToken")
+	defer func() { tracing.End(span, err) }()
+
 	t := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
-		"sub": id,
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(AccessTokenExpireDuration).Unix(),
+		"sub":   claims.UserId,
+		"ver":   claims.TokenVersion,
+		"sid":   claims.SessionId,
+		"roles": claims.Roles,
+		"scope": strings.Join(claims.Permissions, " "),
+		"typ":   AccessTokenType,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(h.AccessTokenTTL).Unix(),
 	})
-	privateKey, err := h.getPrivateKey()
+	privateKey, err := h.getPrivateKey(ctx)
 	if err != nil {
 		return err
 	}
@@ -77,12 +130,20 @@ func (h *Helper) GenerateAccessToken(token *string, id int) error {
 	return nil
 }
 
-func (h *Helper) GenerateRefreshToken(refreshToken *string, id int) error {
+func (h *Helper) GenerateRefreshToken(ctx context.Context, refreshToken *string, claims TokenClaims) (err error) {
+	ctx, span := h.Tracer.Start(ctx, "Helper.GenerateRefreshToken")
+	defer func() { tracing.End(span, err) }()
+
 	t := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": claims.UserId,
+		"ver": claims.TokenVersion,
+		"sid": claims.SessionId,
+		"jti": claims.RefreshTokenId,
+		"typ": RefreshTokenType,
 		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(RefreshTokenExpireDuration).Unix(),
+		"exp": time.Now().Add(h.RefreshTokenTTL).Unix(),
 	})
-	privateKey, err := h.getPrivateKey()
+	privateKey, err := h.getPrivateKey(ctx)
 	if err != nil {
 		return err
 	}
@@ -94,10 +155,52 @@ func (h *Helper) GenerateRefreshToken(refreshToken *string, id int) error {
 	return nil
 }
 
-func (h *Helper) VerifyToken(tokenString string) (string, error) {
-	pubKey, err := h.getPulicKey()
+// GenerateMfaToken issues the token proving the password step of a login
+// with two-factor authentication. It is only accepted by VerifyMfaToken.
+func (h *Helper) GenerateMfaToken(ctx context.Context, token *string, claims TokenClaims) (err error) {
+	ctx, span := h.Tracer.Start(ctx, "Helper.GenerateMfaToken")
+	defer func() { tracing.End(span, err) }()
+
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": claims.UserId,
+		"ver": claims.TokenVersion,
+		"typ": MfaTokenType,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(h.MfaTokenTTL).Unix(),
+	})
+	privateKey, err := h.getPrivateKey(ctx)
 	if err != nil {
-		return "", err
+		return err
+	}
+	tokenString, err := t.SignedString(privateKey)
+	if err != nil {
+		return err
+	}
+	*token = tokenString
+	return nil
+}
+
+// VerifyToken only accepts access tokens, refresh and mfa tokens are
+// rejected.
+func (h *Helper) VerifyToken(ctx context.Context, tokenString string) (TokenClaims, error) {
+	return h.verifyToken(ctx, "Helper.VerifyToken", tokenString, AccessTokenType)
+}
+
+func (h *Helper) VerifyMfaToken(ctx context.Context, tokenString string) (TokenClaims, error) {
+	return h.verifyToken(ctx, "Helper.VerifyMfaToken", tokenString, MfaTokenType)
+}
+
+func (h *Helper) VerifyRefreshToken(ctx context.Context, tokenString string) (TokenClaims, error) {
+	return h.verifyToken(ctx, "Helper.VerifyRefreshToken", tokenString, RefreshTokenType)
+}
+
+func (h *Helper) verifyToken(ctx context.Context, spanName string, tokenString string, tokenType string) (_ TokenClaims, err error) {
+	ctx, span := h.Tracer.Start(ctx, spanName)
+	defer func() { tracing.End(span, err) }()
+
+	pubKey, err := h.getPulicKey(ctx)
+	if err != nil {
+		return TokenClaims{}, err
 	}
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if token.Method == jwt.SigningMethodES256 && token.Valid {
@@ -106,20 +209,102 @@ func (h *Helper) VerifyToken(tokenString string) (string, error) {
 		return pubKey, nil
 	})
 	if err != nil {
-		return "", err
+		return TokenClaims{}, err
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", err
+		return TokenClaims{}, fmt.Errorf("Invalid token")
 	}
 
-	userId, ok := (claims)["sub"]
+	// Access tokens issued before token types existed have no "typ" claim
+	typ, ok := claims["typ"]
+	if !ok {
+		typ = AccessTokenType
+	}
+	if typ != tokenType {
+		return TokenClaims{}, fmt.Errorf("Invalid token type")
+	}
 
+	userId, ok := claims["sub"].(float64)
 	if !ok {
-		return "", fmt.Errorf("Invalid token")
+		return TokenClaims{}, fmt.Errorf("Invalid token")
+	}
+	// Tokens issued before token versions existed have no "ver" claim
+	version, _ := claims["ver"].(float64)
+	// and tokens issued before sessions existed have no "sid" claim
+	sessionId, _ := claims["sid"].(float64)
+	refreshTokenId, _ := claims["jti"].(string)
+	var roles, permissions []string
+	if values, ok := claims["roles"].([]interface{}); ok {
+		for _, value := range values {
+			if role, ok := value.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+	// Permissions are space separated like OAuth scopes
+	if scope, ok := claims["scope"].(string); ok && scope != "" {
+		permissions = strings.Fields(scope)
+	}
+
+	return TokenClaims{
+		UserId:         int(userId),
+		TokenVersion:   int(version),
+		SessionId:      int(sessionId),
+		RefreshTokenId: refreshTokenId,
+		Roles:          roles,
+		Permissions:    permissions,
+	}, nil
+}
+
+func (h *Helper) GenerateOTP(ctx context.Context, length int) (string, error) {
+	_, span := h.Tracer.Start(ctx, "Helper.GenerateOTP")
+	defer span.End()
+
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
+
+// recoveryCodeAlphabet leaves out characters which are easily confused,
+// like 0 and o or 1 and l.
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateRecoveryCode returns a random code formatted as "xxxxx-xxxxx"
+func (h *Helper) GenerateRecoveryCode(ctx context.Context) (string, error) {
+	_, span := h.Tracer.Start(ctx, "Helper.GenerateRecoveryCode")
+	defer span.End()
+
+	code := make([]byte, 0, 11)
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code = append(code, recoveryCodeAlphabet[n.Int64()])
 	}
+	return string(code), nil
+}
+
+// GenerateTokenId returns a random base64url encoded id of 256 bits
+func (h *Helper) GenerateTokenId(ctx context.Context) (string, error) {
+	_, span := h.Tracer.Start(ctx, "Helper.GenerateTokenId")
+	defer span.End()
 
-	return fmt.Sprintf("%v", userId), nil
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(id), nil
 }
 
 func (h *Helper) GetToken(authorization string) string {
@@ -1,6 +1,10 @@
 package helper
 
-import "testing"
+import (
+	"context"
+	"reflect"
+	"testing"
+)
 
 func TestComparePassword(t *testing.T) {
 	type TestStruct struct {
@@ -21,22 +25,22 @@ func TestComparePassword(t *testing.T) {
 		JwtPublicKeyPath:  "../storage/key.pem.pub",
 	})
 
-	hashPassword, err := helper.HashPassword(test.Password)
+	hashPassword, err := helper.HashPassword(context.Background(), test.Password)
 	if err != nil {
 		t.Errorf("Expected nil, got %s", err.Error())
 	}
 	test.HashPassword = hashPassword
-	if err := helper.ComparePassword(test.Password, test.HashPassword); err != nil {
+	if err := helper.ComparePassword(context.Background(), test.Password, test.HashPassword); err != nil {
 		t.Errorf("Expected true, got false")
 	}
 
 	// Invalid password
-	hashPassword, err = helper.HashPassword("wrongpassword")
+	hashPassword, err = helper.HashPassword(context.Background(), "wrongpassword")
 	if err != nil {
 		t.Errorf("Expected nil, got %s", err.Error())
 	}
 	test.HashPassword = hashPassword
-	if err := helper.ComparePassword(test.Password, test.HashPassword); err == nil {
+	if err := helper.ComparePassword(context.Background(), test.Password, test.HashPassword); err == nil {
 		t.Errorf("Expected false, got true")
 	}
 }
@@ -91,12 +95,156 @@ func TestVerifyToken(t *testing.T) {
 	})
 
 	token := ""
-	helper.GenerateAccessToken(&token, 1)
-	if _, err := helper.VerifyToken(token); err != nil {
+	helper.GenerateAccessToken(context.Background(), &token, TokenClaims{UserId: 1, TokenVersion: 2})
+	claims, err := helper.VerifyToken(context.Background(), token)
+	if err != nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if claims.UserId != 1 || claims.TokenVersion != 2 {
+		t.Errorf("Expected user 1 version 2, got %v", claims)
+	}
+
+	refreshToken := ""
+	helper.GenerateRefreshToken(context.Background(), &refreshToken, TokenClaims{UserId: 1})
+	if _, err := helper.VerifyToken(context.Background(), refreshToken); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	if _, err := helper.VerifyToken(context.Background(), "invalid"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestVerifyMfaToken(t *testing.T) {
+	helper := NewHelper(NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+
+	mfaToken := ""
+	helper.GenerateMfaToken(context.Background(), &mfaToken, TokenClaims{UserId: 1, TokenVersion: 2})
+	claims, err := helper.VerifyMfaToken(context.Background(), mfaToken)
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if claims.UserId != 1 || claims.TokenVersion != 2 {
+		t.Errorf("Expected user 1 version 2, got %v", claims)
+	}
+
+	// A pending login can't be used as an access token and vice versa
+	if _, err := helper.VerifyToken(context.Background(), mfaToken); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	token := ""
+	helper.GenerateAccessToken(context.Background(), &token, TokenClaims{UserId: 1})
+	if _, err := helper.VerifyMfaToken(context.Background(), token); err == nil {
 		t.Errorf("Expected error, got nil")
 	}
+}
+
+func TestVerifyRefreshToken(t *testing.T) {
+	helper := NewHelper(NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+
+	refreshToken := ""
+	helper.GenerateRefreshToken(context.Background(), &refreshToken, TokenClaims{UserId: 1, TokenVersion: 2, SessionId: 3, RefreshTokenId: "abc"})
+	claims, err := helper.VerifyRefreshToken(context.Background(), refreshToken)
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	expected := TokenClaims{UserId: 1, TokenVersion: 2, SessionId: 3, RefreshTokenId: "abc"}
+	if !reflect.DeepEqual(claims, expected) {
+		t.Errorf("Expected %v, got %v", expected, claims)
+	}
+
+	token := ""
+	helper.GenerateAccessToken(context.Background(), &token, TokenClaims{UserId: 1, SessionId: 3})
+	if _, err := helper.VerifyRefreshToken(context.Background(), token); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	claims, _ = helper.VerifyToken(context.Background(), token)
+	if claims.SessionId != 3 {
+		t.Errorf("Expected session 3, got %d", claims.SessionId)
+	}
+}
 
-	if _, err := helper.VerifyToken("invalid"); err == nil {
+func TestVerifyTokenPermissions(t *testing.T) {
+	helper := NewHelper(NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+
+	token := ""
+	helper.GenerateAccessToken(context.Background(), &token, TokenClaims{UserId: 1, Roles: []string{"admin"}, Permissions: []string{"roles:read", "roles:write"}})
+	claims, err := helper.VerifyToken(context.Background(), token)
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if !reflect.DeepEqual(claims.Roles, []string{"admin"}) {
+		t.Errorf("Expected admin role, got %v", claims.Roles)
+	}
+	if !reflect.DeepEqual(claims.Permissions, []string{"roles:read", "roles:write"}) {
+		t.Errorf("Expected roles permissions, got %v", claims.Permissions)
+	}
+
+	// Tokens of users without roles grant nothing
+	helper.GenerateAccessToken(context.Background(), &token, TokenClaims{UserId: 1})
+	claims, _ = helper.VerifyToken(context.Background(), token)
+	if len(claims.Roles) != 0 || len(claims.Permissions) != 0 {
+		t.Errorf("Expected no permissions, got %v", claims)
+	}
+}
+
+func TestGenerateOTP(t *testing.T) {
+	helper := NewHelper(NewHelperOptions{})
+
+	code, err := helper.GenerateOTP(context.Background(), 6)
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if len(code) != 6 {
+		t.Errorf("Expected 6 digits, got %s", code)
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			t.Errorf("Expected digits only, got %s", code)
+		}
+	}
+}
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	helper := NewHelper(NewHelperOptions{})
+
+	code, err := helper.GenerateRecoveryCode(context.Background())
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Errorf("Expected xxxxx-xxxxx, got %s", code)
+	}
+
+	other, _ := helper.GenerateRecoveryCode(context.Background())
+	if other == code {
+		t.Errorf("Expected different codes, got %s twice", code)
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	helper := NewHelper(NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem.pub",
+	})
+	if err := helper.CheckKeys(context.Background()); err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+
+	helper = NewHelper(NewHelperOptions{
+		JwtPrivateKeyPath: "../storage/key.pem",
+		JwtPublicKeyPath:  "../storage/key.pem",
+	})
+	if err := helper.CheckKeys(context.Background()); err == nil {
 		t.Errorf("Expected error, got nil")
 	}
 }
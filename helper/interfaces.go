@@ -1,10 +1,20 @@
 package helper
 
+import "context"
+
 type HelperInterface interface {
-	HashPassword(password string) (string, error)
-	ComparePassword(password string, hashedPassword string) error
-	GenerateAccessToken(token *string, id int) error
-	GenerateRefreshToken(token *string, id int) error
-	VerifyToken(tokenString string) (string, error)
+	HashPassword(ctx context.Context, password string) (string, error)
+	ComparePassword(ctx context.Context, password string, hashedPassword string) error
+	GenerateAccessToken(ctx context.Context, token *string, claims TokenClaims) error
+	GenerateRefreshToken(ctx context.Context, token *string, claims TokenClaims) error
+	GenerateMfaToken(ctx context.Context, token *string, claims TokenClaims) error
+	VerifyToken(ctx context.Context, tokenString string) (TokenClaims, error)
+	VerifyMfaToken(ctx context.Context, tokenString string) (TokenClaims, error)
+	VerifyRefreshToken(ctx context.Context, tokenString string) (TokenClaims, error)
 	GetToken(authorization string) string
+	GenerateOTP(ctx context.Context, length int) (string, error)
+	GenerateRecoveryCode(ctx context.Context) (string, error)
+	GenerateTokenId(ctx context.Context) (string, error)
+	// CheckKeys returns an error unless both JWT keys can be loaded
+	CheckKeys(ctx context.Context) error
 }
@@ -5,6 +5,7 @@
 package helper
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -33,46 +34,119 @@ func (m *MockHelperInterface) EXPECT() *MockHelperInterfaceMockRecorder {
 	return m.recorder
 }
 
+// CheckKeys mocks base method.
+func (m *MockHelperInterface) CheckKeys(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckKeys", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckKeys indicates an expected call of CheckKeys.
+func (mr *MockHelperInterfaceMockRecorder) CheckKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckKeys", reflect.TypeOf((*MockHelperInterface)(nil).CheckKeys), ctx)
+}
+
 // ComparePassword mocks base method.
-func (m *MockHelperInterface) ComparePassword(password, hashedPassword string) error {
+func (m *MockHelperInterface) ComparePassword(ctx context.Context, password, hashedPassword string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ComparePassword", password, hashedPassword)
+	ret := m.ctrl.Call(m, "ComparePassword", ctx, password, hashedPassword)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ComparePassword indicates an expected call of ComparePassword.
-func (mr *MockHelperInterfaceMockRecorder) ComparePassword(password, hashedPassword interface{}) *gomock.Call {
+func (mr *MockHelperInterfaceMockRecorder) ComparePassword(ctx, password, hashedPassword interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComparePassword", reflect.TypeOf((*MockHelperInterface)(nil).ComparePassword), password, hashedPassword)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComparePassword", reflect.TypeOf((*MockHelperInterface)(nil).ComparePassword), ctx, password, hashedPassword)
 }
 
 // GenerateAccessToken mocks base method.
-func (m *MockHelperInterface) GenerateAccessToken(token *string, id int) error {
+func (m *MockHelperInterface) GenerateAccessToken(ctx context.Context, token *string, claims TokenClaims) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateAccessToken", token, id)
+	ret := m.ctrl.Call(m, "GenerateAccessToken", ctx, token, claims)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // GenerateAccessToken indicates an expected call of GenerateAccessToken.
-func (mr *MockHelperInterfaceMockRecorder) GenerateAccessToken(token, id interface{}) *gomock.Call {
+func (mr *MockHelperInterfaceMockRecorder) GenerateAccessToken(ctx, token, claims interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAccessToken", reflect.TypeOf((*MockHelperInterface)(nil).GenerateAccessToken), ctx, token, claims)
+}
+
+// GenerateMfaToken mocks base method.
+func (m *MockHelperInterface) GenerateMfaToken(ctx context.Context, token *string, claims TokenClaims) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateMfaToken", ctx, token, claims)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GenerateMfaToken indicates an expected call of GenerateMfaToken.
+func (mr *MockHelperInterfaceMockRecorder) GenerateMfaToken(ctx, token, claims interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateMfaToken", reflect.TypeOf((*MockHelperInterface)(nil).GenerateMfaToken), ctx, token, claims)
+}
+
+// GenerateOTP mocks base method.
+func (m *MockHelperInterface) GenerateOTP(ctx context.Context, length int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateOTP", ctx, length)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateOTP indicates an expected call of GenerateOTP.
+func (mr *MockHelperInterfaceMockRecorder) GenerateOTP(ctx, length interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateOTP", reflect.TypeOf((*MockHelperInterface)(nil).GenerateOTP), ctx, length)
+}
+
+// GenerateRecoveryCode mocks base method.
+func (m *MockHelperInterface) GenerateRecoveryCode(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateRecoveryCode", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateRecoveryCode indicates an expected call of GenerateRecoveryCode.
+func (mr *MockHelperInterfaceMockRecorder) GenerateRecoveryCode(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAccessToken", reflect.TypeOf((*MockHelperInterface)(nil).GenerateAccessToken), token, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRecoveryCode", reflect.TypeOf((*MockHelperInterface)(nil).GenerateRecoveryCode), ctx)
 }
 
 // GenerateRefreshToken mocks base method.
-func (m *MockHelperInterface) GenerateRefreshToken(token *string, id int) error {
+func (m *MockHelperInterface) GenerateRefreshToken(ctx context.Context, token *string, claims TokenClaims) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateRefreshToken", token, id)
+	ret := m.ctrl.Call(m, "GenerateRefreshToken", ctx, token, claims)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // GenerateRefreshToken indicates an expected call of GenerateRefreshToken.
-func (mr *MockHelperInterfaceMockRecorder) GenerateRefreshToken(token, id interface{}) *gomock.Call {
+func (mr *MockHelperInterfaceMockRecorder) GenerateRefreshToken(ctx, token, claims interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRefreshToken", reflect.TypeOf((*MockHelperInterface)(nil).GenerateRefreshToken), token, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRefreshToken", reflect.TypeOf((*MockHelperInterface)(nil).GenerateRefreshToken), ctx, token, claims)
+}
+
+// GenerateTokenId mocks base method.
+func (m *MockHelperInterface) GenerateTokenId(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateTokenId", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateTokenId indicates an expected call of GenerateTokenId.
+func (mr *MockHelperInterfaceMockRecorder) GenerateTokenId(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTokenId", reflect.TypeOf((*MockHelperInterface)(nil).GenerateTokenId), ctx)
 }
 
 // GetToken mocks base method.
@@ -90,31 +164,61 @@ func (mr *MockHelperInterfaceMockRecorder) GetToken(authorization interface{}) *
 }
 
 // HashPassword mocks base method.
-func (m *MockHelperInterface) HashPassword(password string) (string, error) {
+func (m *MockHelperInterface) HashPassword(ctx context.Context, password string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HashPassword", password)
+	ret := m.ctrl.Call(m, "HashPassword", ctx, password)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HashPassword indicates an expected call of HashPassword.
-func (mr *MockHelperInterfaceMockRecorder) HashPassword(password interface{}) *gomock.Call {
+func (mr *MockHelperInterfaceMockRecorder) HashPassword(ctx, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashPassword", reflect.TypeOf((*MockHelperInterface)(nil).HashPassword), ctx, password)
+}
+
+// VerifyMfaToken mocks base method.
+func (m *MockHelperInterface) VerifyMfaToken(ctx context.Context, tokenString string) (TokenClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMfaToken", ctx, tokenString)
+	ret0, _ := ret[0].(TokenClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMfaToken indicates an expected call of VerifyMfaToken.
+func (mr *MockHelperInterfaceMockRecorder) VerifyMfaToken(ctx, tokenString interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashPassword", reflect.TypeOf((*MockHelperInterface)(nil).HashPassword), password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMfaToken", reflect.TypeOf((*MockHelperInterface)(nil).VerifyMfaToken), ctx, tokenString)
+}
+
+// VerifyRefreshToken mocks base method.
+func (m *MockHelperInterface) VerifyRefreshToken(ctx context.Context, tokenString string) (TokenClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyRefreshToken", ctx, tokenString)
+	ret0, _ := ret[0].(TokenClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyRefreshToken indicates an expected call of VerifyRefreshToken.
+func (mr *MockHelperInterfaceMockRecorder) VerifyRefreshToken(ctx, tokenString interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyRefreshToken", reflect.TypeOf((*MockHelperInterface)(nil).VerifyRefreshToken), ctx, tokenString)
 }
 
 // VerifyToken mocks base method.
-func (m *MockHelperInterface) VerifyToken(tokenString string) (string, error) {
+func (m *MockHelperInterface) VerifyToken(ctx context.Context, tokenString string) (TokenClaims, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "VerifyToken", tokenString)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "VerifyToken", ctx, tokenString)
+	ret0, _ := ret[0].(TokenClaims)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // VerifyToken indicates an expected call of VerifyToken.
-func (mr *MockHelperInterfaceMockRecorder) VerifyToken(tokenString interface{}) *gomock.Call {
+func (mr *MockHelperInterfaceMockRecorder) VerifyToken(ctx, tokenString interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyToken", reflect.TypeOf((*MockHelperInterface)(nil).VerifyToken), tokenString)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyToken", reflect.TypeOf((*MockHelperInterface)(nil).VerifyToken), ctx, tokenString)
 }
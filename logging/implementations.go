@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const (
+	Redacted = "[redacted]"
+	// Digits of a phone number left readable, enough to tell numbers
+	// apart when supporting a user
+	visiblePhoneDigits = 3
+)
+
+// Fields whose value is never logged, compared in lower case without
+// separators
+var secretKeys = map[string]bool{
+	"password":        true,
+	"currentpassword": true,
+	"newpassword":     true,
+	"token":           true,
+	"accesstoken":     true,
+	"refreshtoken":    true,
+	"authorization":   true,
+	"cookie":          true,
+	"secret":          true,
+	"code":            true,
+	"otp":             true,
+}
+
+// Fields holding a phone number
+var phoneKeys = map[string]bool{
+	"phone":       true,
+	"phonenumber": true,
+}
+
+var (
+	// Phone numbers are logged in E.164
+	phonePattern = regexp.MustCompile(`\+[1-9][0-9]{6,14}`)
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	keyCleaner   = strings.NewReplacer("_", "", "-", "", ".", "")
+)
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, redactString(record.Message), record.PC)
+	if requestId := RequestId(ctx); requestId != "" {
+		redacted.AddAttrs(slog.String("request_id", requestId))
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, redactAttr(attr))
+	}
+	return &RedactHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	key := keyCleaner.Replace(strings.ToLower(attr.Key))
+
+	switch {
+	case attr.Value.Kind() == slog.KindGroup:
+		group := attr.Value.Group()
+		redacted := make([]any, 0, len(group))
+		for _, member := range group {
+			redacted = append(redacted, redactAttr(member))
+		}
+		return slog.Group(attr.Key, redacted...)
+	case secretKeys[key]:
+		return slog.String(attr.Key, Redacted)
+	case phoneKeys[key]:
+		return slog.String(attr.Key, MaskPhone(attr.Value.String()))
+	case attr.Value.Kind() == slog.KindString:
+		return slog.String(attr.Key, redactString(attr.Value.String()))
+	case attr.Value.Kind() == slog.KindAny:
+		// Errors often quote the values they failed on
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, redactString(err.Error()))
+		}
+	}
+	return attr
+}
+
+// redactString removes tokens and masks phone numbers in free text
+func redactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	return phonePattern.ReplaceAllStringFunc(s, MaskPhone)
+}
+
+// MaskPhone replaces the digits of a phone number but the last ones
+func MaskPhone(phone string) string {
+	digits := 0
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+
+	var masked strings.Builder
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			if digits > visiblePhoneDigits {
+				c = '*'
+			}
+			digits--
+		}
+		masked.WriteRune(c)
+	}
+	return masked.String()
+}
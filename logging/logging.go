@@ -0,0 +1,52 @@
+// This file contains the structured logger of the service. Records are
+// written as JSON, carry the id of the request they were logged in and
+// have secrets and phone numbers redacted, whichever field they are in.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+)
+
+type requestIdKey struct{}
+
+// RedactHandler redacts the records before passing them to the next
+// handler
+type RedactHandler struct {
+	next slog.Handler
+}
+
+type NewLoggerOptions struct {
+	// Output defaults to stdout
+	Output io.Writer
+	// Level defaults to slog.LevelInfo
+	Level slog.Leveler
+}
+
+func NewLogger(opts NewLoggerOptions) *slog.Logger {
+	output := opts.Output
+	if output == nil {
+		output = os.Stdout
+	}
+	return slog.New(NewRedactHandler(slog.NewJSONHandler(output, &slog.HandlerOptions{
+		Level: opts.Level,
+	})))
+}
+
+func NewRedactHandler(next slog.Handler) *RedactHandler {
+	return &RedactHandler{next: next}
+}
+
+// WithRequestId returns a context whose records carry the request id
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestId returns the request id of the context, empty outside of a
+// request
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func (m *Metrics) ObserveRequest(operation string, status int, duration time.Duration) {
+	m.requestDuration.WithLabelValues(operation, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+func (m *Metrics) IncRegistrations() {
+	m.registrations.Inc()
+}
+
+func (m *Metrics) IncLogins(method string, failureReason string) {
+	result := LoginResultSuccess
+	if failureReason != "" {
+		result = LoginResultFailure
+	}
+	m.logins.WithLabelValues(method, result, failureReason).Inc()
+}
+
+func (m *Metrics) IncTokenVerificationFailures(tokenType string, reason string) {
+	m.tokenVerificationFailures.WithLabelValues(tokenType, reason).Inc()
+}
+
+func (m *Metrics) ObservePasswordHash(operation string, duration time.Duration) {
+	m.passwordHashDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
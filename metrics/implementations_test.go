@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func TestHandler(t *testing.T) {
+	// Opening doesn't connect, the pool stats are there regardless
+	db, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	defer db.Close()
+
+	m := NewMetrics(NewMetricsOptions{Db: db})
+	m.ObserveRequest("LoginUser", http.StatusOK, time.Millisecond*20)
+	m.IncRegistrations()
+	m.IncLogins("password", "")
+	m.IncLogins("password", "invalid_password")
+	m.IncLogins("password", "invalid_password")
+	m.IncTokenVerificationFailures("access", "expired")
+	m.ObservePasswordHash(PasswordOperationHash, time.Millisecond*60)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	expected := []string{
+		`userservice_http_request_duration_seconds_count{operation="LoginUser",status="200"} 1`,
+		`userservice_registrations_total 1`,
+		`userservice_logins_total{method="password",reason="",result="success"} 1`,
+		`userservice_logins_total{method="password",reason="invalid_password",result="failure"} 2`,
+		`userservice_token_verification_failures_total{reason="expired",type="access"} 1`,
+		`userservice_password_hash_duration_seconds_bucket{operation="hash",le="0.1"} 1`,
+		`go_sql_open_connections{db_name="postgres"} 0`,
+		`go_goroutines`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("Expected %q in metrics, got\n%s", line, body)
+		}
+	}
+}
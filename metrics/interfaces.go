@@ -0,0 +1,21 @@
+// This file contains the interfaces for the metrics layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package metrics
+
+import (
+	"net/http"
+	"time"
+)
+
+type MetricsInterface interface {
+	ObserveRequest(operation string, status int, duration time.Duration)
+	IncRegistrations()
+	// IncLogins counts a login attempt, an empty failure reason counts a
+	// successful login
+	IncLogins(method string, failureReason string)
+	IncTokenVerificationFailures(tokenType string, reason string)
+	ObservePasswordHash(operation string, duration time.Duration)
+	// Handler serves the metrics to Prometheus
+	Handler() http.Handler
+}
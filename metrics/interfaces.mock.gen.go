@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: metrics/interfaces.go
+
+// Package metrics is a generated GoMock package.
+package metrics
+
+import (
+	http "net/http"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMetricsInterface is a mock of MetricsInterface interface.
+type MockMetricsInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsInterfaceMockRecorder
+}
+
+// MockMetricsInterfaceMockRecorder is the mock recorder for MockMetricsInterface.
+type MockMetricsInterfaceMockRecorder struct {
+	mock *MockMetricsInterface
+}
+
+// NewMockMetricsInterface creates a new mock instance.
+func NewMockMetricsInterface(ctrl *gomock.Controller) *MockMetricsInterface {
+	mock := &MockMetricsInterface{ctrl: ctrl}
+	mock.recorder = &MockMetricsInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetricsInterface) EXPECT() *MockMetricsInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Handler mocks base method.
+func (m *MockMetricsInterface) Handler() http.Handler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handler")
+	ret0, _ := ret[0].(http.Handler)
+	return ret0
+}
+
+// Handler indicates an expected call of Handler.
+func (mr *MockMetricsInterfaceMockRecorder) Handler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handler", reflect.TypeOf((*MockMetricsInterface)(nil).Handler))
+}
+
+// IncLogins mocks base method.
+func (m *MockMetricsInterface) IncLogins(method, failureReason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncLogins", method, failureReason)
+}
+
+// IncLogins indicates an expected call of IncLogins.
+func (mr *MockMetricsInterfaceMockRecorder) IncLogins(method, failureReason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncLogins", reflect.TypeOf((*MockMetricsInterface)(nil).IncLogins), method, failureReason)
+}
+
+// IncRegistrations mocks base method.
+func (m *MockMetricsInterface) IncRegistrations() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncRegistrations")
+}
+
+// IncRegistrations indicates an expected call of IncRegistrations.
+func (mr *MockMetricsInterfaceMockRecorder) IncRegistrations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncRegistrations", reflect.TypeOf((*MockMetricsInterface)(nil).IncRegistrations))
+}
+
+// IncTokenVerificationFailures mocks base method.
+func (m *MockMetricsInterface) IncTokenVerificationFailures(tokenType, reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncTokenVerificationFailures", tokenType, reason)
+}
+
+// IncTokenVerificationFailures indicates an expected call of IncTokenVerificationFailures.
+func (mr *MockMetricsInterfaceMockRecorder) IncTokenVerificationFailures(tokenType, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncTokenVerificationFailures", reflect.TypeOf((*MockMetricsInterface)(nil).IncTokenVerificationFailures), tokenType, reason)
+}
+
+// ObservePasswordHash mocks base method.
+func (m *MockMetricsInterface) ObservePasswordHash(operation string, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObservePasswordHash", operation, duration)
+}
+
+// ObservePasswordHash indicates an expected call of ObservePasswordHash.
+func (mr *MockMetricsInterfaceMockRecorder) ObservePasswordHash(operation, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObservePasswordHash", reflect.TypeOf((*MockMetricsInterface)(nil).ObservePasswordHash), operation, duration)
+}
+
+// ObserveRequest mocks base method.
+func (m *MockMetricsInterface) ObserveRequest(operation string, status int, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveRequest", operation, status, duration)
+}
+
+// ObserveRequest indicates an expected call of ObserveRequest.
+func (mr *MockMetricsInterfaceMockRecorder) ObserveRequest(operation, status, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveRequest", reflect.TypeOf((*MockMetricsInterface)(nil).ObserveRequest), operation, status, duration)
+}
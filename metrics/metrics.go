@@ -0,0 +1,90 @@
+// This file contains the Prometheus metrics of the service. Every Metrics
+// has its own registry, served by Handler in the Prometheus text format.
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "userservice"
+
+const (
+	LoginResultSuccess = "success"
+	LoginResultFailure = "failure"
+)
+
+const (
+	PasswordOperationHash    = "hash"
+	PasswordOperationCompare = "compare"
+)
+
+// OperationUnknown labels the requests of routes which are not part of
+// the API spec, such as unmatched paths
+const OperationUnknown = "unknown"
+
+type Metrics struct {
+	registry                  *prometheus.Registry
+	requestDuration           *prometheus.HistogramVec
+	registrations             prometheus.Counter
+	logins                    *prometheus.CounterVec
+	tokenVerificationFailures *prometheus.CounterVec
+	passwordHashDuration      *prometheus.HistogramVec
+}
+
+type NewMetricsOptions struct {
+	// Db has the stats of its connection pool exported, nil leaves them
+	// out
+	Db *sql.DB
+}
+
+func NewMetrics(opts NewMetricsOptions) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the HTTP requests by operation of the API spec and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "status"}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "Users registered, verified or not.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by method, result and failure reason.",
+		}, []string{"method", "result", "reason"}),
+		tokenVerificationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_verification_failures_total",
+			Help:      "Rejected tokens by token type and reason.",
+		}, []string{"type", "reason"}),
+		// bcrypt is slow on purpose, the buckets start around its cost
+		passwordHashDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "password_hash_duration_seconds",
+			Help:      "Duration of hashing and comparing passwords with bcrypt.",
+			Buckets:   []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		}, []string{"operation"}),
+	}
+
+	m.registry.MustRegister(
+		m.requestDuration,
+		m.registrations,
+		m.logins,
+		m.tokenVerificationFailures,
+		m.passwordHashDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if opts.Db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(opts.Db, "postgres"))
+	}
+
+	return m
+}
@@ -0,0 +1,46 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+// CapturingNotifier keeps the sent messages in memory instead of
+// delivering them. It is a test double for automated tests which need to
+// read the one-time codes sent to a phone number.
+type CapturingNotifier struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewCapturingNotifier() *CapturingNotifier {
+	return &CapturingNotifier{}
+}
+
+func (n *CapturingNotifier) Send(ctx context.Context, message Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (n *CapturingNotifier) Messages() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Message(nil), n.messages...)
+}
+
+// LastCode returns the code of the latest KindOTP message sent to the phone
+// number, or an empty string when there is none.
+func (n *CapturingNotifier) LastCode(phoneNumber string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := len(n.messages) - 1; i >= 0; i-- {
+		m := n.messages[i]
+		if m.PhoneNumber == phoneNumber && m.Kind == KindOTP {
+			return m.Code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/asrul10/UserService/logging"
+)
+
+// Send posts the message to the gateway as a JSON object with the phone
+// number, the kind and the text of the message.
+func (n *HTTPNotifier) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(gatewayMessage{
+		To:   message.PhoneNumber,
+		Kind: message.Kind,
+		Text: message.Text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("SMS gateway responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Send only logs that a message was sent, the phone number is masked. The
+// text is never logged, the code only with ShowCode.
+func (n *LogNotifier) Send(ctx context.Context, message Message) error {
+	args := []any{"to", logging.MaskPhone(message.PhoneNumber), "kind", message.Kind}
+	if n.ShowCode && message.Code != "" {
+		args = append(args, "code", message.Code)
+	}
+	n.Logger.InfoContext(ctx, "Notification sent", args...)
+	return nil
+}
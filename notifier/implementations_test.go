@@ -0,0 +1,138 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogNotifierSend(t *testing.T) {
+	tests := []struct {
+		caseName     string
+		showCode     bool
+		expectedCode bool
+	}{
+		{
+			caseName:     "Code hidden",
+			showCode:     false,
+			expectedCode: false,
+		},
+		{
+			caseName:     "Code shown in development",
+			showCode:     true,
+			expectedCode: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			var buf bytes.Buffer
+			n := NewLogNotifier(NewLogNotifierOptions{
+				Logger:   slog.New(slog.NewTextHandler(&buf, nil)),
+				ShowCode: test.showCode,
+			})
+			err := n.Send(context.Background(), Message{
+				PhoneNumber: "+628123456789",
+				Kind:        KindOTP,
+				Text:        "Your code is 123456",
+				Code:        "123456",
+			})
+			if err != nil {
+				t.Errorf("Expected nil, got %s", err.Error())
+			}
+			if !strings.Contains(buf.String(), "to=+*********789 kind=otp") {
+				t.Errorf("Expected message to be logged with a masked phone number, got %s", buf.String())
+			}
+			if strings.Contains(buf.String(), "Your code is") {
+				t.Errorf("Expected the text not to be logged, got %s", buf.String())
+			}
+			if strings.Contains(buf.String(), "code=123456") != test.expectedCode {
+				t.Errorf("Expected code logged %v, got %s", test.expectedCode, buf.String())
+			}
+		})
+	}
+}
+
+func TestHTTPNotifierSend(t *testing.T) {
+	tests := []struct {
+		caseName      string
+		status        int
+		expectedError bool
+	}{
+		{
+			caseName:      "Delivered",
+			status:        http.StatusAccepted,
+			expectedError: false,
+		},
+		{
+			caseName:      "Gateway failed",
+			status:        http.StatusBadGateway,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(test.status)
+			}))
+			defer gateway.Close()
+
+			n := NewHTTPNotifier(NewHTTPNotifierOptions{URL: gateway.URL, Token: "secret"})
+			err := n.Send(context.Background(), Message{
+				PhoneNumber: "+628123456789",
+				Kind:        KindOTP,
+				Text:        "Your code is 123456",
+				Code:        "123456",
+			})
+			if (err != nil) != test.expectedError {
+				t.Errorf("Expected error %v, got %v", test.expectedError, err)
+			}
+
+			if received == nil {
+				t.Fatalf("Expected a delivery")
+			}
+			if received.Header.Get("Authorization") != "Bearer secret" {
+				t.Errorf("Expected the token, got %v", received.Header)
+			}
+			var message gatewayMessage
+			if err := json.Unmarshal(body, &message); err != nil ||
+				message != (gatewayMessage{To: "+628123456789", Kind: KindOTP, Text: "Your code is 123456"}) {
+				t.Errorf("Expected the message as body, got %s", body)
+			}
+		})
+	}
+}
+
+func TestCapturingNotifier(t *testing.T) {
+	n := NewCapturingNotifier()
+
+	if code := n.LastCode("+628123456789"); code != "" {
+		t.Errorf("Expected no code, got %s", code)
+	}
+
+	n.Send(context.Background(), Message{PhoneNumber: "+628123456789", Kind: KindOTP, Code: "111111"})
+	n.Send(context.Background(), Message{PhoneNumber: "+628123456789", Kind: KindOTP, Code: "222222"})
+	n.Send(context.Background(), Message{PhoneNumber: "+628123456789", Kind: KindSecurityNotice, Text: "notice"})
+	n.Send(context.Background(), Message{PhoneNumber: "+628123456780", Kind: KindOTP, Code: "333333"})
+
+	if code := n.LastCode("+628123456789"); code != "222222" {
+		t.Errorf("Expected 222222, got %s", code)
+	}
+	if code := n.LastCode("+628123456780"); code != "333333" {
+		t.Errorf("Expected 333333, got %s", code)
+	}
+	if len(n.Messages()) != 4 {
+		t.Errorf("Expected 4 messages, got %d", len(n.Messages()))
+	}
+}
@@ -0,0 +1,10 @@
+// This file contains the interfaces for the notifier layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package notifier
+
+import "context"
+
+type NotifierInterface interface {
+	Send(ctx context.Context, message Message) error
+}
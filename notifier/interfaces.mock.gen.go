@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: notifier/interfaces.go
+
+// Package notifier is a generated GoMock package.
+package notifier
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockNotifierInterface is a mock of NotifierInterface interface.
+type MockNotifierInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierInterfaceMockRecorder
+}
+
+// MockNotifierInterfaceMockRecorder is the mock recorder for MockNotifierInterface.
+type MockNotifierInterfaceMockRecorder struct {
+	mock *MockNotifierInterface
+}
+
+// NewMockNotifierInterface creates a new mock instance.
+func NewMockNotifierInterface(ctrl *gomock.Controller) *MockNotifierInterface {
+	mock := &MockNotifierInterface{ctrl: ctrl}
+	mock.recorder = &MockNotifierInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifierInterface) EXPECT() *MockNotifierInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockNotifierInterface) Send(ctx context.Context, message Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockNotifierInterfaceMockRecorder) Send(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotifierInterface)(nil).Send), ctx, message)
+}
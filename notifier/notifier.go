@@ -0,0 +1,68 @@
+// This file contains the notifier used to deliver one-time codes and
+// security notices to users. Messages are sent as SMS through an HTTP
+// gateway, the log notifier is only meant for local development.
+package notifier
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const DefaultHTTPTimeout = time.Second * 10
+
+const (
+	ProviderHTTP = "http"
+	ProviderLog  = "log"
+)
+
+// HTTPNotifier posts every message to an SMS gateway
+type HTTPNotifier struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+type NewHTTPNotifierOptions struct {
+	URL string
+	// Token is sent as a bearer token when set
+	Token string
+	// Timeout of a delivery, defaults to DefaultHTTPTimeout
+	Timeout time.Duration
+}
+
+func NewHTTPNotifier(opts NewHTTPNotifierOptions) *HTTPNotifier {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	return &HTTPNotifier{
+		URL:    opts.URL,
+		Token:  opts.Token,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+type LogNotifier struct {
+	Logger   *slog.Logger
+	ShowCode bool
+}
+
+type NewLogNotifierOptions struct {
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+	// ShowCode logs the one-time codes, nothing is delivered so they can
+	// only be read from the log in development
+	ShowCode bool
+}
+
+func NewLogNotifier(opts NewLogNotifierOptions) *LogNotifier {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LogNotifier{
+		Logger:   logger,
+		ShowCode: opts.ShowCode,
+	}
+}
@@ -0,0 +1,24 @@
+// This file contains types that are used in the notifier layer.
+package notifier
+
+const (
+	KindOTP            = "otp"
+	KindSecurityNotice = "security-notice"
+)
+
+type Message struct {
+	// PhoneNumber in E.164 format
+	PhoneNumber string
+	Kind        string
+	Text        string
+	// Code is the one-time code of KindOTP messages, also part of Text. It
+	// is kept apart so test doubles don't have to parse the text.
+	Code string
+}
+
+// gatewayMessage is the body posted to the SMS gateway
+type gatewayMessage struct {
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/asrul10/UserService/repository"
+)
+
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		// A claimed batch is published to the end on shutdown, cancelling
+		// it would leave its events leased until the lease expires
+		published, err := r.Relay(context.WithoutCancel(ctx))
+		if err != nil {
+			r.Logger.ErrorContext(ctx, "Failed to relay events", "error", err)
+		}
+
+		// A full batch means more events are probably due
+		if err == nil && published == r.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay claims a batch of due events and publishes them one by one. A
+// failed event is scheduled for a retry, the rest of the batch is still
+// published.
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	resp, err := r.Repository.ClaimOutboxEvents(ctx, repository.ClaimOutboxEventsInput{
+		Lease: r.Lease,
+		Limit: r.BatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range resp.Events {
+		if err := r.Publisher.Publish(ctx, event.Event); err != nil {
+			r.Logger.WarnContext(ctx, "Failed to publish event", "event_id", event.Event.Id, "attempt", event.Attempts, "error", err)
+			if _, err := r.Repository.RetryOutboxEvent(ctx, repository.RetryOutboxEventInput{
+				Id:            event.Event.Id,
+				Error:         err.Error(),
+				NextAttemptAt: time.Now().Add(r.backoff(event.Attempts)),
+			}); err != nil {
+				r.Logger.ErrorContext(ctx, "Failed to schedule the retry of an event", "event_id", event.Event.Id, "error", err)
+			}
+			continue
+		}
+
+		// The event is published again after the lease if this fails,
+		// consumers deduplicate by id
+		if _, err := r.Repository.DeleteOutboxEvent(ctx, repository.DeleteOutboxEventInput{
+			Id: event.Event.Id,
+		}); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.MinBackoff
+	for i := 1; i < attempts && backoff < r.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.MaxBackoff {
+		backoff = r.MaxBackoff
+	}
+	return backoff
+}
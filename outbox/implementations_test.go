@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/events"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRelay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	p := events.NewMockEventPublisherInterface(ctrl)
+	r := NewRelay(NewRelayOptions{
+		Repository: m,
+		Publisher:  p,
+		BatchSize:  2,
+		MinBackoff: time.Second,
+	})
+
+	m.
+		EXPECT().
+		ClaimOutboxEvents(gomock.Any(), repository.ClaimOutboxEventsInput{Lease: DefaultLease, Limit: 2}).
+		Return(repository.ClaimOutboxEventsOutput{
+			Events: []repository.OutboxEvent{
+				{Event: events.Event{Id: 1, Type: events.TypeUserRegistered}, Attempts: 1},
+				{Event: events.Event{Id: 2, Type: events.TypeUserLoggedIn}, Attempts: 3},
+			},
+		}, nil)
+
+	// The published event is removed, the failed one is retried later
+	p.
+		EXPECT().
+		Publish(gomock.Any(), events.Event{Id: 1, Type: events.TypeUserRegistered}).
+		Return(nil)
+	m.
+		EXPECT().
+		DeleteOutboxEvent(gomock.Any(), repository.DeleteOutboxEventInput{Id: 1}).
+		Return(repository.DeleteOutboxEventOutput{Id: 1}, nil)
+	p.
+		EXPECT().
+		Publish(gomock.Any(), events.Event{Id: 2, Type: events.TypeUserLoggedIn}).
+		Return(errors.New("connection refused"))
+	m.
+		EXPECT().
+		RetryOutboxEvent(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.RetryOutboxEventInput) (repository.RetryOutboxEventOutput, error) {
+			if input.Id != 2 || input.Error != "connection refused" {
+				t.Errorf("Expected retry of event 2, got %v", input)
+			}
+			// Third attempt waits four times the minimum
+			if wait := time.Until(input.NextAttemptAt); wait < 3*time.Second || wait > 4*time.Second {
+				t.Errorf("Expected retry in 4s, got %v", wait)
+			}
+			return repository.RetryOutboxEventOutput{Id: 2}, nil
+		})
+
+	published, err := r.Relay(context.Background())
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if published != 1 {
+		t.Errorf("Expected 1 published, got %d", published)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	r := NewRelay(NewRelayOptions{MinBackoff: time.Second, MaxBackoff: time.Minute})
+
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: time.Second},
+		{attempts: 2, expected: 2 * time.Second},
+		{attempts: 6, expected: 32 * time.Second},
+		{attempts: 7, expected: time.Minute},
+		{attempts: 100, expected: time.Minute},
+	}
+	for _, test := range tests {
+		if backoff := r.backoff(test.attempts); backoff != test.expected {
+			t.Errorf("Expected %v after %d attempts, got %v", test.expected, test.attempts, backoff)
+		}
+	}
+}
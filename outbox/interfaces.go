@@ -0,0 +1,14 @@
+// This file contains the interfaces for the outbox layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package outbox
+
+import "context"
+
+type RelayInterface interface {
+	// Run relays the events until the context is done
+	Run(ctx context.Context)
+	// Relay publishes the events due once and returns how many were
+	// published
+	Relay(ctx context.Context) (int, error)
+}
@@ -0,0 +1,62 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: outbox/interfaces.go
+
+// Package outbox is a generated GoMock package.
+package outbox
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRelayInterface is a mock of RelayInterface interface.
+type MockRelayInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockRelayInterfaceMockRecorder
+}
+
+// MockRelayInterfaceMockRecorder is the mock recorder for MockRelayInterface.
+type MockRelayInterfaceMockRecorder struct {
+	mock *MockRelayInterface
+}
+
+// NewMockRelayInterface creates a new mock instance.
+func NewMockRelayInterface(ctrl *gomock.Controller) *MockRelayInterface {
+	mock := &MockRelayInterface{ctrl: ctrl}
+	mock.recorder = &MockRelayInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRelayInterface) EXPECT() *MockRelayInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Relay mocks base method.
+func (m *MockRelayInterface) Relay(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Relay", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Relay indicates an expected call of Relay.
+func (mr *MockRelayInterfaceMockRecorder) Relay(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relay", reflect.TypeOf((*MockRelayInterface)(nil).Relay), ctx)
+}
+
+// Run mocks base method.
+func (m *MockRelayInterface) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockRelayInterfaceMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockRelayInterface)(nil).Run), ctx)
+}
@@ -0,0 +1,84 @@
+// This file contains the relay of the transactional outbox. It publishes
+// the domain events the repository queued with the changes, at least once.
+package outbox
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/asrul10/UserService/events"
+	"github.com/asrul10/UserService/repository"
+)
+
+const (
+	DefaultInterval   = time.Second
+	DefaultBatchSize  = 100
+	DefaultLease      = time.Minute
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = time.Hour
+)
+
+type Relay struct {
+	Repository repository.RepositoryInterface
+	Publisher  events.EventPublisherInterface
+	Logger     *slog.Logger
+	Interval   time.Duration
+	BatchSize  int
+	Lease      time.Duration
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type NewRelayOptions struct {
+	Repository repository.RepositoryInterface
+	Publisher  events.EventPublisherInterface
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+	// Interval between two polls when no event is due, defaults to
+	// DefaultInterval
+	Interval time.Duration
+	// BatchSize is the number of events claimed at once, defaults to
+	// DefaultBatchSize
+	BatchSize int
+	// Lease is how long claimed events are hidden from other relays,
+	// defaults to DefaultLease. It must be longer than publishing a batch
+	// takes, or events are published twice.
+	Lease time.Duration
+	// A failed event is retried after MinBackoff, doubled for every
+	// further failure up to MaxBackoff. They default to DefaultMinBackoff
+	// and DefaultMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func NewRelay(opts NewRelayOptions) *Relay {
+	r := &Relay{
+		Repository: opts.Repository,
+		Publisher:  opts.Publisher,
+		Logger:     opts.Logger,
+		Interval:   opts.Interval,
+		BatchSize:  opts.BatchSize,
+		Lease:      opts.Lease,
+		MinBackoff: opts.MinBackoff,
+		MaxBackoff: opts.MaxBackoff,
+	}
+	if r.Logger == nil {
+		r.Logger = slog.Default()
+	}
+	if r.Interval <= 0 {
+		r.Interval = DefaultInterval
+	}
+	if r.BatchSize <= 0 {
+		r.BatchSize = DefaultBatchSize
+	}
+	if r.Lease <= 0 {
+		r.Lease = DefaultLease
+	}
+	if r.MinBackoff <= 0 {
+		r.MinBackoff = DefaultMinBackoff
+	}
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = DefaultMaxBackoff
+	}
+	return r
+}
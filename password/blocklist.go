@@ -0,0 +1,86 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Blocklist keeps only the first 8 bytes of the SHA-1 of every entry in a
+// sorted slice, which keeps memory small for large lists. The false positive
+// rate of a 64-bit prefix is negligible for password lists.
+type Blocklist struct {
+	hashes []uint64
+}
+
+type NewBlocklistOptions struct {
+	Path string
+}
+
+// NewBlocklist loads the blocklist file. Every line is either a plain text
+// password or a hex encoded SHA-1 hash, optionally followed by ":count" as
+// in the Have I Been Pwned downloads. An empty path returns an empty list.
+func NewBlocklist(opts NewBlocklistOptions) (*Blocklist, error) {
+	if opts.Path == "" {
+		return &Blocklist{}, nil
+	}
+	f, err := os.Open(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadBlocklist(f)
+}
+
+func LoadBlocklist(r io.Reader) (*Blocklist, error) {
+	b := &Blocklist{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		b.hashes = append(b.hashes, entryHash(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(b.hashes, func(i, j int) bool { return b.hashes[i] < b.hashes[j] })
+
+	return b, nil
+}
+
+func (b *Blocklist) IsBlocked(password string) bool {
+	if b.contains(passwordHash(password)) {
+		return true
+	}
+	// Common lists are mostly lowercase, "Password1!" should still match
+	// "password1!".
+	return b.contains(passwordHash(strings.ToLower(password)))
+}
+
+func (b *Blocklist) contains(hash uint64) bool {
+	i := sort.Search(len(b.hashes), func(i int) bool { return b.hashes[i] >= hash })
+	return i < len(b.hashes) && b.hashes[i] == hash
+}
+
+func entryHash(line string) uint64 {
+	hash, _, _ := strings.Cut(line, ":")
+	if len(hash) == sha1.Size*2 {
+		if decoded, err := hex.DecodeString(hash); err == nil {
+			return binary.BigEndian.Uint64(decoded[:8])
+		}
+	}
+	return passwordHash(line)
+}
+
+func passwordHash(password string) uint64 {
+	sum := sha1.Sum([]byte(password))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,55 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlocklist(t *testing.T) {
+	list := strings.Join([]string{
+		"# common passwords",
+		"password1!",
+		"qwerty",
+		// SHA-1 of "Summer2024!" in the Have I Been Pwned format
+		"7E8B0A3433F1210A9699D85420E363A1B162ECAC:12",
+	}, "\n")
+	blocklist, err := LoadBlocklist(strings.NewReader(list))
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+
+	tests := []struct {
+		caseName string
+		input    string
+		expected bool
+	}{
+		{
+			caseName: "Plain text entry",
+			input:    "qwerty",
+			expected: true,
+		},
+		{
+			caseName: "Case variant of entry",
+			input:    "Password1!",
+			expected: true,
+		},
+		{
+			caseName: "Hashed entry",
+			input:    "Summer2024!",
+			expected: true,
+		},
+		{
+			caseName: "Not listed",
+			input:    "correct horse battery staple",
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			if blocked := blocklist.IsBlocked(test.input); blocked != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, blocked)
+			}
+		})
+	}
+}
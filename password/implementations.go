@@ -0,0 +1,162 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	uppercaseCharacters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowercaseCharacters = "abcdefghijklmnopqrstuvwxyz"
+	numberCharacters    = "0123456789"
+	specialCharacters   = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+	// Only the trailing digits are compared, they are the part of a phone
+	// number which survives every formatting variant.
+	phoneDigitsToCompare = 8
+	// Name parts shorter than this are too common to be forbidden.
+	minNamePartLength = 3
+)
+
+func (p *Policy) Validate(input ValidateInput) []Violation {
+	var violations []Violation
+	cfg := p.Config
+
+	length := utf8.RuneCountInString(input.Password)
+	tooLong := cfg.MaxLength > 0 && length > cfg.MaxLength
+	tooManyBytes := len(input.Password) > MaxBytes
+	if cfg.MinLength > 0 && length < cfg.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("password must be at least %d characters", cfg.MinLength),
+		})
+	}
+	if tooLong {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("password must be at most %d characters", cfg.MaxLength),
+		})
+	} else if tooManyBytes {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("password must be at most %d bytes, accented and non-Latin characters take more than one", MaxBytes),
+		})
+	}
+
+	classes := []struct {
+		rule  string
+		min   int
+		chars string
+		name  string
+	}{
+		{RuleMinUppercase, cfg.MinUppercase, uppercaseCharacters, "uppercase"},
+		{RuleMinLowercase, cfg.MinLowercase, lowercaseCharacters, "lowercase"},
+		{RuleMinNumber, cfg.MinNumber, numberCharacters, "number"},
+		{RuleMinSpecialChar, cfg.MinSpecialChar, specialCharacters, "special"},
+	}
+	for _, class := range classes {
+		if class.min > 0 && countContains(input.Password, class.chars) < class.min {
+			violations = append(violations, Violation{
+				Rule:    class.rule,
+				Message: fmt.Sprintf("password must contain at least %d %s characters", class.min, class.name),
+			})
+		}
+	}
+
+	if cfg.MaxRepeatedChars > 0 && maxRepeated(input.Password) > cfg.MaxRepeatedChars {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxRepeatedChars,
+			Message: fmt.Sprintf("password must not repeat a character more than %d times in a row", cfg.MaxRepeatedChars),
+		})
+	}
+
+	if cfg.ForbidPhone && containsPhoneNumber(input.Password, input.PhoneNumber) {
+		violations = append(violations, Violation{
+			Rule:    RuleForbidPhone,
+			Message: "password must not contain the phone number",
+		})
+	}
+
+	if cfg.ForbidFullName && containsFullName(input.Password, input.FullName) {
+		violations = append(violations, Violation{
+			Rule:    RuleForbidFullName,
+			Message: "password must not contain the full name",
+		})
+	}
+
+	// A password over the maximum is rejected anyway, it isn't worth the
+	// cost of an estimate
+	if cfg.MinStrengthScore > 0 && !tooLong && !tooManyBytes {
+		estimate := p.Estimator.Estimate(EstimateInput{
+			Password:   input.Password,
+			UserInputs: []string{input.PhoneNumber, input.FullName},
+		})
+		if estimate.Score < cfg.MinStrengthScore {
+			message := fmt.Sprintf("password is too easy to guess, strength %d of minimum %d", estimate.Score, cfg.MinStrengthScore)
+			if estimate.Warning != "" {
+				message += ": " + strings.ToLower(estimate.Warning)
+			}
+			violations = append(violations, Violation{
+				Rule:    RuleMinStrengthScore,
+				Message: message,
+			})
+		}
+	}
+
+	return violations
+}
+
+func countContains(s string, chars string) int {
+	count := 0
+	for _, c := range s {
+		if strings.ContainsRune(chars, c) {
+			count++
+		}
+	}
+	return count
+}
+
+func maxRepeated(s string) int {
+	longest, current := 0, 0
+	var last rune
+	for i, c := range []rune(s) {
+		if i > 0 && c == last {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+		last = c
+	}
+	return longest
+}
+
+func containsPhoneNumber(password string, phoneNumber string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phoneNumber)
+	if len(digits) > phoneDigitsToCompare {
+		digits = digits[len(digits)-phoneDigitsToCompare:]
+	}
+	if digits == "" {
+		return false
+	}
+	return strings.Contains(password, digits)
+}
+
+func containsFullName(password string, fullName string) bool {
+	password = strings.ToLower(password)
+	for _, part := range strings.Fields(strings.ToLower(fullName)) {
+		if utf8.RuneCountInString(part) >= minNamePartLength && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
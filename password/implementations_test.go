@@ -0,0 +1,126 @@
+package password
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestValidate(t *testing.T) {
+	config := DefaultPolicyConfig()
+	config.MaxRepeatedChars = 3
+	config.ForbidPhone = true
+	config.ForbidFullName = true
+	policy := NewPolicy(NewPolicyOptions{Config: config})
+
+	tests := []struct {
+		caseName string
+		input    ValidateInput
+		expected []string
+	}{
+		{
+			caseName: "Valid password",
+			input:    ValidateInput{Password: "Test123/", PhoneNumber: "+62123456789", FullName: "John Doe"},
+			expected: nil,
+		},
+		{
+			caseName: "Too short",
+			input:    ValidateInput{Password: "Te1/"},
+			expected: []string{RuleMinLength},
+		},
+		{
+			caseName: "Missing character classes",
+			input:    ValidateInput{Password: "testtest"},
+			expected: []string{RuleMinUppercase, RuleMinNumber, RuleMinSpecialChar},
+		},
+		{
+			caseName: "Repeated characters",
+			input:    ValidateInput{Password: "Teeeest123/"},
+			expected: []string{RuleMaxRepeatedChars},
+		},
+		{
+			caseName: "Contains phone number",
+			input:    ValidateInput{Password: "Aa/23456789", PhoneNumber: "+62123456789"},
+			expected: []string{RuleForbidPhone},
+		},
+		{
+			caseName: "Multibyte characters over the bcrypt limit",
+			input:    ValidateInput{Password: "Aa1/" + strings.Repeat("éà", 20)},
+			expected: []string{RuleMaxLength},
+		},
+		{
+			caseName: "Contains name part",
+			input:    ValidateInput{Password: "Johnny12/", FullName: "John Doe"},
+			expected: []string{RuleForbidFullName},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			var rules []string
+			for _, v := range policy.Validate(test.input) {
+				rules = append(rules, v.Rule)
+			}
+			if !reflect.DeepEqual(rules, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, rules)
+			}
+		})
+	}
+}
+
+func TestValidateMinStrengthScore(t *testing.T) {
+	config := DefaultPolicyConfig()
+	config.MinStrengthScore = 3
+	policy := NewPolicy(NewPolicyOptions{Config: config})
+
+	violations := policy.Validate(ValidateInput{Password: "Password1!"})
+	if len(violations) != 1 || violations[0].Rule != RuleMinStrengthScore {
+		t.Errorf("Expected %s violation, got %v", RuleMinStrengthScore, violations)
+	}
+
+	violations = policy.Validate(ValidateInput{Password: "Correct horse battery staple 1!"})
+	if len(violations) != 0 {
+		t.Errorf("Expected no violation, got %v", violations)
+	}
+
+	// Passwords over the maximum length are not estimated
+	estimator := NewMockEstimatorInterface(gomock.NewController(t))
+	policy = NewPolicy(NewPolicyOptions{Config: config, Estimator: estimator})
+	violations = policy.Validate(ValidateInput{Password: "Aa1!" + strings.Repeat("x", config.MaxLength)})
+	if len(violations) != 1 || violations[0].Rule != RuleMaxLength {
+		t.Errorf("Expected %s violation, got %v", RuleMaxLength, violations)
+	}
+}
+
+func TestValidateMaxBytes(t *testing.T) {
+	// Without a maximum length bcrypt still limits the password
+	config := DefaultPolicyConfig()
+	config.MaxLength = 0
+	policy := NewPolicy(NewPolicyOptions{Config: config})
+
+	violations := policy.Validate(ValidateInput{Password: "Aa1!" + strings.Repeat("x", MaxBytes)})
+	if len(violations) != 1 || violations[0].Rule != RuleMaxLength {
+		t.Errorf("Expected %s violation, got %v", RuleMaxLength, violations)
+	}
+
+	violations = policy.Validate(ValidateInput{Password: "Aa1!" + strings.Repeat("x", MaxBytes-4)})
+	if len(violations) != 0 {
+		t.Errorf("Expected no violation, got %v", violations)
+	}
+}
+
+func TestLoadPolicyConfig(t *testing.T) {
+	config, err := LoadPolicyConfig("")
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if config != DefaultPolicyConfig() {
+		t.Errorf("Expected default config, got %v", config)
+	}
+
+	if _, err := LoadPolicyConfig("not-exists.json"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
@@ -0,0 +1,16 @@
+// This file contains the interfaces for the password layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package password
+
+type PolicyInterface interface {
+	Validate(input ValidateInput) []Violation
+}
+
+type BlocklistInterface interface {
+	IsBlocked(password string) bool
+}
+
+type EstimatorInterface interface {
+	Estimate(input EstimateInput) EstimateOutput
+}
@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: password/interfaces.go
+
+// Package password is a generated GoMock package.
+package password
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPolicyInterface is a mock of PolicyInterface interface.
+type MockPolicyInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPolicyInterfaceMockRecorder
+}
+
+// MockPolicyInterfaceMockRecorder is the mock recorder for MockPolicyInterface.
+type MockPolicyInterfaceMockRecorder struct {
+	mock *MockPolicyInterface
+}
+
+// NewMockPolicyInterface creates a new mock instance.
+func NewMockPolicyInterface(ctrl *gomock.Controller) *MockPolicyInterface {
+	mock := &MockPolicyInterface{ctrl: ctrl}
+	mock.recorder = &MockPolicyInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPolicyInterface) EXPECT() *MockPolicyInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Validate mocks base method.
+func (m *MockPolicyInterface) Validate(input ValidateInput) []Violation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", input)
+	ret0, _ := ret[0].([]Violation)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockPolicyInterfaceMockRecorder) Validate(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockPolicyInterface)(nil).Validate), input)
+}
+
+// MockBlocklistInterface is a mock of BlocklistInterface interface.
+type MockBlocklistInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlocklistInterfaceMockRecorder
+}
+
+// MockBlocklistInterfaceMockRecorder is the mock recorder for MockBlocklistInterface.
+type MockBlocklistInterfaceMockRecorder struct {
+	mock *MockBlocklistInterface
+}
+
+// NewMockBlocklistInterface creates a new mock instance.
+func NewMockBlocklistInterface(ctrl *gomock.Controller) *MockBlocklistInterface {
+	mock := &MockBlocklistInterface{ctrl: ctrl}
+	mock.recorder = &MockBlocklistInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlocklistInterface) EXPECT() *MockBlocklistInterfaceMockRecorder {
+	return m.recorder
+}
+
+// IsBlocked mocks base method.
+func (m *MockBlocklistInterface) IsBlocked(password string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBlocked", password)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsBlocked indicates an expected call of IsBlocked.
+func (mr *MockBlocklistInterfaceMockRecorder) IsBlocked(password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockBlocklistInterface)(nil).IsBlocked), password)
+}
+
+// MockEstimatorInterface is a mock of EstimatorInterface interface.
+type MockEstimatorInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockEstimatorInterfaceMockRecorder
+}
+
+// MockEstimatorInterfaceMockRecorder is the mock recorder for MockEstimatorInterface.
+type MockEstimatorInterfaceMockRecorder struct {
+	mock *MockEstimatorInterface
+}
+
+// NewMockEstimatorInterface creates a new mock instance.
+func NewMockEstimatorInterface(ctrl *gomock.Controller) *MockEstimatorInterface {
+	mock := &MockEstimatorInterface{ctrl: ctrl}
+	mock.recorder = &MockEstimatorInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEstimatorInterface) EXPECT() *MockEstimatorInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Estimate mocks base method.
+func (m *MockEstimatorInterface) Estimate(input EstimateInput) EstimateOutput {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Estimate", input)
+	ret0, _ := ret[0].(EstimateOutput)
+	return ret0
+}
+
+// Estimate indicates an expected call of Estimate.
+func (mr *MockEstimatorInterfaceMockRecorder) Estimate(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Estimate", reflect.TypeOf((*MockEstimatorInterface)(nil).Estimate), input)
+}
@@ -0,0 +1,80 @@
+// This file contains the password policy component.
+// The rules used to be hard-coded as validate tags in api.yml, the policy
+// makes them configurable without regenerating code.
+package password
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// MaxBytes is the longest password bcrypt hashes. It is enforced whatever
+// MaxLength is, a character may take up to 4 bytes.
+const MaxBytes = 72
+
+type PolicyConfig struct {
+	MinLength int `json:"minLength"`
+	// MaxLength in characters, 0 only leaves the MaxBytes limit
+	MaxLength        int  `json:"maxLength"`
+	MinUppercase     int  `json:"minUppercase"`
+	MinLowercase     int  `json:"minLowercase"`
+	MinNumber        int  `json:"minNumber"`
+	MinSpecialChar   int  `json:"minSpecialChar"`
+	MaxRepeatedChars int  `json:"maxRepeatedChars"`
+	ForbidPhone      bool `json:"forbidPhoneNumber"`
+	ForbidFullName   bool `json:"forbidFullName"`
+	// MinStrengthScore is the lowest estimator score (0-4) accepted,
+	// 0 disables the check.
+	MinStrengthScore int `json:"minStrengthScore"`
+}
+
+type Policy struct {
+	Config    PolicyConfig
+	Estimator EstimatorInterface
+}
+
+type NewPolicyOptions struct {
+	Config    PolicyConfig
+	Estimator EstimatorInterface
+}
+
+func NewPolicy(opts NewPolicyOptions) *Policy {
+	estimator := opts.Estimator
+	if estimator == nil {
+		estimator = NewEstimator(NewEstimatorOptions{})
+	}
+	return &Policy{
+		Config:    opts.Config,
+		Estimator: estimator,
+	}
+}
+
+// DefaultPolicyConfig mirrors the rules previously declared in api.yml.
+func DefaultPolicyConfig() PolicyConfig {
+	return PolicyConfig{
+		MinLength:      6,
+		MaxLength:      64,
+		MinUppercase:   1,
+		MinLowercase:   1,
+		MinNumber:      1,
+		MinSpecialChar: 1,
+	}
+}
+
+// LoadPolicyConfig reads a JSON policy file on top of the defaults, so a
+// file only needs to contain the rules it overrides. An empty path returns
+// the defaults.
+func LoadPolicyConfig(path string) (PolicyConfig, error) {
+	config := DefaultPolicyConfig()
+	if path == "" {
+		return config, nil
+	}
+	read, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(read, &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
@@ -0,0 +1,620 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The estimator follows the approach of zxcvbn: it finds every pattern in
+// the password that an attacker would try first (dictionary words, keyboard
+// walks, repeats, sequences and dates), then searches the cheapest way to
+// cover the password with those patterns plus brute force. The result is an
+// estimate of the number of guesses, expressed as log10.
+
+//go:embed dictionary.txt
+var embeddedDictionary string
+
+const (
+	// Guesses per character of a segment which matched no pattern.
+	bruteforceCardinality = 10
+	// Floor for the guesses of any multi character pattern.
+	minSubmatchGuesses = 50
+	// Smallest span of years assumed by an attacker guessing a date.
+	minYearSpace = 20
+	// Starting keys and average neighbours per key on a qwerty keyboard.
+	keyboardStartingPositions = 47
+	keyboardAverageDegree     = 4
+	// Only substrings up to this length are looked up in the dictionary.
+	maxDictionaryWordLength = 20
+	// Only the first characters are matched against the patterns, the
+	// rest is counted as brute force. It bounds the work of an estimate,
+	// a password this long is strong whatever it contains.
+	maxEstimateLength = 256
+)
+
+const (
+	PatternDictionary = "dictionary"
+	PatternUserInput  = "user-input"
+	PatternSpatial    = "spatial"
+	PatternRepeat     = "repeat"
+	PatternSequence   = "sequence"
+	PatternDate       = "date"
+	PatternBruteforce = "bruteforce"
+)
+
+// log10 of the guesses separating each score, same as zxcvbn.
+var scoreThresholds = []float64{3, 6, 8, 10}
+
+var l33tTable = map[rune]rune{
+	'4': 'a',
+	'@': 'a',
+	'8': 'b',
+	'(': 'c',
+	'3': 'e',
+	'6': 'g',
+	'9': 'g',
+	'1': 'i',
+	'!': 'i',
+	'|': 'l',
+	'0': 'o',
+	'$': 's',
+	'5': 's',
+	'7': 't',
+	'+': 't',
+	'2': 'z',
+}
+
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+var keyboardShifted = map[rune]rune{
+	'~': '`', '!': '1', '@': '2', '#': '3', '$': '4', '%': '5', '^': '6',
+	'&': '7', '*': '8', '(': '9', ')': '0', '_': '-', '+': '=', '{': '[',
+	'}': ']', '|': '\\', ':': ';', '"': '\'', '<': ',', '>': '.', '?': '/',
+}
+
+var dateRegexp = regexp.MustCompile(`^(\d{1,4})([ /\\._-])(\d{1,2})([ /\\._-])(\d{1,4})$`)
+
+type Estimator struct {
+	dictionary map[string]int
+	keyboard   map[rune][2]int
+}
+
+type NewEstimatorOptions struct {
+	// ExtraWords are ranked after the embedded dictionary.
+	ExtraWords []string
+}
+
+func NewEstimator(opts NewEstimatorOptions) *Estimator {
+	e := &Estimator{
+		dictionary: map[string]int{},
+		keyboard:   map[rune][2]int{},
+	}
+	scanner := bufio.NewScanner(strings.NewReader(embeddedDictionary))
+	for scanner.Scan() {
+		e.addWord(scanner.Text())
+	}
+	for _, word := range opts.ExtraWords {
+		e.addWord(word)
+	}
+	for row, keys := range keyboardRows {
+		for col, key := range keys {
+			e.keyboard[key] = [2]int{row, col}
+		}
+	}
+	return e
+}
+
+func (e *Estimator) addWord(word string) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" || strings.HasPrefix(word, "#") {
+		return
+	}
+	if _, ok := e.dictionary[word]; !ok {
+		e.dictionary[word] = len(e.dictionary) + 1
+	}
+}
+
+type match struct {
+	pattern string
+	i, j    int
+	guesses float64
+}
+
+func (e *Estimator) Estimate(input EstimateInput) EstimateOutput {
+	chars := []rune(input.Password)
+	n := len(chars)
+	// Characters past the limit are brute forced, each multiplies the
+	// guesses by the cardinality
+	extra := 0
+	if n > maxEstimateLength {
+		extra = n - maxEstimateLength
+		chars = chars[:maxEstimateLength]
+		n = maxEstimateLength
+	}
+	if n == 0 {
+		return EstimateOutput{
+			Score:    0,
+			Warning:  "Password is empty",
+			Patterns: []string{},
+		}
+	}
+
+	var matches []match
+	matches = append(matches, e.dictionaryMatches(chars, e.dictionary, PatternDictionary)...)
+	matches = append(matches, e.dictionaryMatches(chars, userInputDictionary(input.UserInputs), PatternUserInput)...)
+	matches = append(matches, e.spatialMatches(chars)...)
+	matches = append(matches, repeatMatches(chars)...)
+	matches = append(matches, sequenceMatches(chars)...)
+	matches = append(matches, dateMatches(chars)...)
+
+	// Matches are grouped by their last character, so each is looked at
+	// once below
+	ending := make([][]int, n)
+	for m := range matches {
+		ending[matches[m].j] = append(ending[matches[m].j], m)
+	}
+
+	// Minimum log10 guesses to cover the first k characters, and the match
+	// used to reach it.
+	best := make([]float64, n+1)
+	used := make([]*match, n+1)
+	for k := 1; k <= n; k++ {
+		best[k] = best[k-1] + math.Log10(bruteforceCardinality)
+		used[k] = &match{pattern: PatternBruteforce, i: k - 1, j: k - 1}
+		for _, m := range ending[k-1] {
+			g := math.Max(matches[m].guesses, minSubmatchGuesses)
+			if total := best[matches[m].i] + math.Log10(g); total < best[k] {
+				best[k] = total
+				used[k] = &matches[m]
+			}
+		}
+	}
+	best[n] += float64(extra) * math.Log10(bruteforceCardinality)
+
+	// Walk back the optimal sequence to find the patterns which were used.
+	var sequence []*match
+	for k := n; k > 0; k = used[k].i {
+		sequence = append([]*match{used[k]}, sequence...)
+	}
+
+	output := EstimateOutput{
+		GuessesLog10: math.Round(best[n]*100) / 100,
+		Patterns:     []string{},
+	}
+	for _, threshold := range scoreThresholds {
+		if best[n] >= threshold {
+			output.Score++
+		}
+	}
+	seen := map[string]bool{}
+	var longest *match
+	for _, m := range sequence {
+		if m.pattern == PatternBruteforce {
+			continue
+		}
+		if !seen[m.pattern] {
+			seen[m.pattern] = true
+			output.Patterns = append(output.Patterns, m.pattern)
+		}
+		if longest == nil || m.j-m.i > longest.j-longest.i {
+			longest = m
+		}
+	}
+	output.Warning, output.Suggestions = feedback(output.Score, longest, len(sequence) == 1)
+
+	return output
+}
+
+func (e *Estimator) dictionaryMatches(chars []rune, dictionary map[string]int, pattern string) []match {
+	var matches []match
+	lower := []rune(strings.ToLower(string(chars)))
+	unleet := make([]rune, len(lower))
+	for k, c := range lower {
+		if r, ok := l33tTable[c]; ok {
+			unleet[k] = r
+		} else {
+			unleet[k] = c
+		}
+	}
+
+	for i := 0; i < len(chars); i++ {
+		for j := i + 2; j < len(chars) && j-i < maxDictionaryWordLength; j++ {
+			word := chars[i : j+1]
+			variations := uppercaseVariations(word)
+			candidates := []struct {
+				token  string
+				factor float64
+			}{
+				{string(lower[i : j+1]), 1},
+				{reverse(string(lower[i : j+1])), 2},
+			}
+			if subs := l33tVariations(lower[i:j+1], unleet[i:j+1]); subs > 1 {
+				candidates = append(candidates, struct {
+					token  string
+					factor float64
+				}{string(unleet[i : j+1]), subs})
+			}
+			for _, c := range candidates {
+				if rank, ok := dictionary[c.token]; ok {
+					matches = append(matches, match{
+						pattern: pattern,
+						i:       i,
+						j:       j,
+						guesses: float64(rank) * variations * c.factor,
+					})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+func (e *Estimator) spatialMatches(chars []rune) []match {
+	var matches []match
+	keys := make([]rune, len(chars))
+	shifted := make([]bool, len(chars))
+	for k, c := range chars {
+		c = unicode.ToLower(c)
+		if r, ok := keyboardShifted[c]; ok {
+			c = r
+			shifted[k] = true
+		} else if unicode.IsUpper(chars[k]) {
+			shifted[k] = true
+		}
+		keys[k] = c
+	}
+
+	i := 0
+	for i < len(keys)-1 {
+		j := i
+		turns := 0
+		lastDirection := [2]int{}
+		for j+1 < len(keys) {
+			direction, ok := e.adjacent(keys[j], keys[j+1])
+			if !ok {
+				break
+			}
+			if direction != lastDirection {
+				turns++
+				lastDirection = direction
+			}
+			j++
+		}
+		if j-i >= 2 {
+			length := j - i + 1
+			guesses := keyboardStartingPositions * float64(length) * math.Pow(keyboardAverageDegree, float64(turns))
+			shiftedCount := 0
+			for k := i; k <= j; k++ {
+				if shifted[k] {
+					shiftedCount++
+				}
+			}
+			if shiftedCount > 0 {
+				guesses *= 2 * float64(shiftedCount)
+			}
+			matches = append(matches, match{pattern: PatternSpatial, i: i, j: j, guesses: guesses})
+			i = j
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+func (e *Estimator) adjacent(a rune, b rune) ([2]int, bool) {
+	pa, okA := e.keyboard[a]
+	pb, okB := e.keyboard[b]
+	if !okA || !okB {
+		return [2]int{}, false
+	}
+	direction := [2]int{pb[0] - pa[0], pb[1] - pa[1]}
+	switch direction {
+	// Rows are staggered, the key above is at the same or next column
+	// and the key below is at the same or previous column.
+	case [2]int{0, 1}, [2]int{0, -1}, [2]int{-1, 0}, [2]int{-1, 1}, [2]int{1, 0}, [2]int{1, -1}:
+		return direction, true
+	}
+	return direction, false
+}
+
+func repeatMatches(chars []rune) []match {
+	var matches []match
+	n := len(chars)
+	// end of the last repeat found for each base, a repeat starting
+	// inside it is shorter and never cheaper
+	var end [5]int
+	for i := 0; i < n; i++ {
+		for base := 1; base <= 4 && i+base*2 <= n; base++ {
+			if i < end[base] {
+				continue
+			}
+			count := 1
+			for i+base*(count+1) <= n && equalRunes(chars[i:i+base], chars[i+base*count:i+base*(count+1)]) {
+				count++
+			}
+			if count < 2 || base*count < 3 {
+				continue
+			}
+			baseGuesses := math.Pow(bruteforceCardinality, float64(base))
+			if base == 1 {
+				baseGuesses = float64(charsetSize(chars[i]))
+			}
+			matches = append(matches, match{
+				pattern: PatternRepeat,
+				i:       i,
+				j:       i + base*count - 1,
+				guesses: baseGuesses * float64(count),
+			})
+			end[base] = i + base*count
+		}
+	}
+	return matches
+}
+
+func sequenceMatches(chars []rune) []match {
+	var matches []match
+	n := len(chars)
+	for i := 0; i < n-2; i++ {
+		delta := chars[i+1] - chars[i]
+		if (delta != 1 && delta != -1) || !sameClass(chars[i], chars[i+1]) {
+			continue
+		}
+		j := i + 1
+		for j+1 < n && chars[j+1]-chars[j] == delta && sameClass(chars[j+1], chars[i]) {
+			j++
+		}
+		if j-i < 2 {
+			continue
+		}
+		base := float64(26)
+		if strings.ContainsRune("aAzZ019", chars[i]) {
+			base = 4
+		} else if unicode.IsDigit(chars[i]) {
+			base = 10
+		}
+		guesses := base * float64(j-i+1)
+		if delta < 0 {
+			guesses *= 2
+		}
+		matches = append(matches, match{pattern: PatternSequence, i: i, j: j, guesses: guesses})
+	}
+	return matches
+}
+
+func dateMatches(chars []rune) []match {
+	var matches []match
+	n := len(chars)
+	referenceYear := time.Now().Year()
+	for i := 0; i < n; i++ {
+		for j := i + 3; j < n && j-i < 10; j++ {
+			token := string(chars[i : j+1])
+			year, separator, ok := parseDate(token, referenceYear)
+			if !ok {
+				continue
+			}
+			yearSpace := math.Max(math.Abs(float64(year-referenceYear)), minYearSpace)
+			guesses := yearSpace
+			if len(token) > 4 {
+				guesses *= 365
+			}
+			if separator {
+				guesses *= 4
+			}
+			matches = append(matches, match{pattern: PatternDate, i: i, j: j, guesses: guesses})
+		}
+	}
+	return matches
+}
+
+// parseDate accepts a lone year or a day, month and year in any common
+// order, with or without separators.
+func parseDate(token string, referenceYear int) (year int, separator bool, ok bool) {
+	if len(token) == 4 && isDigits(token) {
+		year, _ = strconv.Atoi(token)
+		return year, false, year >= 1900 && year <= referenceYear+30
+	}
+
+	var parts [][3]string
+	if m := dateRegexp.FindStringSubmatch(token); m != nil && m[2] == m[4] {
+		separator = true
+		parts = append(parts, [3]string{m[1], m[3], m[5]})
+	} else if isDigits(token) && (len(token) == 6 || len(token) == 8) {
+		// ddmmyy, yymmdd, ddmmyyyy or yyyymmdd
+		yearLength := len(token) - 4
+		parts = append(parts,
+			[3]string{token[:2], token[2:4], token[4:]},
+			[3]string{token[:yearLength], token[yearLength : yearLength+2], token[yearLength+2:]},
+		)
+	}
+
+	for _, p := range parts {
+		orders := [][3]int{{0, 1, 2}, {1, 0, 2}, {2, 1, 0}}
+		for _, o := range orders {
+			day, _ := strconv.Atoi(p[o[0]])
+			month, _ := strconv.Atoi(p[o[1]])
+			yearStr := p[o[2]]
+			if len(yearStr) != 2 && len(yearStr) != 4 {
+				continue
+			}
+			y, _ := strconv.Atoi(yearStr)
+			if len(yearStr) == 2 {
+				y += 1900
+				if y < referenceYear-80 {
+					y += 100
+				}
+			}
+			if day >= 1 && day <= 31 && month >= 1 && month <= 12 && y >= 1900 && y <= referenceYear+30 {
+				return y, separator, true
+			}
+		}
+	}
+	return 0, false, false
+}
+
+func feedback(score int, longest *match, single bool) (string, []string) {
+	var suggestions []string
+	if score >= 3 {
+		return "", suggestions
+	}
+	suggestions = append(suggestions, "Add another word or two. Uncommon words are better.")
+	if longest == nil {
+		return "", append(suggestions, "Use a longer password")
+	}
+
+	warning := ""
+	switch longest.pattern {
+	case PatternDictionary:
+		if single {
+			warning = "This is a commonly used password"
+		} else {
+			warning = "Common words are easy to guess"
+		}
+		suggestions = append(suggestions, "Capitalization and predictable substitutions like '@' instead of 'a' don't help very much")
+	case PatternUserInput:
+		warning = "Avoid using your name or phone number"
+	case PatternSpatial:
+		warning = "Straight rows of keys and short keyboard patterns are easy to guess"
+		suggestions = append(suggestions, "Use a longer keyboard pattern with more turns")
+	case PatternRepeat:
+		warning = "Repeats like \"aaa\" or \"abcabc\" are easy to guess"
+		suggestions = append(suggestions, "Avoid repeated words and characters")
+	case PatternSequence:
+		warning = "Sequences like abc or 6543 are easy to guess"
+		suggestions = append(suggestions, "Avoid sequences")
+	case PatternDate:
+		warning = "Dates are often easy to guess"
+		suggestions = append(suggestions, "Avoid dates and years that are associated with you")
+	}
+	return warning, suggestions
+}
+
+func userInputDictionary(inputs []string) map[string]int {
+	dictionary := map[string]int{}
+	add := func(token string) {
+		if len([]rune(token)) >= 3 {
+			if _, ok := dictionary[token]; !ok {
+				dictionary[token] = len(dictionary) + 1
+			}
+		}
+	}
+	for _, input := range inputs {
+		input = strings.ToLower(input)
+		add(strings.Join(strings.Fields(input), ""))
+		for _, field := range strings.Fields(input) {
+			add(field)
+		}
+		digits := strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, input)
+		// Phone numbers are commonly typed partially, every tail of the
+		// number is considered.
+		if len(digits) >= 6 {
+			for k := 0; k <= len(digits)-4; k++ {
+				add(digits[k:])
+			}
+		}
+	}
+	return dictionary
+}
+
+func uppercaseVariations(word []rune) float64 {
+	upper, lower := 0, 0
+	for _, c := range word {
+		if unicode.IsUpper(c) {
+			upper++
+		} else if unicode.IsLower(c) {
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	// First letter, last letter or all letters capitalized are the most
+	// common variations.
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(word[0]) || unicode.IsUpper(word[len(word)-1]))) {
+		return 2
+	}
+	variations := 0.0
+	for k := 1; k <= upper && k <= lower; k++ {
+		variations += binomial(upper+lower, k)
+	}
+	return variations
+}
+
+func l33tVariations(lower []rune, unleet []rune) float64 {
+	substitutions := 0
+	for k := range lower {
+		if lower[k] != unleet[k] {
+			substitutions++
+		}
+	}
+	if substitutions == 0 {
+		return 1
+	}
+	return math.Pow(2, float64(substitutions))
+}
+
+func binomial(n int, k int) float64 {
+	result := 1.0
+	for d := 1; d <= k; d++ {
+		result = result * float64(n-k+d) / float64(d)
+	}
+	return result
+}
+
+func charsetSize(c rune) int {
+	switch {
+	case unicode.IsDigit(c):
+		return 10
+	case unicode.IsLower(c), unicode.IsUpper(c):
+		return 26
+	default:
+		return 33
+	}
+}
+
+func sameClass(a rune, b rune) bool {
+	return (unicode.IsDigit(a) && unicode.IsDigit(b)) ||
+		(unicode.IsLower(a) && unicode.IsLower(b)) ||
+		(unicode.IsUpper(a) && unicode.IsUpper(b))
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func equalRunes(a []rune, b []rune) bool {
+	for k := range a {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for a, b := 0, len(r)-1; a < b; a, b = a+1, b-1 {
+		r[a], r[b] = r[b], r[a]
+	}
+	return string(r)
+}
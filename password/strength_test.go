@@ -0,0 +1,103 @@
+package password
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	estimator := NewEstimator(NewEstimatorOptions{})
+
+	tests := []struct {
+		caseName        string
+		input           EstimateInput
+		expectedMax     int
+		expectedMin     int
+		expectedPattern string
+	}{
+		{
+			caseName:        "Common password",
+			input:           EstimateInput{Password: "password"},
+			expectedMin:     0,
+			expectedMax:     0,
+			expectedPattern: PatternDictionary,
+		},
+		{
+			caseName:        "Keyboard walk",
+			input:           EstimateInput{Password: "asdfghjkl"},
+			expectedMin:     0,
+			expectedMax:     1,
+			expectedPattern: PatternSpatial,
+		},
+		{
+			caseName:        "Sequence",
+			input:           EstimateInput{Password: "abcdef"},
+			expectedMin:     0,
+			expectedMax:     0,
+			expectedPattern: PatternSequence,
+		},
+		{
+			caseName:        "Date",
+			input:           EstimateInput{Password: "12/05/1990"},
+			expectedMin:     0,
+			expectedMax:     1,
+			expectedPattern: PatternDate,
+		},
+		{
+			caseName:        "User input",
+			input:           EstimateInput{Password: "+62123456789", UserInputs: []string{"+62123456789"}},
+			expectedMin:     0,
+			expectedMax:     0,
+			expectedPattern: PatternUserInput,
+		},
+		{
+			caseName:    "Character classes on a short password",
+			input:       EstimateInput{Password: "Aa1!aaaa"},
+			expectedMin: 0,
+			expectedMax: 2,
+		},
+		{
+			caseName:    "Long passphrase",
+			input:       EstimateInput{Password: "correct horse battery staple"},
+			expectedMin: 4,
+			expectedMax: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			output := estimator.Estimate(test.input)
+			if output.Score < test.expectedMin || output.Score > test.expectedMax {
+				t.Errorf("Expected score between %d and %d, got %d", test.expectedMin, test.expectedMax, output.Score)
+			}
+			if test.expectedPattern == "" {
+				return
+			}
+			found := false
+			for _, pattern := range output.Patterns {
+				if pattern == test.expectedPattern {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected pattern %s, got %v", test.expectedPattern, output.Patterns)
+			}
+		})
+	}
+}
+
+func TestEstimateLongPassword(t *testing.T) {
+	estimator := NewEstimator(NewEstimatorOptions{})
+
+	// Repeats used to be matched from every position, a long one took
+	// seconds to estimate
+	start := time.Now()
+	output := estimator.Estimate(EstimateInput{Password: strings.Repeat("ab", 10000)})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the estimate within a second, took %s", elapsed)
+	}
+	if output.Score != 4 {
+		t.Errorf("Expected score 4, got %d", output.Score)
+	}
+}
@@ -0,0 +1,43 @@
+// This file contains types that are used in the password layer.
+package password
+
+const (
+	RuleMinLength        = "min-length"
+	RuleMaxLength        = "max-length"
+	RuleMinUppercase     = "contains-uppercase"
+	RuleMinLowercase     = "contains-lowercase"
+	RuleMinNumber        = "contains-number"
+	RuleMinSpecialChar   = "contains-special-char"
+	RuleMaxRepeatedChars = "max-repeated-chars"
+	RuleForbidPhone      = "forbid-phone-number"
+	RuleForbidFullName   = "forbid-full-name"
+	RuleMinStrengthScore = "min-strength-score"
+	RuleBlocklisted      = "blocklisted"
+)
+
+type ValidateInput struct {
+	Password    string
+	PhoneNumber string
+	FullName    string
+}
+
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+type EstimateInput struct {
+	Password string
+	// UserInputs are values an attacker may know about the user, such as
+	// the full name and phone number.
+	UserInputs []string
+}
+
+type EstimateOutput struct {
+	// Score from 0 (too guessable) to 4 (very unguessable).
+	Score        int
+	GuessesLog10 float64
+	Warning      string
+	Suggestions  []string
+	Patterns     []string
+}
@@ -0,0 +1,113 @@
+package phone
+
+import (
+	"strings"
+)
+
+const (
+	// E.164 numbers are at most 15 digits including the calling code.
+	maxE164Digits = 15
+	// Calling codes are between one and three digits.
+	maxCallingCodeDigits = 3
+)
+
+// Parse accepts international ("+62 812-3456-7890", "0062812...") and
+// national ("0812 3456 7890") formats and returns the number normalized to
+// E.164.
+func (p *Parser) Parse(raw string) (Number, error) {
+	international := false
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "+") {
+		international = true
+		trimmed = trimmed[1:]
+	}
+
+	digits, ok := stripFormatting(trimmed)
+	if !ok || digits == "" {
+		return Number{}, ErrInvalidNumber
+	}
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+	if len(digits) > maxE164Digits {
+		return Number{}, ErrInvalidNumber
+	}
+
+	var number Number
+	if international {
+		number, ok = p.parseInternational(digits)
+	} else {
+		if p.defaultRegion == nil {
+			return Number{}, ErrMissingRegionCode
+		}
+		number, ok = p.parseNational(digits, p.defaultRegion)
+		// Numbers are often typed with the calling code but without the
+		// leading +, e.g. "62812...".
+		if !ok {
+			number, ok = p.parseInternational(digits)
+		}
+	}
+	if !ok {
+		return Number{}, ErrInvalidNumber
+	}
+
+	return number, nil
+}
+
+// CheckRegion returns ErrRegionNotAllowed when the region of the number is
+// not one of the allowed regions
+func (p *Parser) CheckRegion(number Number) error {
+	if len(p.allowedRegions) > 0 && !p.allowedRegions[number.Region] {
+		return ErrRegionNotAllowed
+	}
+	return nil
+}
+
+func (p *Parser) parseInternational(digits string) (Number, bool) {
+	for length := 1; length <= maxCallingCodeDigits && length < len(digits); length++ {
+		for _, region := range p.callingCodes[digits[:length]] {
+			if number, ok := p.parseNational(digits[length:], region); ok {
+				return number, true
+			}
+		}
+	}
+	return Number{}, false
+}
+
+func (p *Parser) parseNational(digits string, region *regionMetadata) (Number, bool) {
+	candidates := []string{digits}
+	// The national prefix is dropped in E.164, but it is commonly kept
+	// after the calling code too, e.g. "+62 (0)812...".
+	if region.NationalPrefix != "" && strings.HasPrefix(digits, region.NationalPrefix) {
+		candidates = append([]string{digits[len(region.NationalPrefix):]}, candidates...)
+	}
+	for _, nsn := range candidates {
+		if region.pattern.MatchString(nsn) {
+			return Number{
+				E164:           "+" + region.CallingCode + nsn,
+				Region:         region.Region,
+				CallingCode:    region.CallingCode,
+				NationalNumber: nsn,
+			}, true
+		}
+	}
+	return Number{}, false
+}
+
+// stripFormatting removes the separators people use when writing numbers
+// and reports false when anything else than digits remains.
+func stripFormatting(s string) (string, bool) {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == ' ', c == '-', c == '.', c == '(', c == ')', c == '/':
+			continue
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
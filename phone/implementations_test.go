@@ -0,0 +1,116 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	parser, err := NewParser(NewParserOptions{
+		DefaultRegion: "ID",
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+
+	tests := []struct {
+		caseName      string
+		input         string
+		expected      string
+		expectedError error
+	}{
+		{
+			caseName: "E.164",
+			input:    "+6281234567890",
+			expected: "+6281234567890",
+		},
+		{
+			caseName: "International with formatting",
+			input:    "+62 812-3456-7890",
+			expected: "+6281234567890",
+		},
+		{
+			caseName: "International with national prefix",
+			input:    "+62 (0)812 3456 7890",
+			expected: "+6281234567890",
+		},
+		{
+			caseName: "International with 00",
+			input:    "0062 812 3456 7890",
+			expected: "+6281234567890",
+		},
+		{
+			caseName: "National format",
+			input:    "0812-3456-7890",
+			expected: "+6281234567890",
+		},
+		{
+			caseName: "Calling code without plus",
+			input:    "6281234567890",
+			expected: "+6281234567890",
+		},
+		{
+			caseName: "Other region",
+			input:    "+65 9123 4567",
+			expected: "+6591234567",
+		},
+		{
+			caseName:      "Too short",
+			input:         "123",
+			expectedError: ErrInvalidNumber,
+		},
+		{
+			caseName:      "Invalid characters",
+			input:         "+62812abc",
+			expectedError: ErrInvalidNumber,
+		},
+		{
+			caseName:      "Invalid for region",
+			input:         "+65 1234 5678",
+			expectedError: ErrInvalidNumber,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			number, err := parser.Parse(test.input)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected %v, got %v", test.expectedError, err)
+			}
+			if number.E164 != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, number.E164)
+			}
+		})
+	}
+}
+
+func TestCheckRegion(t *testing.T) {
+	parser, err := NewParser(NewParserOptions{
+		DefaultRegion:  "ID",
+		AllowedRegions: []string{"ID", "MY"},
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+
+	number, err := parser.Parse("+60 12-345 6789")
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	if err := parser.CheckRegion(number); err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+
+	// Numbers of other regions are parsed, only the check refuses them
+	number, err = parser.Parse("+65 9123 4567")
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	if err := parser.CheckRegion(number); !errors.Is(err, ErrRegionNotAllowed) {
+		t.Errorf("Expected %v, got %v", ErrRegionNotAllowed, err)
+	}
+
+	if _, err := NewParser(NewParserOptions{AllowedRegions: []string{"XX"}}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
@@ -0,0 +1,11 @@
+// This file contains the interfaces for the phone layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package phone
+
+type ParserInterface interface {
+	Parse(raw string) (Number, error)
+	// CheckRegion is only applied to numbers being registered, numbers
+	// of existing users are accepted whatever their region
+	CheckRegion(number Number) error
+}
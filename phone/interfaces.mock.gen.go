@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: phone/interfaces.go
+
+// Package phone is a generated GoMock package.
+package phone
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockParserInterface is a mock of ParserInterface interface.
+type MockParserInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockParserInterfaceMockRecorder
+}
+
+// MockParserInterfaceMockRecorder is the mock recorder for MockParserInterface.
+type MockParserInterfaceMockRecorder struct {
+	mock *MockParserInterface
+}
+
+// NewMockParserInterface creates a new mock instance.
+func NewMockParserInterface(ctrl *gomock.Controller) *MockParserInterface {
+	mock := &MockParserInterface{ctrl: ctrl}
+	mock.recorder = &MockParserInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockParserInterface) EXPECT() *MockParserInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CheckRegion mocks base method.
+func (m *MockParserInterface) CheckRegion(number Number) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckRegion", number)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckRegion indicates an expected call of CheckRegion.
+func (mr *MockParserInterfaceMockRecorder) CheckRegion(number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRegion", reflect.TypeOf((*MockParserInterface)(nil).CheckRegion), number)
+}
+
+// Parse mocks base method.
+func (m *MockParserInterface) Parse(raw string) (Number, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Parse", raw)
+	ret0, _ := ret[0].(Number)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Parse indicates an expected call of Parse.
+func (mr *MockParserInterfaceMockRecorder) Parse(raw interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Parse", reflect.TypeOf((*MockParserInterface)(nil).Parse), raw)
+}
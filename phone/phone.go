@@ -0,0 +1,84 @@
+// This file contains the phone number parser.
+// Numbers are normalized to E.164 using the per country numbering metadata
+// embedded from metadata.json, so formatting variants of the same number
+// resolve to the same user.
+package phone
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//go:embed metadata.json
+var embeddedMetadata []byte
+
+type regionMetadata struct {
+	Region         string `json:"region"`
+	CallingCode    string `json:"callingCode"`
+	NationalPrefix string `json:"nationalPrefix"`
+	Pattern        string `json:"pattern"`
+	pattern        *regexp.Regexp
+}
+
+type Parser struct {
+	defaultRegion  *regionMetadata
+	allowedRegions map[string]bool
+	regions        map[string]*regionMetadata
+	callingCodes   map[string][]*regionMetadata
+}
+
+type NewParserOptions struct {
+	// DefaultRegion is used for numbers written in national format,
+	// e.g. "0812..." for "ID".
+	DefaultRegion string
+	// AllowedRegions limits the numbers of new registrations and phone
+	// number changes to these regions, see CheckRegion. Numbers of every
+	// region are still parsed, so existing users keep logging in when the
+	// list narrows. Empty allows every region in the metadata.
+	AllowedRegions []string
+}
+
+func NewParser(opts NewParserOptions) (*Parser, error) {
+	var metadata []*regionMetadata
+	if err := json.Unmarshal(embeddedMetadata, &metadata); err != nil {
+		return nil, err
+	}
+
+	p := &Parser{
+		allowedRegions: map[string]bool{},
+		regions:        map[string]*regionMetadata{},
+		callingCodes:   map[string][]*regionMetadata{},
+	}
+	for _, m := range metadata {
+		pattern, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for region %s: %w", m.Region, err)
+		}
+		m.pattern = pattern
+		p.regions[m.Region] = m
+		p.callingCodes[m.CallingCode] = append(p.callingCodes[m.CallingCode], m)
+	}
+
+	if opts.DefaultRegion != "" {
+		region, ok := p.regions[strings.ToUpper(opts.DefaultRegion)]
+		if !ok {
+			return nil, fmt.Errorf("unknown default region %s", opts.DefaultRegion)
+		}
+		p.defaultRegion = region
+	}
+	for _, r := range opts.AllowedRegions {
+		r = strings.ToUpper(strings.TrimSpace(r))
+		if r == "" {
+			continue
+		}
+		if _, ok := p.regions[r]; !ok {
+			return nil, fmt.Errorf("unknown allowed region %s", r)
+		}
+		p.allowedRegions[r] = true
+	}
+
+	return p, nil
+}
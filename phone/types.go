@@ -0,0 +1,18 @@
+// This file contains types that are used in the phone layer.
+package phone
+
+import "errors"
+
+var (
+	ErrInvalidNumber     = errors.New("invalid phone number")
+	ErrRegionNotAllowed  = errors.New("phone number region is not allowed")
+	ErrMissingRegionCode = errors.New("phone number must start with + and the country code")
+)
+
+type Number struct {
+	// E164 is the normalized form stored in users.phone_number.
+	E164           string
+	Region         string
+	CallingCode    string
+	NationalNumber string
+}
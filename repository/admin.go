@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/asrul10/UserService/audit"
+)
+
+// likeEscaper escapes the wildcards of LIKE patterns, filters match the
+// text as typed.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+const userColumns = `u.id, u.phone_number, u.full_name, u.status, u.password_reset_required,
+	COALESCE(u.success_login_count, 0), u.created_at, COALESCE(u.updated_at, u.created_at),
+	COALESCE(t.enabled, FALSE)`
+
+func scanUser(row rowScanner, user *User) error {
+	return row.Scan(
+		&user.Id,
+		&user.PhoneNumber,
+		&user.FullName,
+		&user.Status,
+		&user.PasswordResetRequired,
+		&user.SuccessLoginCount,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.MfaEnabled,
+	)
+}
+
+// ListUsers returns the users matching every filter, latest first. Pages
+// are keyed by the user id like the login events.
+func (r *Repository) ListUsers(ctx context.Context, input ListUsersInput) (output ListUsersOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	createdFrom := sql.NullTime{Valid: input.CreatedFrom != nil}
+	if input.CreatedFrom != nil {
+		createdFrom.Time = *input.CreatedFrom
+	}
+	createdTo := sql.NullTime{Valid: input.CreatedTo != nil}
+	if input.CreatedTo != nil {
+		createdTo.Time = *input.CreatedTo
+	}
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`SELECT `+userColumns+`
+		FROM users u LEFT JOIN user_totp t ON t.user_id = u.id
+		WHERE ($1 = 0 OR u.id < $1)
+		AND ($2 = '' OR u.phone_number LIKE $2 || '%')
+		AND ($3 = '' OR u.full_name ILIKE '%' || $3 || '%')
+		AND ($4 = '' OR u.status = $4)
+		AND ($5::TIMESTAMPTZ IS NULL OR u.created_at >= $5)
+		AND ($6::TIMESTAMPTZ IS NULL OR u.created_at < $6)
+		ORDER BY u.id DESC LIMIT $7`,
+		input.BeforeId,
+		likeEscaper.Replace(input.PhoneNumberPrefix),
+		likeEscaper.Replace(input.Name),
+		input.Status,
+		createdFrom,
+		createdTo,
+		input.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user User
+		if err = scanUser(rows, &user); err != nil {
+			return
+		}
+		output.Users = append(output.Users, user)
+	}
+	err = rows.Err()
+
+	return
+}
+
+func (r *Repository) GetUserDetails(ctx context.Context, input GetUserDetailsInput) (output GetUserDetailsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	row := r.Db.QueryRowContext(
+		ctx,
+		`SELECT `+userColumns+`
+		FROM users u LEFT JOIN user_totp t ON t.user_id = u.id
+		WHERE u.id = $1`,
+		input.UserId,
+	)
+	err = scanUser(row, &output.User)
+
+	return
+}
+
+// SuspendUser blocks the logins of an active user and revokes every token
+// and session. It returns sql.ErrNoRows for an unknown user.
+func (r *Repository) SuspendUser(ctx context.Context, input SuspendUserInput) (output SuspendUserOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	output.Suspended, err = r.changeUserStatus(ctx, input.UserId, UserStatusActive, UserStatusSuspended, input.Audit)
+
+	return
+}
+
+// UnsuspendUser lets a suspended user login again. It returns sql.ErrNoRows
+// for an unknown user.
+func (r *Repository) UnsuspendUser(ctx context.Context, input UnsuspendUserInput) (output UnsuspendUserOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	output.Unsuspended, err = r.changeUserStatus(ctx, input.UserId, UserStatusSuspended, UserStatusActive, input.Audit)
+
+	return
+}
+
+func (r *Repository) changeUserStatus(ctx context.Context, userId int, from string, to string, entry AuditEntry) (changed bool, err error) {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT status FROM users WHERE id = $1 FOR UPDATE",
+		userId,
+	).Scan(&status)
+	if err != nil || status != from {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE users SET status = $1, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		to,
+		userId,
+	)
+	if err != nil {
+		return
+	}
+	if to == UserStatusSuspended {
+		if _, err = revokeSessions(ctx, tx, userId); err != nil {
+			return
+		}
+	}
+
+	entry.SubjectId = userId
+	entry.Changes = audit.Diff(map[string]string{"status": from}, map[string]string{"status": to})
+	if _, err = insertAuditEntry(ctx, tx, entry); err != nil {
+		return
+	}
+	if err = insertUserUpdatedEvent(ctx, tx, userId, entry.Changes); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	changed = true
+
+	return
+}
+
+// ForcePasswordReset stops the password from being used until the user
+// changes it, and signs the user out everywhere. It returns sql.ErrNoRows
+// for an unknown user.
+func (r *Repository) ForcePasswordReset(ctx context.Context, input ForcePasswordResetInput) (output ForcePasswordResetOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var resetRequired bool
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT phone_number, password_reset_required FROM users WHERE id = $1 FOR UPDATE",
+		input.UserId,
+	).Scan(&output.PhoneNumber, &resetRequired)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE users SET password_reset_required = TRUE, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+	if _, err = revokeSessions(ctx, tx, input.UserId); err != nil {
+		return
+	}
+
+	input.Audit.SubjectId = input.UserId
+	input.Audit.Changes = audit.Diff(
+		map[string]string{"password_reset_required": strconv.FormatBool(resetRequired)},
+		map[string]string{"password_reset_required": "true"},
+	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+
+	return
+}
+
+// RevokeUserSessions signs the user out of every device. It returns
+// sql.ErrNoRows for an unknown user.
+func (r *Repository) RevokeUserSessions(ctx context.Context, input RevokeUserSessionsInput) (output RevokeUserSessionsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// Tokens issued before sessions existed are only revoked by the
+	// version
+	var userId int
+	err = tx.QueryRowContext(
+		ctx,
+		"UPDATE users SET token_version = token_version + 1 WHERE id = $1 RETURNING id",
+		input.UserId,
+	).Scan(&userId)
+	if err != nil {
+		return
+	}
+	if output.Revoked, err = revokeSessions(ctx, tx, input.UserId); err != nil {
+		return
+	}
+
+	input.Audit.SubjectId = input.UserId
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+
+	return
+}
+
+func revokeSessions(ctx context.Context, tx *sql.Tx, userId int) (int64, error) {
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL",
+		userId,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
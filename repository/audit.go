@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/asrul10/UserService/audit"
+)
+
+// auditLogLockKey is the advisory lock serializing the writers of the
+// audit log, every entry must see the hash of the one written before it.
+const auditLogLockKey = 7421001
+
+func (r *Repository) CreateAuditEntry(ctx context.Context, input AuditEntry) (output CreateAuditEntryOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	if output.Id, err = insertAuditEntry(ctx, tx, input); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+
+	return
+}
+
+// insertAuditEntry chains the entry to the latest one. The lock is held
+// until the transaction ends, so entries are chained in commit order and a
+// rolled back change leaves no entry behind.
+func insertAuditEntry(ctx context.Context, tx *sql.Tx, entry AuditEntry) (id int, err error) {
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditLogLockKey); err != nil {
+		return
+	}
+
+	prevHash := audit.GenesisHash
+	err = tx.QueryRowContext(ctx, "SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+
+	record := audit.Record{
+		ActorId:   entry.ActorId,
+		SubjectId: entry.SubjectId,
+		Action:    entry.Action,
+		Changes:   entry.Changes,
+		RequestId: entry.RequestId,
+		IpAddress: entry.IpAddress,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if record.Changes == nil {
+		record.Changes = []audit.Change{}
+	}
+	changes, err := json.Marshal(record.Changes)
+	if err != nil {
+		return
+	}
+
+	actorId := sql.NullInt64{Int64: int64(entry.ActorId), Valid: entry.ActorId != 0}
+	subjectId := sql.NullInt64{Int64: int64(entry.SubjectId), Valid: entry.SubjectId != 0}
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO audit_log (actor_id, subject_id, action, changes, request_id, ip_address, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		actorId,
+		subjectId,
+		entry.Action,
+		changes,
+		entry.RequestId,
+		entry.IpAddress,
+		record.CreatedAt,
+		prevHash,
+		audit.Hash(prevHash, record),
+	).Scan(&id)
+
+	return
+}
+
+// GetAuditRecords returns the entries in the order they were chained, for
+// verifying the chain.
+func (r *Repository) GetAuditRecords(ctx context.Context, input GetAuditRecordsInput) (output GetAuditRecordsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, batchQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`SELECT id, COALESCE(actor_id, 0), COALESCE(subject_id, 0), action, changes, request_id, ip_address, created_at, prev_hash, hash
+		FROM audit_log WHERE id > $1 ORDER BY id LIMIT $2`,
+		input.AfterId,
+		input.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record audit.Record
+		var changes []byte
+		err = rows.Scan(
+			&record.Id,
+			&record.ActorId,
+			&record.SubjectId,
+			&record.Action,
+			&changes,
+			&record.RequestId,
+			&record.IpAddress,
+			&record.CreatedAt,
+			&record.PrevHash,
+			&record.Hash,
+		)
+		if err != nil {
+			return
+		}
+		if err = json.Unmarshal(changes, &record.Changes); err != nil {
+			return
+		}
+		output.Records = append(output.Records, record)
+	}
+	err = rows.Err()
+
+	return
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// SchemaVersion is the version of database.sql the repository expects,
+// bump it together with the schema_version row of a schema change
+const SchemaVersion = 1
+
+func (r *Repository) PingDatabase(ctx context.Context, input PingDatabaseInput) (output PingDatabaseOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	start := time.Now()
+	err = r.Db.PingContext(ctx)
+	output.Latency = time.Since(start)
+	return
+}
+
+func (r *Repository) GetSchemaVersion(ctx context.Context, input GetSchemaVersionInput) (output GetSchemaVersionOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		"SELECT COALESCE(MAX(version), 0) FROM schema_version",
+	).Scan(&output.Version)
+	return
+}
@@ -2,36 +2,89 @@ package repository
 
 import (
 	"context"
+	"strconv"
+
+	"github.com/asrul10/UserService/audit"
+	"github.com/asrul10/UserService/events"
 )
 
 func (r *Repository) CreateUser(ctx context.Context, input CreateUserInput) (output CreateUserOutput, err error) {
-	tx, err := r.Db.Begin()
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return
 	}
-	defer tx.Commit()
+	defer tx.Rollback()
 
-	err = r.Db.QueryRowContext(
+	status := input.Status
+	if status == "" {
+		status = UserStatusActive
+	}
+	err = tx.QueryRowContext(
 		ctx,
-		"INSERT INTO users (phone_number, full_name, password) VALUES ($1, $2, $3) RETURNING id",
+		"INSERT INTO users (phone_number, full_name, password, status) VALUES ($1, $2, $3, $4) RETURNING id",
 		input.PhoneNumber,
 		input.FullName,
 		input.Password,
+		status,
 	).Scan(&output.UserId)
 	if err != nil {
-		tx.Rollback()
 		return
 	}
 
+	// The verification code is stored with the user, so a pending user
+	// never exists without a way to verify it.
+	if input.VerificationCodeHash != "" {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO registration_verifications (user_id, code_hash, expires_at) VALUES ($1, $2, $3)",
+			output.UserId,
+			input.VerificationCodeHash,
+			input.VerificationExpiresAt,
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	input.Audit.SubjectId = output.UserId
+	input.Audit.Changes = audit.Diff(nil, map[string]string{
+		"phone_number": input.PhoneNumber,
+		"full_name":    input.FullName,
+		"password":     input.Password,
+		"status":       status,
+	})
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+	err = insertOutboxEvent(ctx, tx, events.TypeUserRegistered, output.UserId, events.UserRegistered{
+		UserId:      output.UserId,
+		PhoneNumber: input.PhoneNumber,
+		FullName:    input.FullName,
+		Status:      status,
+	})
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+
 	return
 }
 
 func (r *Repository) GetUserByPhoneNumber(ctx context.Context, input GetUserByPhoneNumberInput) (output GetUserByPhoneNumberOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
 	err = r.Db.QueryRowContext(
 		ctx,
-		"SELECT id, password FROM users WHERE phone_number = $1",
+		`SELECT u.id, u.password, u.token_version, u.status, u.password_reset_required, u.created_at, COALESCE(t.enabled, FALSE)
+		FROM users u LEFT JOIN user_totp t ON t.user_id = u.id
+		WHERE u.phone_number = $1`,
 		input.PhoneNumber,
-	).Scan(&output.UserId, &output.Password)
+	).Scan(&output.UserId, &output.Password, &output.TokenVersion, &output.Status, &output.PasswordResetRequired, &output.CreatedAt, &output.MfaEnabled)
 	if err != nil {
 		return
 	}
@@ -40,11 +93,14 @@ func (r *Repository) GetUserByPhoneNumber(ctx context.Context, input GetUserByPh
 }
 
 func (r *Repository) GetUserById(ctx context.Context, input GetUserByIdInput) (output GetUserByIdOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
 	err = r.Db.QueryRowContext(
 		ctx,
-		"SELECT id, full_name, phone_number FROM users WHERE id = $1",
+		"SELECT id, full_name, phone_number, password FROM users WHERE id = $1",
 		input.UserId,
-	).Scan(&output.UserId, &output.FullName, &output.PhoneNumber)
+	).Scan(&output.UserId, &output.FullName, &output.PhoneNumber, &output.Password)
 	if err != nil {
 		return
 	}
@@ -53,13 +109,26 @@ func (r *Repository) GetUserById(ctx context.Context, input GetUserByIdInput) (o
 }
 
 func (r *Repository) UpdateUserById(ctx context.Context, input UpdateUserByIdInput) (output UpdateUserByIdOutput, err error) {
-	tx, err := r.Db.Begin()
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var fullName, phoneNumber string
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT full_name, phone_number FROM users WHERE id = $1 FOR UPDATE",
+		input.UserId,
+	).Scan(&fullName, &phoneNumber)
 	if err != nil {
 		return
 	}
-	defer tx.Commit()
 
-	_, err = r.Db.ExecContext(
+	_, err = tx.ExecContext(
 		ctx,
 		"UPDATE users SET full_name = $1, phone_number = $2 WHERE id = $3",
 		input.FullName,
@@ -67,7 +136,22 @@ func (r *Repository) UpdateUserById(ctx context.Context, input UpdateUserByIdInp
 		input.UserId,
 	)
 	if err != nil {
-		tx.Rollback()
+		return
+	}
+
+	input.Audit.SubjectId = input.UserId
+	input.Audit.Changes = audit.Diff(
+		map[string]string{"full_name": fullName, "phone_number": phoneNumber},
+		map[string]string{"full_name": input.FullName, "phone_number": input.PhoneNumber},
+	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+	if err = insertUserUpdatedEvent(ctx, tx, input.UserId, input.Audit.Changes); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
 		return
 	}
 
@@ -78,20 +162,94 @@ func (r *Repository) UpdateUserById(ctx context.Context, input UpdateUserByIdInp
 	return
 }
 
+func (r *Repository) UpdatePasswordById(ctx context.Context, input UpdatePasswordByIdInput) (output UpdatePasswordByIdOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var password string
+	var resetRequired bool
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT password, password_reset_required FROM users WHERE id = $1 FOR UPDATE",
+		input.UserId,
+	).Scan(&password, &resetRequired)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE users SET password = $1, password_reset_required = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		input.Password,
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	input.Audit.SubjectId = input.UserId
+	input.Audit.Changes = audit.Diff(
+		map[string]string{"password": password, "password_reset_required": strconv.FormatBool(resetRequired)},
+		map[string]string{"password": input.Password, "password_reset_required": "false"},
+	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+
+	return
+}
+
 func (r *Repository) SuccessLoginCount(ctx context.Context, input SuccessLoginCountInput) (output SuccessLoginCountOutput, err error) {
-	tx, err := r.Db.Begin()
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return
 	}
-	defer tx.Commit()
+	defer tx.Rollback()
 
-	_, err = r.Db.ExecContext(
+	var count int
+	err = tx.QueryRowContext(
 		ctx,
-		"UPDATE users SET success_login_count = success_login_count + 1 WHERE id = $1",
+		`UPDATE users SET success_login_count = COALESCE(success_login_count, 0) + 1 WHERE id = $1
+		RETURNING success_login_count`,
 		input.UserId,
+	).Scan(&count)
+	if err != nil {
+		return
+	}
+
+	input.Audit.SubjectId = input.UserId
+	input.Audit.Changes = audit.Diff(
+		map[string]string{"success_login_count": strconv.Itoa(count - 1)},
+		map[string]string{"success_login_count": strconv.Itoa(count)},
 	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+	err = insertOutboxEvent(ctx, tx, events.TypeUserLoggedIn, input.UserId, events.UserLoggedIn{
+		UserId:     input.UserId,
+		Method:     input.Method,
+		LoginCount: count,
+	})
 	if err != nil {
-		tx.Rollback()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
 		return
 	}
 
@@ -101,6 +259,9 @@ func (r *Repository) SuccessLoginCount(ctx context.Context, input SuccessLoginCo
 }
 
 func (r *Repository) IsPhoneNumberChanged(ctx context.Context, input IsPhoneNumberChangedInput) (output IsPhoneNumberChangedOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
 	var phoneNumber string
 	err = r.Db.QueryRowContext(
 		ctx,
@@ -115,3 +276,19 @@ func (r *Repository) IsPhoneNumberChanged(ctx context.Context, input IsPhoneNumb
 
 	return
 }
+
+func (r *Repository) GetTokenVersion(ctx context.Context, input GetTokenVersionInput) (output GetTokenVersionOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		"SELECT token_version, status FROM users WHERE id = $1",
+		input.UserId,
+	).Scan(&output.TokenVersion, &output.Status)
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -23,6 +23,10 @@ type RepositoryInterface interface {
 		ctx context.Context,
 		input UpdateUserByIdInput,
 	) (output UpdateUserByIdOutput, err error)
+	UpdatePasswordById(
+		ctx context.Context,
+		input UpdatePasswordByIdInput,
+	) (output UpdatePasswordByIdOutput, err error)
 	SuccessLoginCount(
 		ctx context.Context,
 		input SuccessLoginCountInput,
@@ -31,4 +35,256 @@ type RepositoryInterface interface {
 		ctx context.Context,
 		input IsPhoneNumberChangedInput,
 	) (output IsPhoneNumberChangedOutput, err error)
+	GetTokenVersion(
+		ctx context.Context,
+		input GetTokenVersionInput,
+	) (output GetTokenVersionOutput, err error)
+	CreatePhoneChangeRequest(
+		ctx context.Context,
+		input CreatePhoneChangeRequestInput,
+	) (output CreatePhoneChangeRequestOutput, err error)
+	GetPhoneChangeRequest(
+		ctx context.Context,
+		input GetPhoneChangeRequestInput,
+	) (output GetPhoneChangeRequestOutput, err error)
+	ReservePhoneChangeAttempt(
+		ctx context.Context,
+		input ReservePhoneChangeAttemptInput,
+	) (output ReservePhoneChangeAttemptOutput, err error)
+	ConfirmPhoneChange(
+		ctx context.Context,
+		input ConfirmPhoneChangeInput,
+	) (output ConfirmPhoneChangeOutput, err error)
+	ListPhoneNumbers(
+		ctx context.Context,
+		input ListPhoneNumbersInput,
+	) (output ListPhoneNumbersOutput, err error)
+	NormalizePhoneNumber(
+		ctx context.Context,
+		input NormalizePhoneNumberInput,
+	) (output NormalizePhoneNumberOutput, err error)
+	DeletePendingUser(
+		ctx context.Context,
+		input DeletePendingUserInput,
+	) (output DeletePendingUserOutput, err error)
+	UpsertRegistrationVerification(
+		ctx context.Context,
+		input UpsertRegistrationVerificationInput,
+	) (output UpsertRegistrationVerificationOutput, err error)
+	GetRegistrationVerification(
+		ctx context.Context,
+		input GetRegistrationVerificationInput,
+	) (output GetRegistrationVerificationOutput, err error)
+	ReserveRegistrationVerificationAttempt(
+		ctx context.Context,
+		input ReserveRegistrationVerificationAttemptInput,
+	) (output ReserveRegistrationVerificationAttemptOutput, err error)
+	ActivateUser(
+		ctx context.Context,
+		input ActivateUserInput,
+	) (output ActivateUserOutput, err error)
+	UpsertTOTPSecret(
+		ctx context.Context,
+		input UpsertTOTPSecretInput,
+	) (output UpsertTOTPSecretOutput, err error)
+	GetTOTP(
+		ctx context.Context,
+		input GetTOTPInput,
+	) (output GetTOTPOutput, err error)
+	UseTOTPStep(
+		ctx context.Context,
+		input UseTOTPStepInput,
+	) (output UseTOTPStepOutput, err error)
+	ReserveTOTPAttempt(
+		ctx context.Context,
+		input ReserveTOTPAttemptInput,
+	) (output ReserveTOTPAttemptOutput, err error)
+	DeleteTOTP(
+		ctx context.Context,
+		input DeleteTOTPInput,
+	) (output DeleteTOTPOutput, err error)
+	ReplaceRecoveryCodes(
+		ctx context.Context,
+		input ReplaceRecoveryCodesInput,
+	) (output ReplaceRecoveryCodesOutput, err error)
+	GetRecoveryCodes(
+		ctx context.Context,
+		input GetRecoveryCodesInput,
+	) (output GetRecoveryCodesOutput, err error)
+	UseRecoveryCode(
+		ctx context.Context,
+		input UseRecoveryCodeInput,
+	) (output UseRecoveryCodeOutput, err error)
+	UpsertLoginCode(
+		ctx context.Context,
+		input UpsertLoginCodeInput,
+	) (output UpsertLoginCodeOutput, err error)
+	GetLoginCode(
+		ctx context.Context,
+		input GetLoginCodeInput,
+	) (output GetLoginCodeOutput, err error)
+	ReserveLoginCodeAttempt(
+		ctx context.Context,
+		input ReserveLoginCodeAttemptInput,
+	) (output ReserveLoginCodeAttemptOutput, err error)
+	ConsumeLoginCode(
+		ctx context.Context,
+		input ConsumeLoginCodeInput,
+	) (output ConsumeLoginCodeOutput, err error)
+	CreateWebAuthnChallenge(
+		ctx context.Context,
+		input CreateWebAuthnChallengeInput,
+	) (output CreateWebAuthnChallengeOutput, err error)
+	ConsumeWebAuthnChallenge(
+		ctx context.Context,
+		input ConsumeWebAuthnChallengeInput,
+	) (output ConsumeWebAuthnChallengeOutput, err error)
+	CreateWebAuthnCredential(
+		ctx context.Context,
+		input CreateWebAuthnCredentialInput,
+	) (output CreateWebAuthnCredentialOutput, err error)
+	GetWebAuthnCredentials(
+		ctx context.Context,
+		input GetWebAuthnCredentialsInput,
+	) (output GetWebAuthnCredentialsOutput, err error)
+	GetWebAuthnCredential(
+		ctx context.Context,
+		input GetWebAuthnCredentialInput,
+	) (output GetWebAuthnCredentialOutput, err error)
+	UpdateWebAuthnSignCount(
+		ctx context.Context,
+		input UpdateWebAuthnSignCountInput,
+	) (output UpdateWebAuthnSignCountOutput, err error)
+	CreateSession(
+		ctx context.Context,
+		input CreateSessionInput,
+	) (output CreateSessionOutput, err error)
+	GetSession(
+		ctx context.Context,
+		input GetSessionInput,
+	) (output GetSessionOutput, err error)
+	GetSessions(
+		ctx context.Context,
+		input GetSessionsInput,
+	) (output GetSessionsOutput, err error)
+	TouchSession(
+		ctx context.Context,
+		input TouchSessionInput,
+	) (output TouchSessionOutput, err error)
+	RotateSessionRefreshToken(
+		ctx context.Context,
+		input RotateSessionRefreshTokenInput,
+	) (output RotateSessionRefreshTokenOutput, err error)
+	RevokeSession(
+		ctx context.Context,
+		input RevokeSessionInput,
+	) (output RevokeSessionOutput, err error)
+	CreateLoginEvent(
+		ctx context.Context,
+		input CreateLoginEventInput,
+	) (output CreateLoginEventOutput, err error)
+	GetLoginEvents(
+		ctx context.Context,
+		input GetLoginEventsInput,
+	) (output GetLoginEventsOutput, err error)
+	DeleteLoginEvents(
+		ctx context.Context,
+		input DeleteLoginEventsInput,
+	) (output DeleteLoginEventsOutput, err error)
+	GetRoles(
+		ctx context.Context,
+		input GetRolesInput,
+	) (output GetRolesOutput, err error)
+	GetUserRoles(
+		ctx context.Context,
+		input GetUserRolesInput,
+	) (output GetUserRolesOutput, err error)
+	SetUserRoles(
+		ctx context.Context,
+		input SetUserRolesInput,
+	) (output SetUserRolesOutput, err error)
+	ListUsers(
+		ctx context.Context,
+		input ListUsersInput,
+	) (output ListUsersOutput, err error)
+	GetUserDetails(
+		ctx context.Context,
+		input GetUserDetailsInput,
+	) (output GetUserDetailsOutput, err error)
+	SuspendUser(
+		ctx context.Context,
+		input SuspendUserInput,
+	) (output SuspendUserOutput, err error)
+	UnsuspendUser(
+		ctx context.Context,
+		input UnsuspendUserInput,
+	) (output UnsuspendUserOutput, err error)
+	ForcePasswordReset(
+		ctx context.Context,
+		input ForcePasswordResetInput,
+	) (output ForcePasswordResetOutput, err error)
+	RevokeUserSessions(
+		ctx context.Context,
+		input RevokeUserSessionsInput,
+	) (output RevokeUserSessionsOutput, err error)
+	CreateAuditEntry(
+		ctx context.Context,
+		input AuditEntry,
+	) (output CreateAuditEntryOutput, err error)
+	GetAuditRecords(
+		ctx context.Context,
+		input GetAuditRecordsInput,
+	) (output GetAuditRecordsOutput, err error)
+	ClaimOutboxEvents(
+		ctx context.Context,
+		input ClaimOutboxEventsInput,
+	) (output ClaimOutboxEventsOutput, err error)
+	DeleteOutboxEvent(
+		ctx context.Context,
+		input DeleteOutboxEventInput,
+	) (output DeleteOutboxEventOutput, err error)
+	RetryOutboxEvent(
+		ctx context.Context,
+		input RetryOutboxEventInput,
+	) (output RetryOutboxEventOutput, err error)
+	CreateWebhookSubscription(
+		ctx context.Context,
+		input CreateWebhookSubscriptionInput,
+	) (output CreateWebhookSubscriptionOutput, err error)
+	GetWebhookSubscriptions(
+		ctx context.Context,
+		input GetWebhookSubscriptionsInput,
+	) (output GetWebhookSubscriptionsOutput, err error)
+	DeleteWebhookSubscription(
+		ctx context.Context,
+		input DeleteWebhookSubscriptionInput,
+	) (output DeleteWebhookSubscriptionOutput, err error)
+	CreateWebhookDeliveries(
+		ctx context.Context,
+		input CreateWebhookDeliveriesInput,
+	) (output CreateWebhookDeliveriesOutput, err error)
+	ClaimWebhookDeliveries(
+		ctx context.Context,
+		input ClaimWebhookDeliveriesInput,
+	) (output ClaimWebhookDeliveriesOutput, err error)
+	RecordWebhookDelivery(
+		ctx context.Context,
+		input RecordWebhookDeliveryInput,
+	) (output RecordWebhookDeliveryOutput, err error)
+	GetWebhookDeliveries(
+		ctx context.Context,
+		input GetWebhookDeliveriesInput,
+	) (output GetWebhookDeliveriesOutput, err error)
+	ReplayWebhookDelivery(
+		ctx context.Context,
+		input ReplayWebhookDeliveryInput,
+	) (output ReplayWebhookDeliveryOutput, err error)
+	PingDatabase(
+		ctx context.Context,
+		input PingDatabaseInput,
+	) (output PingDatabaseOutput, err error)
+	GetSchemaVersion(
+		ctx context.Context,
+		input GetSchemaVersionInput,
+	) (output GetSchemaVersionOutput, err error)
 }
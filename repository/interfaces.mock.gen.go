@@ -34,6 +34,156 @@ func (m *MockRepositoryInterface) EXPECT() *MockRepositoryInterfaceMockRecorder
 	return m.recorder
 }
 
+// ActivateUser mocks base method.
+func (m *MockRepositoryInterface) ActivateUser(ctx context.Context, input ActivateUserInput) (ActivateUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivateUser", ctx, input)
+	ret0, _ := ret[0].(ActivateUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActivateUser indicates an expected call of ActivateUser.
+func (mr *MockRepositoryInterfaceMockRecorder) ActivateUser(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateUser", reflect.TypeOf((*MockRepositoryInterface)(nil).ActivateUser), ctx, input)
+}
+
+// ClaimOutboxEvents mocks base method.
+func (m *MockRepositoryInterface) ClaimOutboxEvents(ctx context.Context, input ClaimOutboxEventsInput) (ClaimOutboxEventsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutboxEvents", ctx, input)
+	ret0, _ := ret[0].(ClaimOutboxEventsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutboxEvents indicates an expected call of ClaimOutboxEvents.
+func (mr *MockRepositoryInterfaceMockRecorder) ClaimOutboxEvents(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutboxEvents", reflect.TypeOf((*MockRepositoryInterface)(nil).ClaimOutboxEvents), ctx, input)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockRepositoryInterface) ClaimWebhookDeliveries(ctx context.Context, input ClaimWebhookDeliveriesInput) (ClaimWebhookDeliveriesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, input)
+	ret0, _ := ret[0].(ClaimWebhookDeliveriesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockRepositoryInterfaceMockRecorder) ClaimWebhookDeliveries(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockRepositoryInterface)(nil).ClaimWebhookDeliveries), ctx, input)
+}
+
+// ConfirmPhoneChange mocks base method.
+func (m *MockRepositoryInterface) ConfirmPhoneChange(ctx context.Context, input ConfirmPhoneChangeInput) (ConfirmPhoneChangeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmPhoneChange", ctx, input)
+	ret0, _ := ret[0].(ConfirmPhoneChangeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmPhoneChange indicates an expected call of ConfirmPhoneChange.
+func (mr *MockRepositoryInterfaceMockRecorder) ConfirmPhoneChange(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmPhoneChange", reflect.TypeOf((*MockRepositoryInterface)(nil).ConfirmPhoneChange), ctx, input)
+}
+
+// ConsumeLoginCode mocks base method.
+func (m *MockRepositoryInterface) ConsumeLoginCode(ctx context.Context, input ConsumeLoginCodeInput) (ConsumeLoginCodeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeLoginCode", ctx, input)
+	ret0, _ := ret[0].(ConsumeLoginCodeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeLoginCode indicates an expected call of ConsumeLoginCode.
+func (mr *MockRepositoryInterfaceMockRecorder) ConsumeLoginCode(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeLoginCode", reflect.TypeOf((*MockRepositoryInterface)(nil).ConsumeLoginCode), ctx, input)
+}
+
+// ConsumeWebAuthnChallenge mocks base method.
+func (m *MockRepositoryInterface) ConsumeWebAuthnChallenge(ctx context.Context, input ConsumeWebAuthnChallengeInput) (ConsumeWebAuthnChallengeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeWebAuthnChallenge", ctx, input)
+	ret0, _ := ret[0].(ConsumeWebAuthnChallengeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeWebAuthnChallenge indicates an expected call of ConsumeWebAuthnChallenge.
+func (mr *MockRepositoryInterfaceMockRecorder) ConsumeWebAuthnChallenge(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeWebAuthnChallenge", reflect.TypeOf((*MockRepositoryInterface)(nil).ConsumeWebAuthnChallenge), ctx, input)
+}
+
+// CreateAuditEntry mocks base method.
+func (m *MockRepositoryInterface) CreateAuditEntry(ctx context.Context, input AuditEntry) (CreateAuditEntryOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEntry", ctx, input)
+	ret0, _ := ret[0].(CreateAuditEntryOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEntry indicates an expected call of CreateAuditEntry.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateAuditEntry(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEntry", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateAuditEntry), ctx, input)
+}
+
+// CreateLoginEvent mocks base method.
+func (m *MockRepositoryInterface) CreateLoginEvent(ctx context.Context, input CreateLoginEventInput) (CreateLoginEventOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginEvent", ctx, input)
+	ret0, _ := ret[0].(CreateLoginEventOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLoginEvent indicates an expected call of CreateLoginEvent.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateLoginEvent(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginEvent", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateLoginEvent), ctx, input)
+}
+
+// CreatePhoneChangeRequest mocks base method.
+func (m *MockRepositoryInterface) CreatePhoneChangeRequest(ctx context.Context, input CreatePhoneChangeRequestInput) (CreatePhoneChangeRequestOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePhoneChangeRequest", ctx, input)
+	ret0, _ := ret[0].(CreatePhoneChangeRequestOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePhoneChangeRequest indicates an expected call of CreatePhoneChangeRequest.
+func (mr *MockRepositoryInterfaceMockRecorder) CreatePhoneChangeRequest(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePhoneChangeRequest", reflect.TypeOf((*MockRepositoryInterface)(nil).CreatePhoneChangeRequest), ctx, input)
+}
+
+// CreateSession mocks base method.
+func (m *MockRepositoryInterface) CreateSession(ctx context.Context, input CreateSessionInput) (CreateSessionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, input)
+	ret0, _ := ret[0].(CreateSessionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateSession(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateSession), ctx, input)
+}
+
 // CreateUser mocks base method.
 func (m *MockRepositoryInterface) CreateUser(ctx context.Context, input CreateUserInput) (CreateUserOutput, error) {
 	m.ctrl.T.Helper()
@@ -49,6 +199,336 @@ func (mr *MockRepositoryInterfaceMockRecorder) CreateUser(ctx, input interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateUser), ctx, input)
 }
 
+// CreateWebAuthnChallenge mocks base method.
+func (m *MockRepositoryInterface) CreateWebAuthnChallenge(ctx context.Context, input CreateWebAuthnChallengeInput) (CreateWebAuthnChallengeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebAuthnChallenge", ctx, input)
+	ret0, _ := ret[0].(CreateWebAuthnChallengeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebAuthnChallenge indicates an expected call of CreateWebAuthnChallenge.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateWebAuthnChallenge(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebAuthnChallenge", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateWebAuthnChallenge), ctx, input)
+}
+
+// CreateWebAuthnCredential mocks base method.
+func (m *MockRepositoryInterface) CreateWebAuthnCredential(ctx context.Context, input CreateWebAuthnCredentialInput) (CreateWebAuthnCredentialOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebAuthnCredential", ctx, input)
+	ret0, _ := ret[0].(CreateWebAuthnCredentialOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebAuthnCredential indicates an expected call of CreateWebAuthnCredential.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateWebAuthnCredential(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebAuthnCredential", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateWebAuthnCredential), ctx, input)
+}
+
+// CreateWebhookDeliveries mocks base method.
+func (m *MockRepositoryInterface) CreateWebhookDeliveries(ctx context.Context, input CreateWebhookDeliveriesInput) (CreateWebhookDeliveriesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDeliveries", ctx, input)
+	ret0, _ := ret[0].(CreateWebhookDeliveriesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDeliveries indicates an expected call of CreateWebhookDeliveries.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateWebhookDeliveries(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDeliveries", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateWebhookDeliveries), ctx, input)
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockRepositoryInterface) CreateWebhookSubscription(ctx context.Context, input CreateWebhookSubscriptionInput) (CreateWebhookSubscriptionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, input)
+	ret0, _ := ret[0].(CreateWebhookSubscriptionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateWebhookSubscription(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateWebhookSubscription), ctx, input)
+}
+
+// DeleteLoginEvents mocks base method.
+func (m *MockRepositoryInterface) DeleteLoginEvents(ctx context.Context, input DeleteLoginEventsInput) (DeleteLoginEventsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginEvents", ctx, input)
+	ret0, _ := ret[0].(DeleteLoginEventsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteLoginEvents indicates an expected call of DeleteLoginEvents.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteLoginEvents(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginEvents", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteLoginEvents), ctx, input)
+}
+
+// DeleteOutboxEvent mocks base method.
+func (m *MockRepositoryInterface) DeleteOutboxEvent(ctx context.Context, input DeleteOutboxEventInput) (DeleteOutboxEventOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOutboxEvent", ctx, input)
+	ret0, _ := ret[0].(DeleteOutboxEventOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOutboxEvent indicates an expected call of DeleteOutboxEvent.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteOutboxEvent(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOutboxEvent", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteOutboxEvent), ctx, input)
+}
+
+// DeletePendingUser mocks base method.
+func (m *MockRepositoryInterface) DeletePendingUser(ctx context.Context, input DeletePendingUserInput) (DeletePendingUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingUser", ctx, input)
+	ret0, _ := ret[0].(DeletePendingUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePendingUser indicates an expected call of DeletePendingUser.
+func (mr *MockRepositoryInterfaceMockRecorder) DeletePendingUser(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingUser", reflect.TypeOf((*MockRepositoryInterface)(nil).DeletePendingUser), ctx, input)
+}
+
+// DeleteTOTP mocks base method.
+func (m *MockRepositoryInterface) DeleteTOTP(ctx context.Context, input DeleteTOTPInput) (DeleteTOTPOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTOTP", ctx, input)
+	ret0, _ := ret[0].(DeleteTOTPOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTOTP indicates an expected call of DeleteTOTP.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteTOTP(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTP", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteTOTP), ctx, input)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockRepositoryInterface) DeleteWebhookSubscription(ctx context.Context, input DeleteWebhookSubscriptionInput) (DeleteWebhookSubscriptionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, input)
+	ret0, _ := ret[0].(DeleteWebhookSubscriptionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteWebhookSubscription(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteWebhookSubscription), ctx, input)
+}
+
+// ForcePasswordReset mocks base method.
+func (m *MockRepositoryInterface) ForcePasswordReset(ctx context.Context, input ForcePasswordResetInput) (ForcePasswordResetOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForcePasswordReset", ctx, input)
+	ret0, _ := ret[0].(ForcePasswordResetOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForcePasswordReset indicates an expected call of ForcePasswordReset.
+func (mr *MockRepositoryInterfaceMockRecorder) ForcePasswordReset(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForcePasswordReset", reflect.TypeOf((*MockRepositoryInterface)(nil).ForcePasswordReset), ctx, input)
+}
+
+// GetAuditRecords mocks base method.
+func (m *MockRepositoryInterface) GetAuditRecords(ctx context.Context, input GetAuditRecordsInput) (GetAuditRecordsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditRecords", ctx, input)
+	ret0, _ := ret[0].(GetAuditRecordsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditRecords indicates an expected call of GetAuditRecords.
+func (mr *MockRepositoryInterfaceMockRecorder) GetAuditRecords(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditRecords", reflect.TypeOf((*MockRepositoryInterface)(nil).GetAuditRecords), ctx, input)
+}
+
+// GetLoginCode mocks base method.
+func (m *MockRepositoryInterface) GetLoginCode(ctx context.Context, input GetLoginCodeInput) (GetLoginCodeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginCode", ctx, input)
+	ret0, _ := ret[0].(GetLoginCodeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginCode indicates an expected call of GetLoginCode.
+func (mr *MockRepositoryInterfaceMockRecorder) GetLoginCode(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginCode", reflect.TypeOf((*MockRepositoryInterface)(nil).GetLoginCode), ctx, input)
+}
+
+// GetLoginEvents mocks base method.
+func (m *MockRepositoryInterface) GetLoginEvents(ctx context.Context, input GetLoginEventsInput) (GetLoginEventsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginEvents", ctx, input)
+	ret0, _ := ret[0].(GetLoginEventsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginEvents indicates an expected call of GetLoginEvents.
+func (mr *MockRepositoryInterfaceMockRecorder) GetLoginEvents(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginEvents", reflect.TypeOf((*MockRepositoryInterface)(nil).GetLoginEvents), ctx, input)
+}
+
+// GetPhoneChangeRequest mocks base method.
+func (m *MockRepositoryInterface) GetPhoneChangeRequest(ctx context.Context, input GetPhoneChangeRequestInput) (GetPhoneChangeRequestOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPhoneChangeRequest", ctx, input)
+	ret0, _ := ret[0].(GetPhoneChangeRequestOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPhoneChangeRequest indicates an expected call of GetPhoneChangeRequest.
+func (mr *MockRepositoryInterfaceMockRecorder) GetPhoneChangeRequest(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPhoneChangeRequest", reflect.TypeOf((*MockRepositoryInterface)(nil).GetPhoneChangeRequest), ctx, input)
+}
+
+// GetRecoveryCodes mocks base method.
+func (m *MockRepositoryInterface) GetRecoveryCodes(ctx context.Context, input GetRecoveryCodesInput) (GetRecoveryCodesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecoveryCodes", ctx, input)
+	ret0, _ := ret[0].(GetRecoveryCodesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecoveryCodes indicates an expected call of GetRecoveryCodes.
+func (mr *MockRepositoryInterfaceMockRecorder) GetRecoveryCodes(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecoveryCodes", reflect.TypeOf((*MockRepositoryInterface)(nil).GetRecoveryCodes), ctx, input)
+}
+
+// GetRegistrationVerification mocks base method.
+func (m *MockRepositoryInterface) GetRegistrationVerification(ctx context.Context, input GetRegistrationVerificationInput) (GetRegistrationVerificationOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegistrationVerification", ctx, input)
+	ret0, _ := ret[0].(GetRegistrationVerificationOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegistrationVerification indicates an expected call of GetRegistrationVerification.
+func (mr *MockRepositoryInterfaceMockRecorder) GetRegistrationVerification(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrationVerification", reflect.TypeOf((*MockRepositoryInterface)(nil).GetRegistrationVerification), ctx, input)
+}
+
+// GetRoles mocks base method.
+func (m *MockRepositoryInterface) GetRoles(ctx context.Context, input GetRolesInput) (GetRolesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", ctx, input)
+	ret0, _ := ret[0].(GetRolesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockRepositoryInterfaceMockRecorder) GetRoles(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockRepositoryInterface)(nil).GetRoles), ctx, input)
+}
+
+// GetSchemaVersion mocks base method.
+func (m *MockRepositoryInterface) GetSchemaVersion(ctx context.Context, input GetSchemaVersionInput) (GetSchemaVersionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchemaVersion", ctx, input)
+	ret0, _ := ret[0].(GetSchemaVersionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchemaVersion indicates an expected call of GetSchemaVersion.
+func (mr *MockRepositoryInterfaceMockRecorder) GetSchemaVersion(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemaVersion", reflect.TypeOf((*MockRepositoryInterface)(nil).GetSchemaVersion), ctx, input)
+}
+
+// GetSession mocks base method.
+func (m *MockRepositoryInterface) GetSession(ctx context.Context, input GetSessionInput) (GetSessionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, input)
+	ret0, _ := ret[0].(GetSessionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockRepositoryInterfaceMockRecorder) GetSession(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockRepositoryInterface)(nil).GetSession), ctx, input)
+}
+
+// GetSessions mocks base method.
+func (m *MockRepositoryInterface) GetSessions(ctx context.Context, input GetSessionsInput) (GetSessionsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, input)
+	ret0, _ := ret[0].(GetSessionsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockRepositoryInterfaceMockRecorder) GetSessions(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockRepositoryInterface)(nil).GetSessions), ctx, input)
+}
+
+// GetTOTP mocks base method.
+func (m *MockRepositoryInterface) GetTOTP(ctx context.Context, input GetTOTPInput) (GetTOTPOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTP", ctx, input)
+	ret0, _ := ret[0].(GetTOTPOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTOTP indicates an expected call of GetTOTP.
+func (mr *MockRepositoryInterfaceMockRecorder) GetTOTP(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTP", reflect.TypeOf((*MockRepositoryInterface)(nil).GetTOTP), ctx, input)
+}
+
+// GetTokenVersion mocks base method.
+func (m *MockRepositoryInterface) GetTokenVersion(ctx context.Context, input GetTokenVersionInput) (GetTokenVersionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenVersion", ctx, input)
+	ret0, _ := ret[0].(GetTokenVersionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenVersion indicates an expected call of GetTokenVersion.
+func (mr *MockRepositoryInterfaceMockRecorder) GetTokenVersion(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenVersion", reflect.TypeOf((*MockRepositoryInterface)(nil).GetTokenVersion), ctx, input)
+}
+
 // GetUserById mocks base method.
 func (m *MockRepositoryInterface) GetUserById(ctx context.Context, input GetUserByIdInput) (GetUserByIdOutput, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +559,96 @@ func (mr *MockRepositoryInterfaceMockRecorder) GetUserByPhoneNumber(ctx, input i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByPhoneNumber", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUserByPhoneNumber), ctx, input)
 }
 
+// GetUserDetails mocks base method.
+func (m *MockRepositoryInterface) GetUserDetails(ctx context.Context, input GetUserDetailsInput) (GetUserDetailsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDetails", ctx, input)
+	ret0, _ := ret[0].(GetUserDetailsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDetails indicates an expected call of GetUserDetails.
+func (mr *MockRepositoryInterfaceMockRecorder) GetUserDetails(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDetails", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUserDetails), ctx, input)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockRepositoryInterface) GetUserRoles(ctx context.Context, input GetUserRolesInput) (GetUserRolesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, input)
+	ret0, _ := ret[0].(GetUserRolesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockRepositoryInterfaceMockRecorder) GetUserRoles(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUserRoles), ctx, input)
+}
+
+// GetWebAuthnCredential mocks base method.
+func (m *MockRepositoryInterface) GetWebAuthnCredential(ctx context.Context, input GetWebAuthnCredentialInput) (GetWebAuthnCredentialOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredential", ctx, input)
+	ret0, _ := ret[0].(GetWebAuthnCredentialOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredential indicates an expected call of GetWebAuthnCredential.
+func (mr *MockRepositoryInterfaceMockRecorder) GetWebAuthnCredential(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredential", reflect.TypeOf((*MockRepositoryInterface)(nil).GetWebAuthnCredential), ctx, input)
+}
+
+// GetWebAuthnCredentials mocks base method.
+func (m *MockRepositoryInterface) GetWebAuthnCredentials(ctx context.Context, input GetWebAuthnCredentialsInput) (GetWebAuthnCredentialsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredentials", ctx, input)
+	ret0, _ := ret[0].(GetWebAuthnCredentialsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredentials indicates an expected call of GetWebAuthnCredentials.
+func (mr *MockRepositoryInterfaceMockRecorder) GetWebAuthnCredentials(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredentials", reflect.TypeOf((*MockRepositoryInterface)(nil).GetWebAuthnCredentials), ctx, input)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockRepositoryInterface) GetWebhookDeliveries(ctx context.Context, input GetWebhookDeliveriesInput) (GetWebhookDeliveriesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, input)
+	ret0, _ := ret[0].(GetWebhookDeliveriesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockRepositoryInterfaceMockRecorder) GetWebhookDeliveries(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockRepositoryInterface)(nil).GetWebhookDeliveries), ctx, input)
+}
+
+// GetWebhookSubscriptions mocks base method.
+func (m *MockRepositoryInterface) GetWebhookSubscriptions(ctx context.Context, input GetWebhookSubscriptionsInput) (GetWebhookSubscriptionsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscriptions", ctx, input)
+	ret0, _ := ret[0].(GetWebhookSubscriptionsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscriptions indicates an expected call of GetWebhookSubscriptions.
+func (mr *MockRepositoryInterfaceMockRecorder) GetWebhookSubscriptions(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscriptions", reflect.TypeOf((*MockRepositoryInterface)(nil).GetWebhookSubscriptions), ctx, input)
+}
+
 // IsPhoneNumberChanged mocks base method.
 func (m *MockRepositoryInterface) IsPhoneNumberChanged(ctx context.Context, input IsPhoneNumberChangedInput) (IsPhoneNumberChangedOutput, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +664,246 @@ func (mr *MockRepositoryInterfaceMockRecorder) IsPhoneNumberChanged(ctx, input i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPhoneNumberChanged", reflect.TypeOf((*MockRepositoryInterface)(nil).IsPhoneNumberChanged), ctx, input)
 }
 
+// ListPhoneNumbers mocks base method.
+func (m *MockRepositoryInterface) ListPhoneNumbers(ctx context.Context, input ListPhoneNumbersInput) (ListPhoneNumbersOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPhoneNumbers", ctx, input)
+	ret0, _ := ret[0].(ListPhoneNumbersOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPhoneNumbers indicates an expected call of ListPhoneNumbers.
+func (mr *MockRepositoryInterfaceMockRecorder) ListPhoneNumbers(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPhoneNumbers", reflect.TypeOf((*MockRepositoryInterface)(nil).ListPhoneNumbers), ctx, input)
+}
+
+// ListUsers mocks base method.
+func (m *MockRepositoryInterface) ListUsers(ctx context.Context, input ListUsersInput) (ListUsersOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, input)
+	ret0, _ := ret[0].(ListUsersOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockRepositoryInterfaceMockRecorder) ListUsers(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockRepositoryInterface)(nil).ListUsers), ctx, input)
+}
+
+// NormalizePhoneNumber mocks base method.
+func (m *MockRepositoryInterface) NormalizePhoneNumber(ctx context.Context, input NormalizePhoneNumberInput) (NormalizePhoneNumberOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NormalizePhoneNumber", ctx, input)
+	ret0, _ := ret[0].(NormalizePhoneNumberOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NormalizePhoneNumber indicates an expected call of NormalizePhoneNumber.
+func (mr *MockRepositoryInterfaceMockRecorder) NormalizePhoneNumber(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NormalizePhoneNumber", reflect.TypeOf((*MockRepositoryInterface)(nil).NormalizePhoneNumber), ctx, input)
+}
+
+// PingDatabase mocks base method.
+func (m *MockRepositoryInterface) PingDatabase(ctx context.Context, input PingDatabaseInput) (PingDatabaseOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PingDatabase", ctx, input)
+	ret0, _ := ret[0].(PingDatabaseOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PingDatabase indicates an expected call of PingDatabase.
+func (mr *MockRepositoryInterfaceMockRecorder) PingDatabase(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PingDatabase", reflect.TypeOf((*MockRepositoryInterface)(nil).PingDatabase), ctx, input)
+}
+
+// RecordWebhookDelivery mocks base method.
+func (m *MockRepositoryInterface) RecordWebhookDelivery(ctx context.Context, input RecordWebhookDeliveryInput) (RecordWebhookDeliveryOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookDelivery", ctx, input)
+	ret0, _ := ret[0].(RecordWebhookDeliveryOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordWebhookDelivery indicates an expected call of RecordWebhookDelivery.
+func (mr *MockRepositoryInterfaceMockRecorder) RecordWebhookDelivery(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookDelivery", reflect.TypeOf((*MockRepositoryInterface)(nil).RecordWebhookDelivery), ctx, input)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockRepositoryInterface) ReplaceRecoveryCodes(ctx context.Context, input ReplaceRecoveryCodesInput) (ReplaceRecoveryCodesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", ctx, input)
+	ret0, _ := ret[0].(ReplaceRecoveryCodesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockRepositoryInterfaceMockRecorder) ReplaceRecoveryCodes(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockRepositoryInterface)(nil).ReplaceRecoveryCodes), ctx, input)
+}
+
+// ReplayWebhookDelivery mocks base method.
+func (m *MockRepositoryInterface) ReplayWebhookDelivery(ctx context.Context, input ReplayWebhookDeliveryInput) (ReplayWebhookDeliveryOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayWebhookDelivery", ctx, input)
+	ret0, _ := ret[0].(ReplayWebhookDeliveryOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayWebhookDelivery indicates an expected call of ReplayWebhookDelivery.
+func (mr *MockRepositoryInterfaceMockRecorder) ReplayWebhookDelivery(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayWebhookDelivery", reflect.TypeOf((*MockRepositoryInterface)(nil).ReplayWebhookDelivery), ctx, input)
+}
+
+// ReserveLoginCodeAttempt mocks base method.
+func (m *MockRepositoryInterface) ReserveLoginCodeAttempt(ctx context.Context, input ReserveLoginCodeAttemptInput) (ReserveLoginCodeAttemptOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveLoginCodeAttempt", ctx, input)
+	ret0, _ := ret[0].(ReserveLoginCodeAttemptOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveLoginCodeAttempt indicates an expected call of ReserveLoginCodeAttempt.
+func (mr *MockRepositoryInterfaceMockRecorder) ReserveLoginCodeAttempt(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveLoginCodeAttempt", reflect.TypeOf((*MockRepositoryInterface)(nil).ReserveLoginCodeAttempt), ctx, input)
+}
+
+// ReservePhoneChangeAttempt mocks base method.
+func (m *MockRepositoryInterface) ReservePhoneChangeAttempt(ctx context.Context, input ReservePhoneChangeAttemptInput) (ReservePhoneChangeAttemptOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReservePhoneChangeAttempt", ctx, input)
+	ret0, _ := ret[0].(ReservePhoneChangeAttemptOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReservePhoneChangeAttempt indicates an expected call of ReservePhoneChangeAttempt.
+func (mr *MockRepositoryInterfaceMockRecorder) ReservePhoneChangeAttempt(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReservePhoneChangeAttempt", reflect.TypeOf((*MockRepositoryInterface)(nil).ReservePhoneChangeAttempt), ctx, input)
+}
+
+// ReserveRegistrationVerificationAttempt mocks base method.
+func (m *MockRepositoryInterface) ReserveRegistrationVerificationAttempt(ctx context.Context, input ReserveRegistrationVerificationAttemptInput) (ReserveRegistrationVerificationAttemptOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveRegistrationVerificationAttempt", ctx, input)
+	ret0, _ := ret[0].(ReserveRegistrationVerificationAttemptOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveRegistrationVerificationAttempt indicates an expected call of ReserveRegistrationVerificationAttempt.
+func (mr *MockRepositoryInterfaceMockRecorder) ReserveRegistrationVerificationAttempt(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveRegistrationVerificationAttempt", reflect.TypeOf((*MockRepositoryInterface)(nil).ReserveRegistrationVerificationAttempt), ctx, input)
+}
+
+// ReserveTOTPAttempt mocks base method.
+func (m *MockRepositoryInterface) ReserveTOTPAttempt(ctx context.Context, input ReserveTOTPAttemptInput) (ReserveTOTPAttemptOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveTOTPAttempt", ctx, input)
+	ret0, _ := ret[0].(ReserveTOTPAttemptOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveTOTPAttempt indicates an expected call of ReserveTOTPAttempt.
+func (mr *MockRepositoryInterfaceMockRecorder) ReserveTOTPAttempt(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveTOTPAttempt", reflect.TypeOf((*MockRepositoryInterface)(nil).ReserveTOTPAttempt), ctx, input)
+}
+
+// RetryOutboxEvent mocks base method.
+func (m *MockRepositoryInterface) RetryOutboxEvent(ctx context.Context, input RetryOutboxEventInput) (RetryOutboxEventOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryOutboxEvent", ctx, input)
+	ret0, _ := ret[0].(RetryOutboxEventOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryOutboxEvent indicates an expected call of RetryOutboxEvent.
+func (mr *MockRepositoryInterfaceMockRecorder) RetryOutboxEvent(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryOutboxEvent", reflect.TypeOf((*MockRepositoryInterface)(nil).RetryOutboxEvent), ctx, input)
+}
+
+// RevokeSession mocks base method.
+func (m *MockRepositoryInterface) RevokeSession(ctx context.Context, input RevokeSessionInput) (RevokeSessionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, input)
+	ret0, _ := ret[0].(RevokeSessionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeSession(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeSession), ctx, input)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockRepositoryInterface) RevokeUserSessions(ctx context.Context, input RevokeUserSessionsInput) (RevokeUserSessionsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, input)
+	ret0, _ := ret[0].(RevokeUserSessionsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeUserSessions(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeUserSessions), ctx, input)
+}
+
+// RotateSessionRefreshToken mocks base method.
+func (m *MockRepositoryInterface) RotateSessionRefreshToken(ctx context.Context, input RotateSessionRefreshTokenInput) (RotateSessionRefreshTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSessionRefreshToken", ctx, input)
+	ret0, _ := ret[0].(RotateSessionRefreshTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSessionRefreshToken indicates an expected call of RotateSessionRefreshToken.
+func (mr *MockRepositoryInterfaceMockRecorder) RotateSessionRefreshToken(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSessionRefreshToken", reflect.TypeOf((*MockRepositoryInterface)(nil).RotateSessionRefreshToken), ctx, input)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockRepositoryInterface) SetUserRoles(ctx context.Context, input SetUserRolesInput) (SetUserRolesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", ctx, input)
+	ret0, _ := ret[0].(SetUserRolesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockRepositoryInterfaceMockRecorder) SetUserRoles(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockRepositoryInterface)(nil).SetUserRoles), ctx, input)
+}
+
 // SuccessLoginCount mocks base method.
 func (m *MockRepositoryInterface) SuccessLoginCount(ctx context.Context, input SuccessLoginCountInput) (SuccessLoginCountOutput, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +919,66 @@ func (mr *MockRepositoryInterfaceMockRecorder) SuccessLoginCount(ctx, input inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuccessLoginCount", reflect.TypeOf((*MockRepositoryInterface)(nil).SuccessLoginCount), ctx, input)
 }
 
+// SuspendUser mocks base method.
+func (m *MockRepositoryInterface) SuspendUser(ctx context.Context, input SuspendUserInput) (SuspendUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", ctx, input)
+	ret0, _ := ret[0].(SuspendUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockRepositoryInterfaceMockRecorder) SuspendUser(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockRepositoryInterface)(nil).SuspendUser), ctx, input)
+}
+
+// TouchSession mocks base method.
+func (m *MockRepositoryInterface) TouchSession(ctx context.Context, input TouchSessionInput) (TouchSessionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, input)
+	ret0, _ := ret[0].(TouchSessionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockRepositoryInterfaceMockRecorder) TouchSession(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockRepositoryInterface)(nil).TouchSession), ctx, input)
+}
+
+// UnsuspendUser mocks base method.
+func (m *MockRepositoryInterface) UnsuspendUser(ctx context.Context, input UnsuspendUserInput) (UnsuspendUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsuspendUser", ctx, input)
+	ret0, _ := ret[0].(UnsuspendUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnsuspendUser indicates an expected call of UnsuspendUser.
+func (mr *MockRepositoryInterfaceMockRecorder) UnsuspendUser(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsuspendUser", reflect.TypeOf((*MockRepositoryInterface)(nil).UnsuspendUser), ctx, input)
+}
+
+// UpdatePasswordById mocks base method.
+func (m *MockRepositoryInterface) UpdatePasswordById(ctx context.Context, input UpdatePasswordByIdInput) (UpdatePasswordByIdOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordById", ctx, input)
+	ret0, _ := ret[0].(UpdatePasswordByIdOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePasswordById indicates an expected call of UpdatePasswordById.
+func (mr *MockRepositoryInterfaceMockRecorder) UpdatePasswordById(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordById", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdatePasswordById), ctx, input)
+}
+
 // UpdateUserById mocks base method.
 func (m *MockRepositoryInterface) UpdateUserById(ctx context.Context, input UpdateUserByIdInput) (UpdateUserByIdOutput, error) {
 	m.ctrl.T.Helper()
@@ -123,3 +993,93 @@ func (mr *MockRepositoryInterfaceMockRecorder) UpdateUserById(ctx, input interfa
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserById", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateUserById), ctx, input)
 }
+
+// UpdateWebAuthnSignCount mocks base method.
+func (m *MockRepositoryInterface) UpdateWebAuthnSignCount(ctx context.Context, input UpdateWebAuthnSignCountInput) (UpdateWebAuthnSignCountOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnSignCount", ctx, input)
+	ret0, _ := ret[0].(UpdateWebAuthnSignCountOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebAuthnSignCount indicates an expected call of UpdateWebAuthnSignCount.
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateWebAuthnSignCount(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnSignCount", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateWebAuthnSignCount), ctx, input)
+}
+
+// UpsertLoginCode mocks base method.
+func (m *MockRepositoryInterface) UpsertLoginCode(ctx context.Context, input UpsertLoginCodeInput) (UpsertLoginCodeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertLoginCode", ctx, input)
+	ret0, _ := ret[0].(UpsertLoginCodeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertLoginCode indicates an expected call of UpsertLoginCode.
+func (mr *MockRepositoryInterfaceMockRecorder) UpsertLoginCode(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertLoginCode", reflect.TypeOf((*MockRepositoryInterface)(nil).UpsertLoginCode), ctx, input)
+}
+
+// UpsertRegistrationVerification mocks base method.
+func (m *MockRepositoryInterface) UpsertRegistrationVerification(ctx context.Context, input UpsertRegistrationVerificationInput) (UpsertRegistrationVerificationOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertRegistrationVerification", ctx, input)
+	ret0, _ := ret[0].(UpsertRegistrationVerificationOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertRegistrationVerification indicates an expected call of UpsertRegistrationVerification.
+func (mr *MockRepositoryInterfaceMockRecorder) UpsertRegistrationVerification(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertRegistrationVerification", reflect.TypeOf((*MockRepositoryInterface)(nil).UpsertRegistrationVerification), ctx, input)
+}
+
+// UpsertTOTPSecret mocks base method.
+func (m *MockRepositoryInterface) UpsertTOTPSecret(ctx context.Context, input UpsertTOTPSecretInput) (UpsertTOTPSecretOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTOTPSecret", ctx, input)
+	ret0, _ := ret[0].(UpsertTOTPSecretOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertTOTPSecret indicates an expected call of UpsertTOTPSecret.
+func (mr *MockRepositoryInterfaceMockRecorder) UpsertTOTPSecret(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTOTPSecret", reflect.TypeOf((*MockRepositoryInterface)(nil).UpsertTOTPSecret), ctx, input)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockRepositoryInterface) UseRecoveryCode(ctx context.Context, input UseRecoveryCodeInput) (UseRecoveryCodeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, input)
+	ret0, _ := ret[0].(UseRecoveryCodeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockRepositoryInterfaceMockRecorder) UseRecoveryCode(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockRepositoryInterface)(nil).UseRecoveryCode), ctx, input)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockRepositoryInterface) UseTOTPStep(ctx context.Context, input UseTOTPStepInput) (UseTOTPStepOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, input)
+	ret0, _ := ret[0].(UseTOTPStepOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockRepositoryInterfaceMockRecorder) UseTOTPStep(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockRepositoryInterface)(nil).UseTOTPStep), ctx, input)
+}
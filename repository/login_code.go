@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+)
+
+// UpsertLoginCode stores the code of a passwordless login, replacing a
+// previous code of the user. The attempts carry over until the previous
+// code expires, so requesting a new code doesn't reset the budget.
+func (r *Repository) UpsertLoginCode(ctx context.Context, input UpsertLoginCodeInput) (output UpsertLoginCodeOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	_, err = r.Db.ExecContext(
+		ctx,
+		`INSERT INTO login_codes (user_id, code_hash, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash, expires_at = EXCLUDED.expires_at,
+			created_at = CURRENT_TIMESTAMP,
+			attempts = CASE WHEN login_codes.expires_at < CURRENT_TIMESTAMP
+				THEN 0 ELSE login_codes.attempts END`,
+		input.UserId,
+		input.CodeHash,
+		input.ExpiresAt,
+	)
+	if err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+
+	return
+}
+
+func (r *Repository) GetLoginCode(ctx context.Context, input GetLoginCodeInput) (output GetLoginCodeOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		"SELECT user_id, code_hash, attempts, expires_at, created_at FROM login_codes WHERE user_id = $1",
+		input.UserId,
+	).Scan(&output.UserId, &output.CodeHash, &output.Attempts, &output.ExpiresAt, &output.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// ReserveLoginCodeAttempt counts an attempt before the code is compared,
+// so parallel guesses can't exceed MaxAttempts. sql.ErrNoRows is returned
+// when no attempt is left, the code expired or there is none.
+func (r *Repository) ReserveLoginCodeAttempt(ctx context.Context, input ReserveLoginCodeAttemptInput) (output ReserveLoginCodeAttemptOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		`UPDATE login_codes SET attempts = attempts + 1
+		WHERE user_id = $1 AND attempts < $2 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING code_hash, attempts`,
+		input.UserId,
+		input.MaxAttempts,
+	).Scan(&output.CodeHash, &output.Attempts)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// ConsumeLoginCode deletes the code so it can't be used twice. Only the
+// request deleting the row is accepted.
+func (r *Repository) ConsumeLoginCode(ctx context.Context, input ConsumeLoginCodeInput) (output ConsumeLoginCodeOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	res, err := r.Db.ExecContext(
+		ctx,
+		"DELETE FROM login_codes WHERE user_id = $1 AND code_hash = $2",
+		input.UserId,
+		input.CodeHash,
+	)
+	if err != nil {
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	output.Accepted = affected == 1
+
+	return
+}
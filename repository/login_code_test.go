@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestRepository connects to the database of TEST_DATABASE_URL, created
+// from database.sql. The test is skipped without it.
+func newTestRepository(t *testing.T) *Repository {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if testing.Short() || dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	r := NewRepository(NewRepositoryOptions{Dsn: dsn, ConnectRetries: -1})
+	t.Cleanup(func() { r.Db.Close() })
+	return r
+}
+
+func TestUpsertLoginCodeAttempts(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	// Test cases
+	tests := []struct {
+		caseName         string
+		expired          bool
+		expectedAttempts int
+	}{
+		{
+			caseName:         "Resend keeps the attempts",
+			expired:          false,
+			expectedAttempts: 1,
+		},
+		{
+			caseName:         "Resend after the code expired resets the attempts",
+			expired:          true,
+			expectedAttempts: 0,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			var userId int
+			err := r.Db.QueryRowContext(
+				ctx,
+				"INSERT INTO users (phone_number, full_name, password) VALUES ($1, 'test', 'test') RETURNING id",
+				fmt.Sprintf("+62812%07d", int(time.Now().UnixNano()%1e7)+i),
+			).Scan(&userId)
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			t.Cleanup(func() { r.Db.Exec("DELETE FROM users WHERE id = $1", userId) })
+
+			if _, err := r.UpsertLoginCode(ctx, UpsertLoginCodeInput{
+				UserId:    userId,
+				CodeHash:  "first",
+				ExpiresAt: time.Now().Add(time.Minute),
+			}); err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			if _, err := r.ReserveLoginCodeAttempt(ctx, ReserveLoginCodeAttemptInput{
+				UserId:      userId,
+				MaxAttempts: 5,
+			}); err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			if test.expired {
+				if _, err := r.Db.ExecContext(ctx, "UPDATE login_codes SET expires_at = CURRENT_TIMESTAMP - INTERVAL '1 second' WHERE user_id = $1", userId); err != nil {
+					t.Fatalf("Error: %v", err)
+				}
+			}
+
+			// Resend
+			if _, err := r.UpsertLoginCode(ctx, UpsertLoginCodeInput{
+				UserId:    userId,
+				CodeHash:  "second",
+				ExpiresAt: time.Now().Add(time.Minute),
+			}); err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+
+			code, err := r.GetLoginCode(ctx, GetLoginCodeInput{UserId: userId})
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			if code.CodeHash != "second" {
+				t.Errorf("Expected the new code, got %q", code.CodeHash)
+			}
+			if code.Attempts != test.expectedAttempts {
+				t.Errorf("Expected %d attempts, got %d", test.expectedAttempts, code.Attempts)
+			}
+		})
+	}
+}
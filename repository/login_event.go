@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) CreateLoginEvent(ctx context.Context, input CreateLoginEventInput) (output CreateLoginEventOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	userId := sql.NullInt64{Int64: int64(input.UserId), Valid: input.UserId != 0}
+	failureReason := sql.NullString{String: input.FailureReason, Valid: input.FailureReason != ""}
+	riskDecision := sql.NullString{String: input.RiskDecision, Valid: input.RiskDecision != ""}
+	err = r.Db.QueryRowContext(
+		ctx,
+		`INSERT INTO login_events (user_id, method, success, failure_reason, ip_address, user_agent, risk_decision, risk_reasons)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		userId,
+		input.Method,
+		input.Success,
+		failureReason,
+		input.IpAddress,
+		input.UserAgent,
+		riskDecision,
+		pq.Array(input.RiskReasons),
+	).Scan(&output.Id)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// GetLoginEvents returns the events of the user, latest first. Pages are
+// keyed by the event id so new events don't shift them.
+func (r *Repository) GetLoginEvents(ctx context.Context, input GetLoginEventsInput) (output GetLoginEventsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`SELECT id, user_id, method, success, failure_reason, ip_address, user_agent, risk_decision, risk_reasons, created_at
+		FROM login_events WHERE user_id = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC LIMIT $3`,
+		input.UserId,
+		input.BeforeId,
+		input.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event LoginEvent
+		var failureReason, riskDecision sql.NullString
+		if err = rows.Scan(
+			&event.Id,
+			&event.UserId,
+			&event.Method,
+			&event.Success,
+			&failureReason,
+			&event.IpAddress,
+			&event.UserAgent,
+			&riskDecision,
+			pq.Array(&event.RiskReasons),
+			&event.CreatedAt,
+		); err != nil {
+			return
+		}
+		event.FailureReason = failureReason.String
+		event.RiskDecision = riskDecision.String
+		output.Events = append(output.Events, event)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// DeleteLoginEvents prunes events older than the retention period. At most
+// Limit events are deleted so a large backlog doesn't lock the table.
+func (r *Repository) DeleteLoginEvents(ctx context.Context, input DeleteLoginEventsInput) (output DeleteLoginEventsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, batchQuery)
+	defer cancel()
+
+	res, err := r.Db.ExecContext(
+		ctx,
+		`DELETE FROM login_events WHERE id IN (
+			SELECT id FROM login_events WHERE created_at < $1 ORDER BY id LIMIT $2
+		)`,
+		input.Before,
+		input.Limit,
+	)
+	if err != nil {
+		return
+	}
+
+	output.Deleted, err = res.RowsAffected()
+
+	return
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+
+	"github.com/asrul10/UserService/audit"
+	"github.com/asrul10/UserService/events"
+)
+
+// insertOutboxEvent queues an event in the transaction of the change, so
+// it is published if and only if the change is committed.
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, eventType string, userId int, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO outbox (event_type, user_id, data) VALUES ($1, $2, $3)",
+		eventType,
+		userId,
+		payload,
+	)
+	return err
+}
+
+// insertUserUpdatedEvent queues a user.updated event with the fields of
+// the changes other services know about. Nothing is queued when none of
+// them changed.
+func insertUserUpdatedEvent(ctx context.Context, tx *sql.Tx, userId int, changes []audit.Change) error {
+	event := events.UserUpdated{UserId: userId}
+	changed := false
+	for _, change := range changes {
+		after := change.After
+		switch change.Field {
+		case "phone_number":
+			event.PhoneNumber = &after
+		case "full_name":
+			event.FullName = &after
+		case "status":
+			event.Status = &after
+		default:
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	return insertOutboxEvent(ctx, tx, events.TypeUserUpdated, userId, event)
+}
+
+// ClaimOutboxEvents returns the events due for publishing, oldest first,
+// and hides them from other relays until the lease ends. An event the
+// relay didn't finish is published again after the lease.
+func (r *Repository) ClaimOutboxEvents(ctx context.Context, input ClaimOutboxEventsInput) (output ClaimOutboxEventsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, batchQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`UPDATE outbox SET next_attempt_at = CURRENT_TIMESTAMP + $1 * INTERVAL '1 microsecond', attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox WHERE next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, user_id, data, created_at, attempts`,
+		input.Lease.Microseconds(),
+		input.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event OutboxEvent
+		var data []byte
+		err = rows.Scan(
+			&event.Event.Id,
+			&event.Event.Type,
+			&event.Event.UserId,
+			&data,
+			&event.Event.OccurredAt,
+			&event.Attempts,
+		)
+		if err != nil {
+			return
+		}
+		event.Event.Data = json.RawMessage(data)
+		output.Events = append(output.Events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	// RETURNING doesn't keep the order of the subquery
+	sort.Slice(output.Events, func(i, j int) bool {
+		return output.Events[i].Event.Id < output.Events[j].Event.Id
+	})
+
+	return
+}
+
+func (r *Repository) DeleteOutboxEvent(ctx context.Context, input DeleteOutboxEventInput) (output DeleteOutboxEventOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	_, err = r.Db.ExecContext(
+		ctx,
+		"DELETE FROM outbox WHERE id = $1",
+		input.Id,
+	)
+	if err != nil {
+		return
+	}
+
+	output.Id = input.Id
+
+	return
+}
+
+// RetryOutboxEvent records a failed delivery and when to try again
+func (r *Repository) RetryOutboxEvent(ctx context.Context, input RetryOutboxEventInput) (output RetryOutboxEventOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	_, err = r.Db.ExecContext(
+		ctx,
+		"UPDATE outbox SET next_attempt_at = $1, last_error = $2 WHERE id = $3",
+		input.NextAttemptAt,
+		input.Error,
+		input.Id,
+	)
+	if err != nil {
+		return
+	}
+
+	output.Id = input.Id
+
+	return
+}
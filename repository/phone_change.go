@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/asrul10/UserService/audit"
+	"github.com/lib/pq"
+)
+
+// Postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+func (r *Repository) CreatePhoneChangeRequest(ctx context.Context, input CreatePhoneChangeRequestInput) (output CreatePhoneChangeRequestOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	// Only one pending request per user, a new request replaces the
+	// previous code. The attempts carry over until the previous request
+	// expires, so requesting a new code doesn't reset the budget.
+	_, err = r.Db.ExecContext(
+		ctx,
+		`INSERT INTO phone_change_requests (user_id, phone_number, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET phone_number = EXCLUDED.phone_number, code_hash = EXCLUDED.code_hash,
+			expires_at = EXCLUDED.expires_at, created_at = CURRENT_TIMESTAMP,
+			attempts = CASE WHEN phone_change_requests.expires_at < CURRENT_TIMESTAMP
+				THEN 0 ELSE phone_change_requests.attempts END`,
+		input.UserId,
+		input.PhoneNumber,
+		input.CodeHash,
+		input.ExpiresAt,
+	)
+	if err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+
+	return
+}
+
+func (r *Repository) GetPhoneChangeRequest(ctx context.Context, input GetPhoneChangeRequestInput) (output GetPhoneChangeRequestOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		"SELECT user_id, phone_number, code_hash, attempts, expires_at, created_at FROM phone_change_requests WHERE user_id = $1",
+		input.UserId,
+	).Scan(&output.UserId, &output.PhoneNumber, &output.CodeHash, &output.Attempts, &output.ExpiresAt, &output.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// ReservePhoneChangeAttempt counts an attempt before the code is
+// compared, so parallel guesses can't exceed MaxAttempts. sql.ErrNoRows is
+// returned when no attempt is left, the code expired or there is none.
+func (r *Repository) ReservePhoneChangeAttempt(ctx context.Context, input ReservePhoneChangeAttemptInput) (output ReservePhoneChangeAttemptOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		`UPDATE phone_change_requests SET attempts = attempts + 1
+		WHERE user_id = $1 AND attempts < $2 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING phone_number, code_hash, attempts`,
+		input.UserId,
+		input.MaxAttempts,
+	).Scan(&output.PhoneNumber, &output.CodeHash, &output.Attempts)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// ConfirmPhoneChange swaps the phone number, revokes the issued tokens and
+// removes the pending request in one transaction. ErrPhoneNumberTaken is
+// returned when the number was registered since the request was made.
+func (r *Repository) ConfirmPhoneChange(ctx context.Context, input ConfirmPhoneChangeInput) (output ConfirmPhoneChangeOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT phone_number FROM users WHERE id = $1 FOR UPDATE",
+		input.UserId,
+	).Scan(&output.OldPhoneNumber)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE users SET phone_number = $1, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`,
+		input.PhoneNumber,
+		input.UserId,
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		err = ErrPhoneNumberTaken
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM phone_change_requests WHERE user_id = $1",
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	input.Audit.SubjectId = input.UserId
+	input.Audit.Changes = audit.Diff(
+		map[string]string{"phone_number": output.OldPhoneNumber},
+		map[string]string{"phone_number": input.PhoneNumber},
+	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+	if err = insertUserUpdatedEvent(ctx, tx, input.UserId, input.Audit.Changes); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+	output.PhoneNumber = input.PhoneNumber
+
+	return
+}
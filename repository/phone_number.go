@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/asrul10/UserService/audit"
+	"github.com/lib/pq"
+)
+
+// ListPhoneNumbers returns the phone numbers of all users as stored, in
+// batches ordered by id
+func (r *Repository) ListPhoneNumbers(ctx context.Context, input ListPhoneNumbersInput) (output ListPhoneNumbersOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, batchQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		"SELECT id, phone_number FROM users WHERE id > $1 ORDER BY id LIMIT $2",
+		input.AfterId,
+		input.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user UserPhoneNumber
+		if err = rows.Scan(&user.UserId, &user.PhoneNumber); err != nil {
+			return
+		}
+		output.Users = append(output.Users, user)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// NormalizePhoneNumber rewrites the phone number of a user to its
+// normalized form, only if it is still the one which was normalized.
+// sql.ErrNoRows is returned when it changed in the meantime and
+// ErrPhoneNumberTaken when another user has the normalized number. The
+// tokens are not revoked, the number is the same.
+func (r *Repository) NormalizePhoneNumber(ctx context.Context, input NormalizePhoneNumberInput) (output NormalizePhoneNumberOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE users SET phone_number = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND phone_number = $3",
+		input.PhoneNumber,
+		input.UserId,
+		input.OldPhoneNumber,
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		err = ErrPhoneNumberTaken
+		return
+	}
+	if err != nil {
+		return
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if updated == 0 {
+		err = sql.ErrNoRows
+		return
+	}
+
+	input.Audit.SubjectId = input.UserId
+	input.Audit.Changes = audit.Diff(
+		map[string]string{"phone_number": input.OldPhoneNumber},
+		map[string]string{"phone_number": input.PhoneNumber},
+	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+	if err = insertUserUpdatedEvent(ctx, tx, input.UserId, input.Audit.Changes); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+
+	return
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+)
+
+// ReplaceRecoveryCodes invalidates every previous code of the user and
+// stores the new ones.
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, input ReplaceRecoveryCodesInput) (output ReplaceRecoveryCodesOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM recovery_codes WHERE user_id = $1",
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	for _, codeHash := range input.CodeHashes {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)",
+			input.UserId,
+			codeHash,
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	output.Remaining = len(input.CodeHashes)
+
+	return
+}
+
+func (r *Repository) GetRecoveryCodes(ctx context.Context, input GetRecoveryCodesInput) (output GetRecoveryCodesOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		"SELECT id, code_hash FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL ORDER BY id",
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code RecoveryCode
+		if err = rows.Scan(&code.Id, &code.CodeHash); err != nil {
+			return
+		}
+		output.Codes = append(output.Codes, code)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// UseRecoveryCode marks the code as used. The update is conditional so a
+// code racing itself is only accepted once.
+func (r *Repository) UseRecoveryCode(ctx context.Context, input UseRecoveryCodeInput) (output UseRecoveryCodeOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	res, err := r.Db.ExecContext(
+		ctx,
+		"UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND used_at IS NULL",
+		input.Id,
+	)
+	if err != nil {
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	output.Accepted = affected == 1
+
+	return
+}
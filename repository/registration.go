@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/asrul10/UserService/audit"
+)
+
+// DeletePendingUser removes an unverified registration so the phone number
+// can be claimed again. Active users are never deleted.
+func (r *Repository) DeletePendingUser(ctx context.Context, input DeletePendingUserInput) (output DeletePendingUserOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	_, err = r.Db.ExecContext(
+		ctx,
+		"DELETE FROM users WHERE id = $1 AND status = $2",
+		input.UserId,
+		UserStatusPendingVerification,
+	)
+	if err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+
+	return
+}
+
+// UpsertRegistrationVerification replaces the code of a registration. The
+// attempts are kept, they are a budget of the whole registration rather
+// than of a code.
+func (r *Repository) UpsertRegistrationVerification(ctx context.Context, input UpsertRegistrationVerificationInput) (output UpsertRegistrationVerificationOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	_, err = r.Db.ExecContext(
+		ctx,
+		`INSERT INTO registration_verifications (user_id, code_hash, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash, expires_at = EXCLUDED.expires_at,
+			created_at = CURRENT_TIMESTAMP`,
+		input.UserId,
+		input.CodeHash,
+		input.ExpiresAt,
+	)
+	if err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+
+	return
+}
+
+func (r *Repository) GetRegistrationVerification(ctx context.Context, input GetRegistrationVerificationInput) (output GetRegistrationVerificationOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		"SELECT user_id, code_hash, attempts, expires_at, created_at FROM registration_verifications WHERE user_id = $1",
+		input.UserId,
+	).Scan(&output.UserId, &output.CodeHash, &output.Attempts, &output.ExpiresAt, &output.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// ReserveRegistrationVerificationAttempt counts an attempt before the code
+// is compared, so parallel guesses can't exceed MaxAttempts. sql.ErrNoRows
+// is returned when no attempt is left, the code expired or there is none.
+func (r *Repository) ReserveRegistrationVerificationAttempt(ctx context.Context, input ReserveRegistrationVerificationAttemptInput) (output ReserveRegistrationVerificationAttemptOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		`UPDATE registration_verifications SET attempts = attempts + 1
+		WHERE user_id = $1 AND attempts < $2 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING code_hash, attempts`,
+		input.UserId,
+		input.MaxAttempts,
+	).Scan(&output.CodeHash, &output.Attempts)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (r *Repository) ActivateUser(ctx context.Context, input ActivateUserInput) (output ActivateUserOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT status FROM users WHERE id = $1 FOR UPDATE",
+		input.UserId,
+	).Scan(&status)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE users SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		UserStatusActive,
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM registration_verifications WHERE user_id = $1",
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	input.Audit.SubjectId = input.UserId
+	input.Audit.Changes = audit.Diff(
+		map[string]string{"status": status},
+		map[string]string{"status": UserStatusActive},
+	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+	if err = insertUserUpdatedEvent(ctx, tx, input.UserId, input.Audit.Changes); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+	output.Status = UserStatusActive
+
+	return
+}
@@ -2,32 +2,178 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"log/slog"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/asrul10/UserService/tracing"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultMaxOpenConns    = 20
+	DefaultMaxIdleConns    = 10
+	DefaultConnMaxLifetime = time.Minute * 30
+	DefaultConnMaxIdleTime = time.Minute * 5
+	DefaultConnectRetries  = 5
+	DefaultConnectBackoff  = time.Millisecond * 500
+	DefaultReadTimeout     = time.Second * 5
+	DefaultWriteTimeout    = time.Second * 10
+	DefaultBatchTimeout    = time.Minute
+)
+
+// maxConnectBackoff caps the doubling of the backoff between two pings
+const maxConnectBackoff = time.Second * 10
+
+// queryClass picks the timeout of the statements of a method
+type queryClass int
+
+const (
+	// readQuery reads the rows of a request
+	readQuery queryClass = iota
+	// writeQuery changes rows in a request, usually in a transaction
+	writeQuery
+	// batchQuery is run by the background workers on many rows at once
+	batchQuery
 )
 
 type Repository struct {
-	Db *sql.DB
+	Db           *sql.DB
+	Logger       *slog.Logger
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	BatchTimeout time.Duration
 }
 
 type NewRepositoryOptions struct {
-	Dsn string
+	Dsn    string
+	Logger *slog.Logger
+	// Tracer records a span for every query, defaults to the tracer of
+	// the global provider
+	Tracer trace.Tracer
+	// MaxOpenConns defaults to DefaultMaxOpenConns
+	MaxOpenConns int
+	// MaxIdleConns defaults to DefaultMaxIdleConns
+	MaxIdleConns int
+	// ConnMaxLifetime defaults to DefaultConnMaxLifetime
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime defaults to DefaultConnMaxIdleTime
+	ConnMaxIdleTime time.Duration
+	// ConnectRetries is how many times the first ping is retried, the
+	// backoff between two pings starts at ConnectBackoff and doubles.
+	// They default to DefaultConnectRetries and DefaultConnectBackoff,
+	// a negative ConnectRetries disables the retries.
+	ConnectRetries int
+	ConnectBackoff time.Duration
+	// ReadTimeout, WriteTimeout and BatchTimeout bound the statements of
+	// every method by its query class, on top of the deadline of the
+	// context. They default to DefaultReadTimeout, DefaultWriteTimeout
+	// and DefaultBatchTimeout.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	BatchTimeout time.Duration
 }
 
 func NewRepository(opts NewRepositoryOptions) *Repository {
-	db, err := sql.Open("postgres", opts.Dsn)
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Tracer == nil {
+		opts.Tracer = otel.Tracer(tracing.TracerName)
+	}
+	if opts.MaxOpenConns <= 0 {
+		opts.MaxOpenConns = DefaultMaxOpenConns
+	}
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if opts.ConnMaxLifetime <= 0 {
+		opts.ConnMaxLifetime = DefaultConnMaxLifetime
+	}
+	if opts.ConnMaxIdleTime <= 0 {
+		opts.ConnMaxIdleTime = DefaultConnMaxIdleTime
+	}
+	if opts.ConnectRetries == 0 {
+		opts.ConnectRetries = DefaultConnectRetries
+	}
+	if opts.ConnectBackoff <= 0 {
+		opts.ConnectBackoff = DefaultConnectBackoff
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = DefaultReadTimeout
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = DefaultWriteTimeout
+	}
+	if opts.BatchTimeout <= 0 {
+		opts.BatchTimeout = DefaultBatchTimeout
+	}
+
+	connector, err := pq.NewConnector(opts.Dsn)
 	if err != nil {
 		panic(err)
 	}
+	db := sql.OpenDB(tracing.NewConnector(connector, opts.Tracer))
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
 
-	err = db.Ping()
-	if err != nil {
-		log.Panicln("Failed to connect to the database")
+	r := &Repository{
+		Db:           db,
+		Logger:       opts.Logger,
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
+		BatchTimeout: opts.BatchTimeout,
+	}
+
+	// The database may still be starting, the service reports not ready
+	// until it can be reached
+	if err := r.ping(opts.ConnectRetries, opts.ConnectBackoff); err != nil {
+		opts.Logger.Warn("Failed to connect to the database", "error", err)
+	}
+
+	return r
+}
+
+// ping pings the database until it answers or the retries are exhausted
+func (r *Repository) ping(retries int, backoff time.Duration) error {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := r.withTimeout(context.Background(), readQuery)
+		err := r.Db.PingContext(ctx)
+		cancel()
+		if err == nil || attempt >= retries {
+			return err
+		}
+		r.Logger.Info("Waiting for the database", "error", err, "attempt", attempt+1, "backoff", backoff.String())
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
 	}
+}
 
-	return &Repository{
-		Db: db,
+// withTimeout bounds ctx by the timeout of the query class, the deadline
+// of ctx is kept when it is earlier
+func (r *Repository) withTimeout(ctx context.Context, class queryClass) (context.Context, context.CancelFunc) {
+	var timeout time.Duration
+	switch class {
+	case readQuery:
+		timeout = r.ReadTimeout
+	case writeQuery:
+		timeout = r.WriteTimeout
+	case batchQuery:
+		timeout = r.BatchTimeout
 	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Close closes the connections of the pool, queries still running are
+// waited for
+func (r *Repository) Close() error {
+	return r.Db.Close()
 }
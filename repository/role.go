@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/asrul10/UserService/audit"
+	"github.com/lib/pq"
+)
+
+func (r *Repository) GetRoles(ctx context.Context, input GetRolesInput) (output GetRolesOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		"SELECT id, name, description, permissions FROM roles ORDER BY name",
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role Role
+		if err = rows.Scan(&role.Id, &role.Name, &role.Description, pq.Array(&role.Permissions)); err != nil {
+			return
+		}
+		output.Roles = append(output.Roles, role)
+	}
+	err = rows.Err()
+
+	return
+}
+
+func (r *Repository) GetUserRoles(ctx context.Context, input GetUserRolesInput) (output GetUserRolesOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`SELECT r.name, r.permissions FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1 ORDER BY r.name`,
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	seen := map[string]bool{}
+	for rows.Next() {
+		var name string
+		var permissions []string
+		if err = rows.Scan(&name, pq.Array(&permissions)); err != nil {
+			return
+		}
+		output.Roles = append(output.Roles, name)
+		for _, permission := range permissions {
+			if !seen[permission] {
+				seen[permission] = true
+				output.Permissions = append(output.Permissions, permission)
+			}
+		}
+	}
+	err = rows.Err()
+	sort.Strings(output.Permissions)
+
+	return
+}
+
+// SetUserRoles replaces the roles of the user. It returns sql.ErrNoRows for
+// an unknown user and ErrRoleNotFound for an unknown role.
+func (r *Repository) SetUserRoles(ctx context.Context, input SetUserRolesInput) (output SetUserRolesOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// Locking the user serializes concurrent changes of their roles
+	var userId int
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT id FROM users WHERE id = $1 FOR UPDATE",
+		input.UserId,
+	).Scan(&userId)
+	if err != nil {
+		return
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		"SELECT id, name FROM roles WHERE name = ANY($1) ORDER BY name",
+		pq.Array(input.Roles),
+	)
+	if err != nil {
+		return
+	}
+	var roleIds []int64
+	for rows.Next() {
+		var id int64
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return
+		}
+		roleIds = append(roleIds, id)
+		output.Roles = append(output.Roles, name)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+	if len(roleIds) != countDistinct(input.Roles) {
+		err = ErrRoleNotFound
+		return
+	}
+
+	var previousRoles string
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(string_agg(r.name, ',' ORDER BY r.name), '')
+		FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = $1`,
+		input.UserId,
+	).Scan(&previousRoles)
+	if err != nil {
+		return
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM user_roles WHERE user_id = $1 AND NOT (role_id = ANY($2))",
+		input.UserId,
+		pq.Array(roleIds),
+	)
+	if err != nil {
+		return
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO user_roles (user_id, role_id) SELECT $1, unnest($2::BIGINT[])
+		ON CONFLICT DO NOTHING`,
+		input.UserId,
+		pq.Array(roleIds),
+	)
+	if err != nil {
+		return
+	}
+
+	if removed > 0 {
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE users SET token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+			input.UserId,
+		)
+		if err != nil {
+			return
+		}
+		output.Revoked = true
+	}
+
+	input.Audit.SubjectId = input.UserId
+	input.Audit.Changes = audit.Diff(
+		map[string]string{"roles": previousRoles},
+		map[string]string{"roles": strings.Join(output.Roles, ",")},
+	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+
+	return
+}
+
+func countDistinct(values []string) int {
+	seen := map[string]bool{}
+	for _, value := range values {
+		seen[value] = true
+	}
+	return len(seen)
+}
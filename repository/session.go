@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+)
+
+func (r *Repository) CreateSession(ctx context.Context, input CreateSessionInput) (output CreateSessionOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		`INSERT INTO sessions (user_id, user_agent, ip_address, refresh_token_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		input.UserId,
+		input.UserAgent,
+		input.IpAddress,
+		input.RefreshTokenId,
+		input.ExpiresAt,
+	).Scan(&output.Id, &output.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (r *Repository) GetSession(ctx context.Context, input GetSessionInput) (output GetSessionOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	row := r.Db.QueryRowContext(
+		ctx,
+		`SELECT id, user_id, user_agent, ip_address, expires_at, revoked_at, last_seen_at, created_at
+		FROM sessions WHERE id = $1`,
+		input.Id,
+	)
+	err = scanSession(row, &output.Session)
+
+	return
+}
+
+// GetSessions returns the sessions of the user which are neither revoked
+// nor expired, most recently used first.
+func (r *Repository) GetSessions(ctx context.Context, input GetSessionsInput) (output GetSessionsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`SELECT id, user_id, user_agent, ip_address, expires_at, revoked_at, last_seen_at, created_at
+		FROM sessions WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY last_seen_at DESC`,
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session Session
+		if err = scanSession(rows, &session); err != nil {
+			return
+		}
+		output.Sessions = append(output.Sessions, session)
+	}
+	err = rows.Err()
+
+	return
+}
+
+func (r *Repository) TouchSession(ctx context.Context, input TouchSessionInput) (output TouchSessionOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	_, err = r.Db.ExecContext(
+		ctx,
+		"UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP WHERE id = $1",
+		input.Id,
+	)
+	if err != nil {
+		return
+	}
+
+	output.Id = input.Id
+
+	return
+}
+
+// RotateSessionRefreshToken replaces the refresh token of the session. Only
+// the latest refresh token is accepted, so a token can't be used twice.
+func (r *Repository) RotateSessionRefreshToken(ctx context.Context, input RotateSessionRefreshTokenInput) (output RotateSessionRefreshTokenOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	res, err := r.Db.ExecContext(
+		ctx,
+		`UPDATE sessions SET refresh_token_id = $1, expires_at = $2, last_seen_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND refresh_token_id = $4 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP`,
+		input.RefreshTokenId,
+		input.ExpiresAt,
+		input.Id,
+		input.PreviousRefreshTokenId,
+	)
+	if err != nil {
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	output.Accepted = affected == 1
+
+	return
+}
+
+func (r *Repository) RevokeSession(ctx context.Context, input RevokeSessionInput) (output RevokeSessionOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	res, err := r.Db.ExecContext(
+		ctx,
+		"UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		input.Id,
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	output.Revoked = affected == 1
+
+	return
+}
+
+func scanSession(row rowScanner, session *Session) error {
+	return row.Scan(
+		&session.Id,
+		&session.UserId,
+		&session.UserAgent,
+		&session.IpAddress,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.LastSeenAt,
+		&session.CreatedAt,
+	)
+}
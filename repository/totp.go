@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+)
+
+// UpsertTOTPSecret stores a new, not yet enabled, secret. Starting the
+// enrollment again replaces the previous secret.
+func (r *Repository) UpsertTOTPSecret(ctx context.Context, input UpsertTOTPSecretInput) (output UpsertTOTPSecretOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	_, err = r.Db.ExecContext(
+		ctx,
+		`INSERT INTO user_totp (user_id, secret_encrypted)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, enabled = FALSE,
+			last_used_step = 0, failed_attempts = 0, last_failed_at = NULL,
+			enabled_at = NULL, created_at = CURRENT_TIMESTAMP
+		WHERE user_totp.enabled = FALSE`,
+		input.UserId,
+		input.EncryptedSecret,
+	)
+	if err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+
+	return
+}
+
+func (r *Repository) GetTOTP(ctx context.Context, input GetTOTPInput) (output GetTOTPOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		`SELECT user_id, secret_encrypted, enabled, last_used_step, failed_attempts, last_failed_at
+		FROM user_totp WHERE user_id = $1`,
+		input.UserId,
+	).Scan(&output.UserId, &output.EncryptedSecret, &output.Enabled, &output.LastUsedStep, &output.FailedAttempts, &output.LastFailedAt)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// UseTOTPStep records the step of an accepted code and resets the failed
+// attempts. The update is conditional so a code racing itself is only
+// accepted once.
+func (r *Repository) UseTOTPStep(ctx context.Context, input UseTOTPStepInput) (output UseTOTPStepOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	res, err := r.Db.ExecContext(
+		ctx,
+		`UPDATE user_totp
+		SET last_used_step = $1, failed_attempts = 0, last_failed_at = NULL,
+			enabled = enabled OR $2,
+			enabled_at = CASE WHEN $2 AND NOT enabled THEN CURRENT_TIMESTAMP ELSE enabled_at END
+		WHERE user_id = $3 AND last_used_step < $1`,
+		input.Step,
+		input.Enable,
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	output.Accepted = affected == 1
+
+	return
+}
+
+// ReserveTOTPAttempt counts an attempt before the code is compared, so
+// parallel guesses can't exceed MaxAttempts. UseTOTPStep resets the count
+// when the code is accepted. sql.ErrNoRows is returned while the second
+// factor is locked, for LockDuration after the last of MaxAttempts.
+func (r *Repository) ReserveTOTPAttempt(ctx context.Context, input ReserveTOTPAttemptInput) (output ReserveTOTPAttemptOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		`UPDATE user_totp SET failed_attempts = failed_attempts + 1, last_failed_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND (failed_attempts < $2 OR last_failed_at IS NULL
+			OR last_failed_at <= CURRENT_TIMESTAMP - $3 * INTERVAL '1 microsecond')
+		RETURNING failed_attempts`,
+		input.UserId,
+		input.MaxAttempts,
+		input.LockDuration.Microseconds(),
+	).Scan(&output.FailedAttempts)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// DeleteTOTP disables two-factor authentication, the recovery codes are
+// removed along with the secret.
+func (r *Repository) DeleteTOTP(ctx context.Context, input DeleteTOTPInput) (output DeleteTOTPOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM user_totp WHERE user_id = $1",
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM recovery_codes WHERE user_id = $1",
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	output.UserId = input.UserId
+
+	return
+}
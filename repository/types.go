@@ -1,10 +1,97 @@
 // This file contains types that are used in the repository layer.
 package repository
 
+import (
+	"errors"
+	"time"
+
+	"github.com/asrul10/UserService/audit"
+	"github.com/asrul10/UserService/events"
+)
+
+var (
+	ErrPhoneNumberTaken         = errors.New("phone number already registered")
+	ErrWebAuthnCredentialExists = errors.New("webauthn credential already registered")
+	ErrRoleNotFound             = errors.New("role not found")
+)
+
+// Values of webauthn_challenges.ceremony
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+// Values of users.status
+const (
+	UserStatusActive              = "active"
+	UserStatusPendingVerification = "pending_verification"
+	UserStatusSuspended           = "suspended"
+)
+
+// Values of audit_log.action
+const (
+	AuditActionUserCreate         = "user.create"
+	AuditActionUserActivate       = "user.activate"
+	AuditActionUserUpdate         = "user.update"
+	AuditActionUserPhoneChange    = "user.phone_change"
+	AuditActionUserPhoneNormalize = "user.phone_normalize"
+	AuditActionUserPasswordChange = "user.password_change"
+	AuditActionUserLogin          = "user.login"
+	AuditActionUserList           = "user.list"
+	AuditActionUserView           = "user.view"
+	AuditActionUserSuspend        = "user.suspend"
+	AuditActionUserUnsuspend      = "user.unsuspend"
+	AuditActionUserPasswordReset  = "user.password_reset"
+	AuditActionUserSessionsRevoke = "user.sessions_revoke"
+	AuditActionUserRolesSet       = "user.roles_set"
+	AuditActionWebhookCreate      = "webhook.create"
+	AuditActionWebhookDelete      = "webhook.delete"
+	AuditActionWebhookReplay      = "webhook.delivery_replay"
+)
+
+// Values of webhook_deliveries.status
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// Values of login_events.method, the factor which completed or failed the
+// login
+const (
+	LoginMethodPassword     = "password"
+	LoginMethodOTP          = "otp"
+	LoginMethodPasskey      = "passkey"
+	LoginMethodTOTP         = "totp"
+	LoginMethodRecoveryCode = "recovery_code"
+)
+
+// Values of login_events.failure_reason
+const (
+	LoginFailureUserNotFound       = "user_not_found"
+	LoginFailureInvalidPassword    = "invalid_password"
+	LoginFailureAccountNotVerified = "account_not_verified"
+	LoginFailureAccountSuspended   = "account_suspended"
+	LoginFailurePasswordReset      = "password_reset_required"
+	LoginFailureInvalidCode        = "invalid_code"
+	LoginFailureCodeExpired        = "code_expired"
+	LoginFailureCodeReused         = "code_reused"
+	LoginFailureTooManyAttempts    = "too_many_attempts"
+	LoginFailureInvalidPasskey     = "invalid_passkey"
+	// The password was correct but the login needs a second step
+	LoginFailureMfaRequired    = "mfa_required"
+	LoginFailureStepUpRequired = "step_up_required"
+)
+
 type CreateUserInput struct {
 	PhoneNumber string
 	FullName    string
 	Password    string
+	// Status defaults to UserStatusActive
+	Status                string
+	VerificationCodeHash  string
+	VerificationExpiresAt time.Time
+	Audit                 AuditEntry
 }
 
 type CreateUserOutput struct {
@@ -16,8 +103,14 @@ type GetUserByPhoneNumberInput struct {
 }
 
 type GetUserByPhoneNumberOutput struct {
-	UserId   int
-	Password string
+	UserId       int
+	Password     string
+	TokenVersion int
+	Status       string
+	CreatedAt    time.Time
+	// MfaEnabled is set when a confirmed TOTP secret exists
+	MfaEnabled            bool
+	PasswordResetRequired bool
 }
 
 type GetUserByIdInput struct {
@@ -28,12 +121,14 @@ type GetUserByIdOutput struct {
 	UserId      int
 	FullName    string
 	PhoneNumber string
+	Password    string
 }
 
 type UpdateUserByIdInput struct {
 	UserId      int
 	FullName    string
 	PhoneNumber string
+	Audit       AuditEntry
 }
 
 type UpdateUserByIdOutput struct {
@@ -42,8 +137,21 @@ type UpdateUserByIdOutput struct {
 	PhoneNumber string
 }
 
+type UpdatePasswordByIdInput struct {
+	UserId   int
+	Password string
+	Audit    AuditEntry
+}
+
+type UpdatePasswordByIdOutput struct {
+	UserId int
+}
+
 type SuccessLoginCountInput struct {
 	UserId int
+	// Method is the factor which completed the login
+	Method string
+	Audit  AuditEntry
 }
 
 type SuccessLoginCountOutput struct {
@@ -58,3 +166,766 @@ type IsPhoneNumberChangedInput struct {
 type IsPhoneNumberChangedOutput struct {
 	IsChanged bool
 }
+
+type GetTokenVersionInput struct {
+	UserId int
+}
+
+type GetTokenVersionOutput struct {
+	TokenVersion int
+	Status       string
+}
+
+type CreatePhoneChangeRequestInput struct {
+	UserId      int
+	PhoneNumber string
+	CodeHash    string
+	ExpiresAt   time.Time
+}
+
+type CreatePhoneChangeRequestOutput struct {
+	UserId int
+}
+
+type GetPhoneChangeRequestInput struct {
+	UserId int
+}
+
+type GetPhoneChangeRequestOutput struct {
+	UserId      int
+	PhoneNumber string
+	CodeHash    string
+	Attempts    int
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+type ReservePhoneChangeAttemptInput struct {
+	UserId      int
+	MaxAttempts int
+}
+
+type ReservePhoneChangeAttemptOutput struct {
+	PhoneNumber string
+	CodeHash    string
+	Attempts    int
+}
+
+type ConfirmPhoneChangeInput struct {
+	UserId      int
+	PhoneNumber string
+	Audit       AuditEntry
+}
+
+type ConfirmPhoneChangeOutput struct {
+	UserId         int
+	OldPhoneNumber string
+	PhoneNumber    string
+}
+
+type ListPhoneNumbersInput struct {
+	// AfterId only returns users with a higher id, zero starts with the
+	// first user
+	AfterId int
+	Limit   int
+}
+
+type UserPhoneNumber struct {
+	UserId      int
+	PhoneNumber string
+}
+
+type ListPhoneNumbersOutput struct {
+	Users []UserPhoneNumber
+}
+
+type NormalizePhoneNumberInput struct {
+	UserId         int
+	OldPhoneNumber string
+	PhoneNumber    string
+	Audit          AuditEntry
+}
+
+type NormalizePhoneNumberOutput struct {
+	UserId int
+}
+
+type DeletePendingUserInput struct {
+	UserId int
+}
+
+type DeletePendingUserOutput struct {
+	UserId int
+}
+
+type UpsertRegistrationVerificationInput struct {
+	UserId    int
+	CodeHash  string
+	ExpiresAt time.Time
+}
+
+type UpsertRegistrationVerificationOutput struct {
+	UserId int
+}
+
+type GetRegistrationVerificationInput struct {
+	UserId int
+}
+
+type GetRegistrationVerificationOutput struct {
+	UserId    int
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type ReserveRegistrationVerificationAttemptInput struct {
+	UserId      int
+	MaxAttempts int
+}
+
+type ReserveRegistrationVerificationAttemptOutput struct {
+	CodeHash string
+	Attempts int
+}
+
+type ActivateUserInput struct {
+	UserId int
+	Audit  AuditEntry
+}
+
+type ActivateUserOutput struct {
+	UserId int
+	Status string
+}
+
+type UpsertTOTPSecretInput struct {
+	UserId          int
+	EncryptedSecret string
+}
+
+type UpsertTOTPSecretOutput struct {
+	UserId int
+}
+
+type GetTOTPInput struct {
+	UserId int
+}
+
+type GetTOTPOutput struct {
+	UserId          int
+	EncryptedSecret string
+	Enabled         bool
+	LastUsedStep    int64
+	FailedAttempts  int
+	LastFailedAt    *time.Time
+}
+
+type UseTOTPStepInput struct {
+	UserId int
+	Step   int64
+	// Enable confirms the enrollment along with the first code
+	Enable bool
+}
+
+type UseTOTPStepOutput struct {
+	// Accepted is false when the step, or a later one, was already used
+	Accepted bool
+}
+
+type ReserveTOTPAttemptInput struct {
+	UserId      int
+	MaxAttempts int
+	// LockDuration is how long the second factor is refused once
+	// MaxAttempts are counted
+	LockDuration time.Duration
+}
+
+type ReserveTOTPAttemptOutput struct {
+	FailedAttempts int
+}
+
+type DeleteTOTPInput struct {
+	UserId int
+}
+
+type DeleteTOTPOutput struct {
+	UserId int
+}
+
+type ReplaceRecoveryCodesInput struct {
+	UserId     int
+	CodeHashes []string
+}
+
+type ReplaceRecoveryCodesOutput struct {
+	Remaining int
+}
+
+type GetRecoveryCodesInput struct {
+	UserId int
+}
+
+type RecoveryCode struct {
+	Id       int
+	CodeHash string
+}
+
+type GetRecoveryCodesOutput struct {
+	// Codes only holds the unused codes
+	Codes []RecoveryCode
+}
+
+type UseRecoveryCodeInput struct {
+	Id int
+}
+
+type UseRecoveryCodeOutput struct {
+	// Accepted is false when the code was used in the meantime
+	Accepted bool
+}
+
+type UpsertLoginCodeInput struct {
+	UserId    int
+	CodeHash  string
+	ExpiresAt time.Time
+}
+
+type UpsertLoginCodeOutput struct {
+	UserId int
+}
+
+type GetLoginCodeInput struct {
+	UserId int
+}
+
+type GetLoginCodeOutput struct {
+	UserId    int
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type ReserveLoginCodeAttemptInput struct {
+	UserId      int
+	MaxAttempts int
+}
+
+type ReserveLoginCodeAttemptOutput struct {
+	CodeHash string
+	Attempts int
+}
+
+type ConsumeLoginCodeInput struct {
+	UserId   int
+	CodeHash string
+}
+
+type ConsumeLoginCodeOutput struct {
+	// Accepted is false when the code was used or replaced in the meantime
+	Accepted bool
+}
+
+type CreateWebAuthnChallengeInput struct {
+	Challenge string
+	// UserId is zero for a login with a discoverable credential
+	UserId    int
+	Ceremony  string
+	ExpiresAt time.Time
+}
+
+type CreateWebAuthnChallengeOutput struct {
+	Challenge string
+}
+
+type ConsumeWebAuthnChallengeInput struct {
+	Challenge string
+}
+
+type ConsumeWebAuthnChallengeOutput struct {
+	UserId    int
+	Ceremony  string
+	ExpiresAt time.Time
+}
+
+type WebAuthnCredential struct {
+	Id           int
+	UserId       int
+	CredentialId []byte
+	PublicKey    []byte
+	SignCount    int64
+	AAGUID       []byte
+	Name         string
+	LastUsedAt   *time.Time
+	CreatedAt    time.Time
+}
+
+type CreateWebAuthnCredentialInput struct {
+	UserId       int
+	CredentialId []byte
+	PublicKey    []byte
+	SignCount    int64
+	AAGUID       []byte
+	Name         string
+}
+
+type CreateWebAuthnCredentialOutput struct {
+	Id        int
+	CreatedAt time.Time
+}
+
+type GetWebAuthnCredentialsInput struct {
+	UserId int
+}
+
+type GetWebAuthnCredentialsOutput struct {
+	Credentials []WebAuthnCredential
+}
+
+type GetWebAuthnCredentialInput struct {
+	CredentialId []byte
+}
+
+type GetWebAuthnCredentialOutput struct {
+	Credential WebAuthnCredential
+}
+
+type UpdateWebAuthnSignCountInput struct {
+	Id int
+	// PreviousSignCount is the value the new count was checked against
+	PreviousSignCount int64
+	SignCount         int64
+}
+
+type UpdateWebAuthnSignCountOutput struct {
+	// Accepted is false when another login used the credential in the
+	// meantime
+	Accepted bool
+}
+
+type Session struct {
+	Id         int
+	UserId     int
+	UserAgent  string
+	IpAddress  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	LastSeenAt time.Time
+	CreatedAt  time.Time
+}
+
+type CreateSessionInput struct {
+	UserId         int
+	UserAgent      string
+	IpAddress      string
+	RefreshTokenId string
+	ExpiresAt      time.Time
+}
+
+type CreateSessionOutput struct {
+	Id        int
+	CreatedAt time.Time
+}
+
+type GetSessionInput struct {
+	Id int
+}
+
+type GetSessionOutput struct {
+	Session Session
+}
+
+type GetSessionsInput struct {
+	UserId int
+}
+
+type GetSessionsOutput struct {
+	Sessions []Session
+}
+
+type TouchSessionInput struct {
+	Id int
+}
+
+type TouchSessionOutput struct {
+	Id int
+}
+
+type RotateSessionRefreshTokenInput struct {
+	Id                     int
+	PreviousRefreshTokenId string
+	RefreshTokenId         string
+	ExpiresAt              time.Time
+}
+
+type RotateSessionRefreshTokenOutput struct {
+	// Accepted is false when the previous refresh token is not the latest
+	// of the session, or the session was revoked or expired
+	Accepted bool
+}
+
+type RevokeSessionInput struct {
+	Id     int
+	UserId int
+}
+
+type RevokeSessionOutput struct {
+	// Revoked is false when the session does not exist, belongs to another
+	// user or was already revoked
+	Revoked bool
+}
+
+type LoginEvent struct {
+	Id            int
+	UserId        int
+	Method        string
+	Success       bool
+	FailureReason string
+	IpAddress     string
+	UserAgent     string
+	// RiskDecision is empty when the login was not assessed
+	RiskDecision string
+	RiskReasons  []string
+	CreatedAt    time.Time
+}
+
+type CreateLoginEventInput struct {
+	// UserId is zero for attempts on unknown phone numbers
+	UserId        int
+	Method        string
+	Success       bool
+	FailureReason string
+	IpAddress     string
+	UserAgent     string
+	RiskDecision  string
+	RiskReasons   []string
+}
+
+type CreateLoginEventOutput struct {
+	Id int
+}
+
+type GetLoginEventsInput struct {
+	UserId int
+	// BeforeId only returns events older than the event, zero starts with
+	// the latest event
+	BeforeId int
+	Limit    int
+}
+
+type GetLoginEventsOutput struct {
+	Events []LoginEvent
+}
+
+type DeleteLoginEventsInput struct {
+	// Before deletes the events created before the time
+	Before time.Time
+	// Limit is the maximum number of events deleted at once
+	Limit int
+}
+
+type DeleteLoginEventsOutput struct {
+	Deleted int64
+}
+
+type Role struct {
+	Id          int
+	Name        string
+	Description string
+	Permissions []string
+}
+
+type GetRolesInput struct{}
+
+type GetRolesOutput struct {
+	Roles []Role
+}
+
+type GetUserRolesInput struct {
+	UserId int
+}
+
+type GetUserRolesOutput struct {
+	Roles []string
+	// Permissions of all the roles, without duplicates
+	Permissions []string
+}
+
+type SetUserRolesInput struct {
+	UserId int
+	// Roles replace the current roles of the user
+	Roles []string
+	Audit AuditEntry
+}
+
+type SetUserRolesOutput struct {
+	Roles []string
+	// Revoked is true when a role was taken away, the tokens of the user
+	// are revoked so the permissions can't be used anymore
+	Revoked bool
+}
+
+// AuditEntry records who changed what. The repository methods taking one
+// write it in the transaction of the change.
+type AuditEntry struct {
+	// ActorId is zero for anonymous requests, like registrations
+	ActorId int
+	// SubjectId is the user acted on, zero for actions on no particular
+	// user. Methods changing a user fill it in.
+	SubjectId int
+	Action    string
+	// Changes are filled in by the methods changing a user
+	Changes   []audit.Change
+	RequestId string
+	IpAddress string
+}
+
+type CreateAuditEntryOutput struct {
+	Id int
+}
+
+type GetAuditRecordsInput struct {
+	// AfterId only returns records with a higher id, zero starts with the
+	// first record
+	AfterId int
+	Limit   int
+}
+
+type GetAuditRecordsOutput struct {
+	Records []audit.Record
+}
+
+// User is the account as seen by support staff
+type User struct {
+	Id                    int
+	PhoneNumber           string
+	FullName              string
+	Status                string
+	MfaEnabled            bool
+	PasswordResetRequired bool
+	SuccessLoginCount     int
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+type ListUsersInput struct {
+	// Filters, empty values match every user
+	PhoneNumberPrefix string
+	// Name matches a part of the full name, case insensitive
+	Name        string
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// BeforeId only returns users with a lower id, zero starts with the
+	// latest user
+	BeforeId int
+	Limit    int
+}
+
+type ListUsersOutput struct {
+	Users []User
+}
+
+type GetUserDetailsInput struct {
+	UserId int
+}
+
+type GetUserDetailsOutput struct {
+	User User
+}
+
+type SuspendUserInput struct {
+	UserId int
+	Audit  AuditEntry
+}
+
+type SuspendUserOutput struct {
+	// Suspended is false when the user is not active
+	Suspended bool
+}
+
+type UnsuspendUserInput struct {
+	UserId int
+	Audit  AuditEntry
+}
+
+type UnsuspendUserOutput struct {
+	// Unsuspended is false when the user is not suspended
+	Unsuspended bool
+}
+
+type ForcePasswordResetInput struct {
+	UserId int
+	Audit  AuditEntry
+}
+
+type ForcePasswordResetOutput struct {
+	PhoneNumber string
+}
+
+type RevokeUserSessionsInput struct {
+	UserId int
+	Audit  AuditEntry
+}
+
+type RevokeUserSessionsOutput struct {
+	Revoked int64
+}
+
+type OutboxEvent struct {
+	Event events.Event
+	// Attempts counts the deliveries started, including this one
+	Attempts int
+}
+
+type ClaimOutboxEventsInput struct {
+	// Lease is how long the events are hidden from other relays
+	Lease time.Duration
+	Limit int
+}
+
+type ClaimOutboxEventsOutput struct {
+	Events []OutboxEvent
+}
+
+type DeleteOutboxEventInput struct {
+	Id int
+}
+
+type DeleteOutboxEventOutput struct {
+	Id int
+}
+
+type RetryOutboxEventInput struct {
+	Id            int
+	Error         string
+	NextAttemptAt time.Time
+}
+
+type RetryOutboxEventOutput struct {
+	Id int
+}
+
+type WebhookSubscription struct {
+	Id              int
+	URL             string
+	EventTypes      []string
+	EncryptedSecret string
+	CreatedAt       time.Time
+}
+
+type CreateWebhookSubscriptionInput struct {
+	URL             string
+	EventTypes      []string
+	EncryptedSecret string
+	Audit           AuditEntry
+}
+
+type CreateWebhookSubscriptionOutput struct {
+	Subscription WebhookSubscription
+}
+
+type GetWebhookSubscriptionsInput struct{}
+
+type GetWebhookSubscriptionsOutput struct {
+	Subscriptions []WebhookSubscription
+}
+
+type DeleteWebhookSubscriptionInput struct {
+	Id    int
+	Audit AuditEntry
+}
+
+type DeleteWebhookSubscriptionOutput struct {
+	Id int
+}
+
+type WebhookDelivery struct {
+	Id             int
+	SubscriptionId int
+	EventId        int
+	EventType      string
+	// Payload is the event as delivered
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+	// URL and EncryptedSecret of the subscription, only set on claimed
+	// deliveries
+	URL             string
+	EncryptedSecret string
+}
+
+type CreateWebhookDeliveriesInput struct {
+	Event events.Event
+}
+
+type CreateWebhookDeliveriesOutput struct {
+	// Created is the number of subscriptions the event is delivered to
+	Created int64
+}
+
+type ClaimWebhookDeliveriesInput struct {
+	// Lease is how long the deliveries are hidden from other dispatchers
+	Lease time.Duration
+	Limit int
+}
+
+type ClaimWebhookDeliveriesOutput struct {
+	Deliveries []WebhookDelivery
+}
+
+type RecordWebhookDeliveryInput struct {
+	Id int
+	// Status is WebhookDeliveryPending to retry at NextAttemptAt
+	Status        string
+	StatusCode    int
+	Error         string
+	NextAttemptAt time.Time
+}
+
+type RecordWebhookDeliveryOutput struct {
+	Id int
+}
+
+type GetWebhookDeliveriesInput struct {
+	SubscriptionId int
+	// Status filters the deliveries, empty matches every delivery
+	Status string
+	// BeforeId only returns deliveries with a lower id, zero starts with
+	// the latest delivery
+	BeforeId int
+	Limit    int
+}
+
+type GetWebhookDeliveriesOutput struct {
+	Deliveries []WebhookDelivery
+}
+
+type ReplayWebhookDeliveryInput struct {
+	Id    int
+	Audit AuditEntry
+}
+
+type ReplayWebhookDeliveryOutput struct {
+	// Replayed is false when the delivery is still pending
+	Replayed bool
+}
+
+type PingDatabaseInput struct{}
+
+type PingDatabaseOutput struct {
+	Latency time.Duration
+}
+
+type GetSchemaVersionInput struct{}
+
+type GetSchemaVersionOutput struct {
+	Version int
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) CreateWebAuthnChallenge(ctx context.Context, input CreateWebAuthnChallengeInput) (output CreateWebAuthnChallengeOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	userId := sql.NullInt64{Int64: int64(input.UserId), Valid: input.UserId != 0}
+	_, err = r.Db.ExecContext(
+		ctx,
+		"INSERT INTO webauthn_challenges (challenge, user_id, ceremony, expires_at) VALUES ($1, $2, $3, $4)",
+		input.Challenge,
+		userId,
+		input.Ceremony,
+		input.ExpiresAt,
+	)
+	if err != nil {
+		return
+	}
+
+	output.Challenge = input.Challenge
+
+	return
+}
+
+// ConsumeWebAuthnChallenge deletes the challenge and returns the ceremony
+// it was issued for. A challenge can only be consumed once.
+func (r *Repository) ConsumeWebAuthnChallenge(ctx context.Context, input ConsumeWebAuthnChallengeInput) (output ConsumeWebAuthnChallengeOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	var userId sql.NullInt64
+	err = r.Db.QueryRowContext(
+		ctx,
+		"DELETE FROM webauthn_challenges WHERE challenge = $1 RETURNING user_id, ceremony, expires_at",
+		input.Challenge,
+	).Scan(&userId, &output.Ceremony, &output.ExpiresAt)
+	if err != nil {
+		return
+	}
+
+	output.UserId = int(userId.Int64)
+
+	return
+}
+
+func (r *Repository) CreateWebAuthnCredential(ctx context.Context, input CreateWebAuthnCredentialInput) (output CreateWebAuthnCredentialOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	err = r.Db.QueryRowContext(
+		ctx,
+		`INSERT INTO webauthn_credentials (user_id, credential_id, public_key, sign_count, aaguid, name)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		input.UserId,
+		input.CredentialId,
+		input.PublicKey,
+		input.SignCount,
+		input.AAGUID,
+		input.Name,
+	).Scan(&output.Id, &output.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		err = ErrWebAuthnCredentialExists
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (r *Repository) GetWebAuthnCredentials(ctx context.Context, input GetWebAuthnCredentialsInput) (output GetWebAuthnCredentialsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`SELECT id, user_id, credential_id, public_key, sign_count, aaguid, name, last_used_at, created_at
+		FROM webauthn_credentials WHERE user_id = $1 ORDER BY id`,
+		input.UserId,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var credential WebAuthnCredential
+		if err = scanWebAuthnCredential(rows, &credential); err != nil {
+			return
+		}
+		output.Credentials = append(output.Credentials, credential)
+	}
+	err = rows.Err()
+
+	return
+}
+
+func (r *Repository) GetWebAuthnCredential(ctx context.Context, input GetWebAuthnCredentialInput) (output GetWebAuthnCredentialOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	row := r.Db.QueryRowContext(
+		ctx,
+		`SELECT id, user_id, credential_id, public_key, sign_count, aaguid, name, last_used_at, created_at
+		FROM webauthn_credentials WHERE credential_id = $1`,
+		input.CredentialId,
+	)
+	err = scanWebAuthnCredential(row, &output.Credential)
+
+	return
+}
+
+// UpdateWebAuthnSignCount stores the counter of the latest assertion. The
+// update only applies if the counter was not changed since it was read, so
+// two logins racing with the same counter can't both succeed.
+func (r *Repository) UpdateWebAuthnSignCount(ctx context.Context, input UpdateWebAuthnSignCountInput) (output UpdateWebAuthnSignCountOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	res, err := r.Db.ExecContext(
+		ctx,
+		`UPDATE webauthn_credentials SET sign_count = $1, last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND sign_count = $3`,
+		input.SignCount,
+		input.Id,
+		input.PreviousSignCount,
+	)
+	if err != nil {
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	output.Accepted = affected == 1
+
+	return
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebAuthnCredential(row rowScanner, credential *WebAuthnCredential) error {
+	return row.Scan(
+		&credential.Id,
+		&credential.UserId,
+		&credential.CredentialId,
+		&credential.PublicKey,
+		&credential.SignCount,
+		&credential.AAGUID,
+		&credential.Name,
+		&credential.LastUsedAt,
+		&credential.CreatedAt,
+	)
+}
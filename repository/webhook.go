@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asrul10/UserService/audit"
+	"github.com/lib/pq"
+)
+
+const webhookDeliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+func scanWebhookDelivery(row rowScanner, delivery *WebhookDelivery, extra ...interface{}) error {
+	var deliveredAt sql.NullTime
+	dest := []interface{}{
+		&delivery.Id,
+		&delivery.SubscriptionId,
+		&delivery.EventId,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&deliveredAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return nil
+}
+
+func (r *Repository) CreateWebhookSubscription(ctx context.Context, input CreateWebhookSubscriptionInput) (output CreateWebhookSubscriptionOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO webhook_subscriptions (url, event_types, encrypted_secret) VALUES ($1, $2, $3)
+		RETURNING id, created_at`,
+		input.URL,
+		pq.Array(input.EventTypes),
+		input.EncryptedSecret,
+	).Scan(&output.Subscription.Id, &output.Subscription.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	input.Audit.Changes = audit.Diff(nil, map[string]string{
+		"webhook_id":  strconv.Itoa(output.Subscription.Id),
+		"url":         input.URL,
+		"event_types": strings.Join(input.EventTypes, ","),
+	})
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	output.Subscription.URL = input.URL
+	output.Subscription.EventTypes = input.EventTypes
+	output.Subscription.EncryptedSecret = input.EncryptedSecret
+
+	return
+}
+
+func (r *Repository) GetWebhookSubscriptions(ctx context.Context, input GetWebhookSubscriptionsInput) (output GetWebhookSubscriptionsOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		"SELECT id, url, event_types, encrypted_secret, created_at FROM webhook_subscriptions ORDER BY id",
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var subscription WebhookSubscription
+		err = rows.Scan(
+			&subscription.Id,
+			&subscription.URL,
+			pq.Array(&subscription.EventTypes),
+			&subscription.EncryptedSecret,
+			&subscription.CreatedAt,
+		)
+		if err != nil {
+			return
+		}
+		output.Subscriptions = append(output.Subscriptions, subscription)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// DeleteWebhookSubscription deletes the subscription with its deliveries.
+// It returns sql.ErrNoRows for an unknown subscription.
+func (r *Repository) DeleteWebhookSubscription(ctx context.Context, input DeleteWebhookSubscriptionInput) (output DeleteWebhookSubscriptionOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var url string
+	var eventTypes []string
+	err = tx.QueryRowContext(
+		ctx,
+		"DELETE FROM webhook_subscriptions WHERE id = $1 RETURNING url, event_types",
+		input.Id,
+	).Scan(&url, pq.Array(&eventTypes))
+	if err != nil {
+		return
+	}
+
+	input.Audit.Changes = audit.Diff(map[string]string{
+		"webhook_id":  strconv.Itoa(input.Id),
+		"url":         url,
+		"event_types": strings.Join(eventTypes, ","),
+	}, nil)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	output.Id = input.Id
+
+	return
+}
+
+// CreateWebhookDeliveries queues the event for every subscription to its
+// type. An event published again is not queued twice.
+func (r *Repository) CreateWebhookDeliveries(ctx context.Context, input CreateWebhookDeliveriesInput) (output CreateWebhookDeliveriesOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	payload, err := json.Marshal(input.Event)
+	if err != nil {
+		return
+	}
+
+	res, err := r.Db.ExecContext(
+		ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3 FROM webhook_subscriptions WHERE $2 = ANY(event_types)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+		input.Event.Id,
+		input.Event.Type,
+		payload,
+	)
+	if err != nil {
+		return
+	}
+
+	output.Created, err = res.RowsAffected()
+
+	return
+}
+
+// ClaimWebhookDeliveries returns the pending deliveries due, oldest first,
+// and hides them from other dispatchers until the lease ends.
+func (r *Repository) ClaimWebhookDeliveries(ctx context.Context, input ClaimWebhookDeliveriesInput) (output ClaimWebhookDeliveriesOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, batchQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`UPDATE webhook_deliveries d
+		SET next_attempt_at = CURRENT_TIMESTAMP + $1 * INTERVAL '1 microsecond', attempts = d.attempts + 1
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT id FROM webhook_deliveries WHERE status = $2 AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY id LIMIT $3 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns+`, s.url, s.encrypted_secret`,
+		input.Lease.Microseconds(),
+		WebhookDeliveryPending,
+		input.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery WebhookDelivery
+		if err = scanWebhookDelivery(rows, &delivery, &delivery.URL, &delivery.EncryptedSecret); err != nil {
+			return
+		}
+		output.Deliveries = append(output.Deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	// RETURNING doesn't keep the order of the subquery
+	sort.Slice(output.Deliveries, func(i, j int) bool {
+		return output.Deliveries[i].Id < output.Deliveries[j].Id
+	})
+
+	return
+}
+
+// RecordWebhookDelivery records the result of an attempt
+func (r *Repository) RecordWebhookDelivery(ctx context.Context, input RecordWebhookDeliveryInput) (output RecordWebhookDeliveryOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	_, err = r.Db.ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries SET status = $1, last_status_code = $2, last_error = $3, next_attempt_at = $4,
+		delivered_at = CASE WHEN $1 = 'delivered' THEN CURRENT_TIMESTAMP ELSE delivered_at END
+		WHERE id = $5`,
+		input.Status,
+		input.StatusCode,
+		input.Error,
+		input.NextAttemptAt,
+		input.Id,
+	)
+	if err != nil {
+		return
+	}
+
+	output.Id = input.Id
+
+	return
+}
+
+// GetWebhookDeliveries returns the deliveries of a subscription, latest
+// first
+func (r *Repository) GetWebhookDeliveries(ctx context.Context, input GetWebhookDeliveriesInput) (output GetWebhookDeliveriesOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, readQuery)
+	defer cancel()
+
+	rows, err := r.Db.QueryContext(
+		ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries d
+		WHERE d.subscription_id = $1 AND ($2 = '' OR d.status = $2) AND ($3 = 0 OR d.id < $3)
+		ORDER BY d.id DESC LIMIT $4`,
+		input.SubscriptionId,
+		input.Status,
+		input.BeforeId,
+		input.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery WebhookDelivery
+		if err = scanWebhookDelivery(rows, &delivery); err != nil {
+			return
+		}
+		output.Deliveries = append(output.Deliveries, delivery)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// ReplayWebhookDelivery delivers a dead or delivered event again, with a
+// fresh set of attempts. It returns sql.ErrNoRows for an unknown delivery.
+func (r *Repository) ReplayWebhookDelivery(ctx context.Context, input ReplayWebhookDeliveryInput) (output ReplayWebhookDeliveryOutput, err error) {
+	ctx, cancel := r.withTimeout(ctx, writeQuery)
+	defer cancel()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT status FROM webhook_deliveries WHERE id = $1 FOR UPDATE",
+		input.Id,
+	).Scan(&status)
+	if err != nil || status == WebhookDeliveryPending {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id = $2`,
+		WebhookDeliveryPending,
+		input.Id,
+	)
+	if err != nil {
+		return
+	}
+
+	// Deliveries are not users, the unchanged id identifies the delivery
+	id := strconv.Itoa(input.Id)
+	input.Audit.Changes = append(
+		[]audit.Change{{Field: "webhook_delivery_id", Before: id, After: id}},
+		audit.Diff(map[string]string{"status": status}, map[string]string{"status": WebhookDeliveryPending})...,
+	)
+	if _, err = insertAuditEntry(ctx, tx, input.Audit); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	output.Replayed = true
+
+	return
+}
@@ -0,0 +1,52 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/asrul10/UserService/repository"
+)
+
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := p.Prune(ctx)
+		if err != nil {
+			p.Logger.ErrorContext(ctx, "Failed to prune login events", "error", err)
+		} else if deleted > 0 {
+			p.Logger.InfoContext(ctx, "Pruned login events", "deleted", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune deletes the login events older than the retention period in
+// batches, until no expired event is left.
+func (p *Pruner) Prune(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-p.LoginEventRetention)
+
+	var total int64
+	for {
+		resp, err := p.Repository.DeleteLoginEvents(ctx, repository.DeleteLoginEventsInput{
+			Before: before,
+			Limit:  p.BatchSize,
+		})
+		if err != nil {
+			return total, err
+		}
+		total += resp.Deleted
+		if resp.Deleted < int64(p.BatchSize) {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
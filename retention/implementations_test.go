@@ -0,0 +1,66 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+func TestPrune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	p := NewPruner(NewPrunerOptions{
+		Repository:          m,
+		LoginEventRetention: time.Hour,
+		BatchSize:           2,
+	})
+
+	// Full batches are repeated until a partial one
+	gomock.InOrder(
+		m.
+			EXPECT().
+			DeleteLoginEvents(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input repository.DeleteLoginEventsInput) (repository.DeleteLoginEventsOutput, error) {
+				if time.Since(input.Before) < time.Hour || time.Since(input.Before) > time.Hour+time.Minute {
+					t.Errorf("Expected events older than an hour, got %v", input.Before)
+				}
+				if input.Limit != 2 {
+					t.Errorf("Expected batch size 2, got %d", input.Limit)
+				}
+				return repository.DeleteLoginEventsOutput{Deleted: 2}, nil
+			}),
+		m.
+			EXPECT().
+			DeleteLoginEvents(gomock.Any(), gomock.Any()).
+			Return(repository.DeleteLoginEventsOutput{Deleted: 1}, nil),
+	)
+
+	deleted, err := p.Prune(context.Background())
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 deleted, got %d", deleted)
+	}
+
+	m.
+		EXPECT().
+		DeleteLoginEvents(gomock.Any(), gomock.Any()).
+		Return(repository.DeleteLoginEventsOutput{}, errors.New("connection refused"))
+	if _, err := p.Prune(context.Background()); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestNewPrunerDefaults(t *testing.T) {
+	p := NewPruner(NewPrunerOptions{})
+	if p.LoginEventRetention != DefaultLoginEventRetention || p.Interval != DefaultInterval || p.BatchSize != DefaultBatchSize {
+		t.Errorf("Expected defaults, got %v", p)
+	}
+}
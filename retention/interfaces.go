@@ -0,0 +1,13 @@
+// This file contains the interfaces for the retention layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package retention
+
+import "context"
+
+type PrunerInterface interface {
+	// Run prunes every interval until the context is done
+	Run(ctx context.Context)
+	// Prune deletes the expired records once and returns how many
+	Prune(ctx context.Context) (int64, error)
+}
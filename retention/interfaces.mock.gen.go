@@ -0,0 +1,62 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: retention/interfaces.go
+
+// Package retention is a generated GoMock package.
+package retention
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPrunerInterface is a mock of PrunerInterface interface.
+type MockPrunerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrunerInterfaceMockRecorder
+}
+
+// MockPrunerInterfaceMockRecorder is the mock recorder for MockPrunerInterface.
+type MockPrunerInterfaceMockRecorder struct {
+	mock *MockPrunerInterface
+}
+
+// NewMockPrunerInterface creates a new mock instance.
+func NewMockPrunerInterface(ctrl *gomock.Controller) *MockPrunerInterface {
+	mock := &MockPrunerInterface{ctrl: ctrl}
+	mock.recorder = &MockPrunerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrunerInterface) EXPECT() *MockPrunerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Prune mocks base method.
+func (m *MockPrunerInterface) Prune(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prune", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prune indicates an expected call of Prune.
+func (mr *MockPrunerInterfaceMockRecorder) Prune(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockPrunerInterface)(nil).Prune), ctx)
+}
+
+// Run mocks base method.
+func (m *MockPrunerInterface) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockPrunerInterfaceMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockPrunerInterface)(nil).Run), ctx)
+}
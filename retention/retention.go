@@ -0,0 +1,60 @@
+// This file contains the retention job, it prunes records which are kept
+// only for a limited time, like the login history.
+package retention
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/asrul10/UserService/repository"
+)
+
+const (
+	DefaultLoginEventRetention = time.Hour * 24 * 90
+	DefaultInterval            = time.Hour
+	DefaultBatchSize           = 1000
+)
+
+type Pruner struct {
+	Repository          repository.RepositoryInterface
+	Logger              *slog.Logger
+	LoginEventRetention time.Duration
+	Interval            time.Duration
+	BatchSize           int
+}
+
+type NewPrunerOptions struct {
+	Repository repository.RepositoryInterface
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+	// LoginEventRetention defaults to DefaultLoginEventRetention
+	LoginEventRetention time.Duration
+	// Interval between two runs, defaults to DefaultInterval
+	Interval time.Duration
+	// BatchSize is the number of rows deleted per statement, defaults to
+	// DefaultBatchSize
+	BatchSize int
+}
+
+func NewPruner(opts NewPrunerOptions) *Pruner {
+	p := &Pruner{
+		Repository:          opts.Repository,
+		Logger:              opts.Logger,
+		LoginEventRetention: opts.LoginEventRetention,
+		Interval:            opts.Interval,
+		BatchSize:           opts.BatchSize,
+	}
+	if p.Logger == nil {
+		p.Logger = slog.Default()
+	}
+	if p.LoginEventRetention <= 0 {
+		p.LoginEventRetention = DefaultLoginEventRetention
+	}
+	if p.Interval <= 0 {
+		p.Interval = DefaultInterval
+	}
+	if p.BatchSize <= 0 {
+		p.BatchSize = DefaultBatchSize
+	}
+	return p
+}
@@ -0,0 +1,150 @@
+package risk
+
+import (
+	"math"
+	"net/netip"
+	"strings"
+
+	"github.com/asrul10/UserService/geoip"
+)
+
+const (
+	// Addresses in the same prefix are considered the same network
+	ipv4NetworkBits = 24
+	ipv6NetworkBits = 48
+
+	earthRadius = 6371.0
+)
+
+func (a *Assessor) Assess(input AssessInput) Assessment {
+	assessment := Assessment{Decision: DecisionAllow}
+	current := input.Current
+
+	var successes []Attempt
+	failures := 0
+	for _, attempt := range input.History {
+		if attempt.Success {
+			successes = append(successes, attempt)
+		} else if attempt.At.After(current.At.Add(-a.FailureBurstWindow)) {
+			failures++
+		}
+	}
+
+	if a.GeoIP != nil {
+		if location, ok := a.GeoIP.Lookup(current.IpAddress); ok {
+			assessment.Location = &location
+		}
+	}
+
+	// The first login of a user has nothing to be compared with
+	if len(successes) > 0 {
+		if !knownDevice(current, successes) {
+			assessment.Reasons = append(assessment.Reasons, ReasonNewDevice)
+		}
+		if !knownNetwork(current, successes) {
+			assessment.Reasons = append(assessment.Reasons, ReasonNewNetwork)
+		}
+		if assessment.Location != nil && a.impossibleTravel(current, *assessment.Location, successes) {
+			assessment.Reasons = append(assessment.Reasons, ReasonImpossibleTravel)
+		}
+	}
+	if failures >= a.FailureBurstThreshold {
+		assessment.Reasons = append(assessment.Reasons, ReasonFailureBurst)
+	}
+
+	// A changed IP address alone is common on mobile networks, it is only
+	// recorded. A new device is worth telling the user, and a new device
+	// on a new network or signs of an attack need a proof of the phone
+	// number.
+	reasons := map[string]bool{}
+	for _, reason := range assessment.Reasons {
+		reasons[reason] = true
+	}
+	switch {
+	case reasons[ReasonImpossibleTravel] || reasons[ReasonFailureBurst] ||
+		(reasons[ReasonNewDevice] && reasons[ReasonNewNetwork]):
+		assessment.Decision = DecisionStepUp
+	case reasons[ReasonNewDevice]:
+		assessment.Decision = DecisionNotify
+	}
+
+	return assessment
+}
+
+func knownDevice(current Attempt, successes []Attempt) bool {
+	userAgent := strings.TrimSpace(current.UserAgent)
+	for _, attempt := range successes {
+		if strings.TrimSpace(attempt.UserAgent) == userAgent {
+			return true
+		}
+	}
+	return false
+}
+
+func knownNetwork(current Attempt, successes []Attempt) bool {
+	network, ok := networkOf(current.IpAddress)
+	if !ok {
+		return false
+	}
+	for _, attempt := range successes {
+		if other, ok := networkOf(attempt.IpAddress); ok && other == network {
+			return true
+		}
+	}
+	return false
+}
+
+func networkOf(ip string) (netip.Prefix, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+	bits := ipv6NetworkBits
+	if addr.Is4() {
+		bits = ipv4NetworkBits
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return prefix, true
+}
+
+// impossibleTravel compares the location with the one of the latest
+// successful login, the user couldn't have travelled the distance in
+// between.
+func (a *Assessor) impossibleTravel(current Attempt, location geoip.Location, successes []Attempt) bool {
+	latest := successes[0]
+	for _, attempt := range successes[1:] {
+		if attempt.At.After(latest.At) {
+			latest = attempt
+		}
+	}
+	previous, ok := a.GeoIP.Lookup(latest.IpAddress)
+	if !ok {
+		return false
+	}
+
+	distance := distanceKm(previous, location)
+	if distance < a.MinTravelDistance {
+		return false
+	}
+	hours := current.At.Sub(latest.At).Hours()
+	if hours <= 0 {
+		return true
+	}
+	return distance/hours > a.MaxTravelSpeed
+}
+
+// distanceKm is the great-circle distance with the haversine formula
+func distanceKm(from geoip.Location, to geoip.Location) float64 {
+	lat1 := from.Latitude * math.Pi / 180
+	lat2 := to.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (to.Longitude - from.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
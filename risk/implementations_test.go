@@ -0,0 +1,127 @@
+package risk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/geoip"
+)
+
+const testDatabase = `36.64.0.0,36.95.255.255,AS,ID,Jakarta,Jakarta,-6.2146,106.845
+8.8.8.0,8.8.8.255,NA,US,California,Mountain View,37.4223,-122.085
+`
+
+func TestAssess(t *testing.T) {
+	database, err := geoip.LoadDatabase(strings.NewReader(testDatabase))
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	assessor := NewAssessor(NewAssessorOptions{GeoIP: database})
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	known := Attempt{IpAddress: "36.70.1.2", UserAgent: "Firefox", Success: true, At: now.Add(-time.Hour)}
+	failed := Attempt{IpAddress: "36.70.1.2", UserAgent: "Firefox", At: now.Add(-time.Minute)}
+
+	tests := []struct {
+		caseName         string
+		input            AssessInput
+		expectedDecision string
+		expectedReasons  []string
+	}{
+		{
+			caseName: "First login",
+			input: AssessInput{
+				Current: Attempt{IpAddress: "8.8.8.8", UserAgent: "Chrome", At: now},
+			},
+			expectedDecision: DecisionAllow,
+		},
+		{
+			caseName: "Known device and network",
+			input: AssessInput{
+				Current: Attempt{IpAddress: "36.70.1.200", UserAgent: "Firefox", At: now},
+				History: []Attempt{known},
+			},
+			expectedDecision: DecisionAllow,
+		},
+		{
+			caseName: "New network",
+			input: AssessInput{
+				Current: Attempt{IpAddress: "36.80.1.2", UserAgent: "Firefox", At: now},
+				History: []Attempt{known},
+			},
+			expectedDecision: DecisionAllow,
+			expectedReasons:  []string{ReasonNewNetwork},
+		},
+		{
+			caseName: "New device",
+			input: AssessInput{
+				Current: Attempt{IpAddress: "36.70.1.2", UserAgent: "Chrome", At: now},
+				History: []Attempt{known},
+			},
+			expectedDecision: DecisionNotify,
+			expectedReasons:  []string{ReasonNewDevice},
+		},
+		{
+			caseName: "Impossible travel",
+			input: AssessInput{
+				Current: Attempt{IpAddress: "8.8.8.8", UserAgent: "Firefox", At: now},
+				History: []Attempt{known},
+			},
+			expectedDecision: DecisionStepUp,
+			expectedReasons:  []string{ReasonNewNetwork, ReasonImpossibleTravel},
+		},
+		{
+			caseName: "Possible travel",
+			input: AssessInput{
+				Current: Attempt{IpAddress: "8.8.8.8", UserAgent: "Firefox", At: now.Add(time.Hour * 24)},
+				History: []Attempt{known},
+			},
+			expectedDecision: DecisionAllow,
+			expectedReasons:  []string{ReasonNewNetwork},
+		},
+		{
+			caseName: "Failure burst",
+			input: AssessInput{
+				Current: Attempt{IpAddress: "36.70.1.2", UserAgent: "Firefox", At: now},
+				History: []Attempt{known, failed, failed, failed, failed, failed},
+			},
+			expectedDecision: DecisionStepUp,
+			expectedReasons:  []string{ReasonFailureBurst},
+		},
+		{
+			caseName: "Failures outside of the window",
+			input: AssessInput{
+				Current: Attempt{IpAddress: "36.70.1.2", UserAgent: "Firefox", At: now.Add(time.Hour)},
+				History: []Attempt{known, failed, failed, failed, failed, failed},
+			},
+			expectedDecision: DecisionAllow,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			assessment := assessor.Assess(test.input)
+			if assessment.Decision != test.expectedDecision {
+				t.Errorf("Expected %s, got %s", test.expectedDecision, assessment.Decision)
+			}
+			if !reflect.DeepEqual(assessment.Reasons, test.expectedReasons) {
+				t.Errorf("Expected %v, got %v", test.expectedReasons, assessment.Reasons)
+			}
+		})
+	}
+}
+
+func TestAssessWithoutGeoIP(t *testing.T) {
+	assessor := NewAssessor(NewAssessorOptions{})
+
+	now := time.Now()
+	assessment := assessor.Assess(AssessInput{
+		Current: Attempt{IpAddress: "8.8.8.8", UserAgent: "Firefox", At: now},
+		History: []Attempt{{IpAddress: "36.70.1.2", UserAgent: "Firefox", Success: true, At: now.Add(-time.Minute)}},
+	})
+	if assessment.Decision != DecisionAllow || assessment.Location != nil {
+		t.Errorf("Expected allow without location, got %v", assessment)
+	}
+}
@@ -0,0 +1,8 @@
+// This file contains the interfaces for the risk layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package risk
+
+type AssessorInterface interface {
+	Assess(input AssessInput) Assessment
+}
@@ -0,0 +1,48 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: risk/interfaces.go
+
+// Package risk is a generated GoMock package.
+package risk
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAssessorInterface is a mock of AssessorInterface interface.
+type MockAssessorInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAssessorInterfaceMockRecorder
+}
+
+// MockAssessorInterfaceMockRecorder is the mock recorder for MockAssessorInterface.
+type MockAssessorInterfaceMockRecorder struct {
+	mock *MockAssessorInterface
+}
+
+// NewMockAssessorInterface creates a new mock instance.
+func NewMockAssessorInterface(ctrl *gomock.Controller) *MockAssessorInterface {
+	mock := &MockAssessorInterface{ctrl: ctrl}
+	mock.recorder = &MockAssessorInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAssessorInterface) EXPECT() *MockAssessorInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Assess mocks base method.
+func (m *MockAssessorInterface) Assess(input AssessInput) Assessment {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Assess", input)
+	ret0, _ := ret[0].(Assessment)
+	return ret0
+}
+
+// Assess indicates an expected call of Assess.
+func (mr *MockAssessorInterfaceMockRecorder) Assess(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assess", reflect.TypeOf((*MockAssessorInterface)(nil).Assess), input)
+}
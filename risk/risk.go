@@ -0,0 +1,99 @@
+// This file contains the risk assessment of logins. A login is compared
+// with the previous attempts of the user: the devices and networks used
+// before, where the last login came from and how many attempts failed
+// recently.
+package risk
+
+import (
+	"time"
+
+	"github.com/asrul10/UserService/geoip"
+)
+
+// Decisions of an assessment, from the least to the most restrictive
+const (
+	DecisionAllow  = "allow"
+	DecisionNotify = "notify"
+	DecisionStepUp = "step_up"
+)
+
+// Reasons of an assessment
+const (
+	ReasonNewDevice        = "new_device"
+	ReasonNewNetwork       = "new_network"
+	ReasonImpossibleTravel = "impossible_travel"
+	ReasonFailureBurst     = "failure_burst"
+)
+
+const (
+	DefaultFailureBurstThreshold = 5
+	DefaultFailureBurstWindow    = time.Minute * 15
+	// DefaultMaxTravelSpeed is about the speed of a airliner, in km/h
+	DefaultMaxTravelSpeed = 900
+	// DefaultMinTravelDistance ignores short distances, GeoIP locations
+	// are not precise enough for them. In km.
+	DefaultMinTravelDistance = 500
+)
+
+type Attempt struct {
+	IpAddress string
+	UserAgent string
+	Success   bool
+	At        time.Time
+}
+
+type AssessInput struct {
+	// Current is the login being assessed
+	Current Attempt
+	// History is the previous attempts of the user, in any order
+	History []Attempt
+}
+
+type Assessment struct {
+	Decision string
+	Reasons  []string
+	// Location of the current attempt, nil when unknown
+	Location *geoip.Location
+}
+
+type Assessor struct {
+	// GeoIP is optional, without it impossible travel is not detected
+	GeoIP                 geoip.DatabaseInterface
+	FailureBurstThreshold int
+	FailureBurstWindow    time.Duration
+	MaxTravelSpeed        float64
+	MinTravelDistance     float64
+}
+
+type NewAssessorOptions struct {
+	GeoIP geoip.DatabaseInterface
+	// FailureBurstThreshold is the number of failed attempts within
+	// FailureBurstWindow which makes a login risky
+	FailureBurstThreshold int
+	FailureBurstWindow    time.Duration
+	MaxTravelSpeed        float64
+	MinTravelDistance     float64
+}
+
+func NewAssessor(opts NewAssessorOptions) *Assessor {
+	a := &Assessor{
+		GeoIP:                 opts.GeoIP,
+		FailureBurstThreshold: opts.FailureBurstThreshold,
+		FailureBurstWindow:    opts.FailureBurstWindow,
+		MaxTravelSpeed:        opts.MaxTravelSpeed,
+		MinTravelDistance:     opts.MinTravelDistance,
+	}
+	if a.FailureBurstThreshold <= 0 {
+		a.FailureBurstThreshold = DefaultFailureBurstThreshold
+	}
+	if a.FailureBurstWindow <= 0 {
+		a.FailureBurstWindow = DefaultFailureBurstWindow
+	}
+	if a.MaxTravelSpeed <= 0 {
+		a.MaxTravelSpeed = DefaultMaxTravelSpeed
+	}
+	if a.MinTravelDistance <= 0 {
+		a.MinTravelDistance = DefaultMinTravelDistance
+	}
+	return a
+}
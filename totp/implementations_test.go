@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B, SHA1 variant
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	totp := NewTOTP(NewTOTPOptions{Digits: 8})
+
+	tests := []struct {
+		caseName string
+		at       int64
+		code     string
+	}{
+		{caseName: "59", at: 59, code: "94287082"},
+		{caseName: "1111111109", at: 1111111109, code: "07081804"},
+		{caseName: "1111111111", at: 1111111111, code: "14050471"},
+		{caseName: "1234567890", at: 1234567890, code: "89005924"},
+		{caseName: "2000000000", at: 2000000000, code: "69279037"},
+		{caseName: "20000000000", at: 20000000000, code: "65353130"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			step, ok := totp.Validate(secret, test.code, time.Unix(test.at, 0))
+			if !ok {
+				t.Errorf("Expected code %s to be valid", test.code)
+			}
+			if step != test.at/30 {
+				t.Errorf("Expected step %d, got %d", test.at/30, step)
+			}
+		})
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	totp := NewTOTP(NewTOTPOptions{Digits: 8})
+
+	// The code of time 59 is accepted one period later but not two
+	if _, ok := totp.Validate(secret, "94287082", time.Unix(89, 0)); !ok {
+		t.Errorf("Expected code of the previous period to be valid")
+	}
+	if _, ok := totp.Validate(secret, "94287082", time.Unix(119, 0)); ok {
+		t.Errorf("Expected code of two periods ago to be invalid")
+	}
+	if _, ok := totp.Validate(secret, "9428708", time.Unix(59, 0)); ok {
+		t.Errorf("Expected code with the wrong length to be invalid")
+	}
+	if _, ok := totp.Validate("not base32!", "94287082", time.Unix(59, 0)); ok {
+		t.Errorf("Expected invalid secret to be rejected")
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	totp := NewTOTP(NewTOTPOptions{Issuer: "UserService"})
+
+	key, err := totp.GenerateKey("+628123456789")
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if len(key.Secret) != 32 {
+		t.Errorf("Expected 32 characters secret, got %s", key.Secret)
+	}
+
+	uri, err := url.Parse(key.URI)
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("Expected otpauth://totp URI, got %s", key.URI)
+	}
+	if !strings.HasSuffix(uri.Path, "UserService:+628123456789") {
+		t.Errorf("Expected label with issuer, got %s", uri.Path)
+	}
+	if uri.Query().Get("secret") != key.Secret {
+		t.Errorf("Expected secret in URI, got %s", key.URI)
+	}
+
+	// A code generated from the key is accepted
+	raw, _ := decodeSecret(key.Secret)
+	now := time.Now()
+	code := generateCode(raw, now.Unix()/30, DefaultDigits)
+	if _, ok := totp.Validate(key.Secret, code, now); !ok {
+		t.Errorf("Expected generated code to be valid")
+	}
+}
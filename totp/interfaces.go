@@ -0,0 +1,13 @@
+// This file contains the interfaces for the totp layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package totp
+
+import "time"
+
+type TOTPInterface interface {
+	GenerateKey(accountName string) (Key, error)
+	// Validate returns the time step of the matched code, callers store it
+	// to refuse the same code twice.
+	Validate(secret string, code string, at time.Time) (step int64, ok bool)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: totp/interfaces.go
+
+// Package totp is a generated GoMock package.
+package totp
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTOTPInterface is a mock of TOTPInterface interface.
+type MockTOTPInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTOTPInterfaceMockRecorder
+}
+
+// MockTOTPInterfaceMockRecorder is the mock recorder for MockTOTPInterface.
+type MockTOTPInterfaceMockRecorder struct {
+	mock *MockTOTPInterface
+}
+
+// NewMockTOTPInterface creates a new mock instance.
+func NewMockTOTPInterface(ctrl *gomock.Controller) *MockTOTPInterface {
+	mock := &MockTOTPInterface{ctrl: ctrl}
+	mock.recorder = &MockTOTPInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTOTPInterface) EXPECT() *MockTOTPInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GenerateKey mocks base method.
+func (m *MockTOTPInterface) GenerateKey(accountName string) (Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateKey", accountName)
+	ret0, _ := ret[0].(Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateKey indicates an expected call of GenerateKey.
+func (mr *MockTOTPInterfaceMockRecorder) GenerateKey(accountName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateKey", reflect.TypeOf((*MockTOTPInterface)(nil).GenerateKey), accountName)
+}
+
+// Validate mocks base method.
+func (m *MockTOTPInterface) Validate(secret, code string, at time.Time) (int64, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", secret, code, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockTOTPInterfaceMockRecorder) Validate(secret, code, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockTOTPInterface)(nil).Validate), secret, code, at)
+}
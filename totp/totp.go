@@ -0,0 +1,51 @@
+// This file contains the TOTP (RFC 6238) generator and validator used for
+// two-factor authentication. Codes are HMAC-SHA1 based, which is what
+// authenticator apps support.
+package totp
+
+import "time"
+
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30 * time.Second
+	// DefaultSkew accepts the previous and next code too, clocks of phones
+	// are rarely exact.
+	DefaultSkew = 1
+	// secretSize is the size in bytes of generated secrets, 160 bits as
+	// recommended by RFC 4226.
+	secretSize = 20
+)
+
+type TOTP struct {
+	Issuer string
+	Digits int
+	Period time.Duration
+	Skew   int
+}
+
+type NewTOTPOptions struct {
+	// Issuer is shown by authenticator apps next to the account name.
+	Issuer string
+	Digits int
+	Period time.Duration
+	Skew   int
+}
+
+func NewTOTP(opts NewTOTPOptions) *TOTP {
+	t := &TOTP{
+		Issuer: opts.Issuer,
+		Digits: opts.Digits,
+		Period: opts.Period,
+		Skew:   opts.Skew,
+	}
+	if t.Digits == 0 {
+		t.Digits = DefaultDigits
+	}
+	if t.Period == 0 {
+		t.Period = DefaultPeriod
+	}
+	if t.Skew == 0 {
+		t.Skew = DefaultSkew
+	}
+	return t
+}
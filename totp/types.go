@@ -0,0 +1,8 @@
+package totp
+
+type Key struct {
+	// Secret is base32 encoded without padding
+	Secret string
+	// URI is the otpauth:// URI to be rendered as a QR code
+	URI string
+}
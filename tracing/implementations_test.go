@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		caseName string
+		query    string
+		expected string
+	}{
+		{
+			caseName: "Placeholders are kept",
+			query:    "SELECT id, full_name FROM users WHERE phone_number = $1",
+			expected: "SELECT id, full_name FROM users WHERE phone_number = $1",
+		},
+		{
+			caseName: "Whitespace is collapsed",
+			query:    "\n\t\tUPDATE users\n\t\tSET status = $2\n\t\tWHERE id = $1\n\t",
+			expected: "UPDATE users SET status = $2 WHERE id = $1",
+		},
+		{
+			caseName: "String literals are replaced",
+			query:    "UPDATE users SET status = 'suspended', full_name = 'O''Brien' WHERE id = $1",
+			expected: "UPDATE users SET status = ?, full_name = ? WHERE id = $1",
+		},
+		{
+			caseName: "Number literals are replaced",
+			query:    "SELECT pg_advisory_xact_lock(7421001) FROM login_events LIMIT 10 OFFSET 2.5",
+			expected: "SELECT pg_advisory_xact_lock(?) FROM login_events LIMIT ? OFFSET ?",
+		},
+		{
+			caseName: "Identifiers with digits are kept",
+			query:    "SELECT sha256 FROM t1",
+			expected: "SELECT sha256 FROM t1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			if sanitized := SanitizeSQL(test.query); sanitized != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, sanitized)
+			}
+		})
+	}
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	provider, err := NewTracerProvider(context.Background(), NewTracerProviderOptions{
+		Exporter: ExporterFile,
+		FilePath: t.TempDir() + "/traces.json",
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+
+	if _, err := NewTracerProvider(context.Background(), NewTracerProviderOptions{
+		Exporter: "zipkin",
+	}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Connector wraps the connector of a driver, every query of its
+// connections is recorded as a span with the sanitized SQL. Queries of
+// transactions are recorded too, they run on the same connections.
+type Connector struct {
+	next   driver.Connector
+	tracer trace.Tracer
+}
+
+type conn struct {
+	driver.Conn
+	tracer trace.Tracer
+}
+
+func NewConnector(next driver.Connector, tracer trace.Tracer) *Connector {
+	return &Connector{next: next, tracer: tracer}
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	next, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: next, tracer: c.tracer}, nil
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+func (c *conn) startSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := operationOf(query)
+	return c.tracer.Start(ctx, "db "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(SanitizeSQL(query)),
+		),
+	)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.startSpan(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	End(span, err)
+	return rows, err
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.startSpan(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	End(span, err)
+	return result, err
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
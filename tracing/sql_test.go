@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+type fakeConnector struct{}
+
+type fakeConn struct{}
+
+type fakeResult struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+func (fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return fakeResult{}, nil
+}
+
+func (fakeResult) LastInsertId() (int64, error) { return 0, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+func TestConnector(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	db := sql.OpenDB(NewConnector(fakeConnector{}, tracer))
+	defer db.Close()
+
+	if _, err := db.ExecContext(context.Background(), "UPDATE users SET status = 'suspended' WHERE id = $1", 1); err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "db UPDATE" {
+		t.Errorf("Expected span db UPDATE, got %s", spans[0].Name())
+	}
+	expected := semconv.DBQueryText("UPDATE users SET status = ? WHERE id = $1")
+	found := false
+	for _, attr := range spans[0].Attributes() {
+		if attr == expected {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected %v, got %v", expected, spans[0].Attributes())
+	}
+}
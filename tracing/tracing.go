@@ -0,0 +1,85 @@
+// This file contains the OpenTelemetry tracing of the service. Spans are
+// exported to stdout or a file for local runs, or to an OTLP collector,
+// and dropped when no exporter is configured.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// TracerName is the instrumentation scope of the spans of the service
+const TracerName = "github.com/asrul10/UserService"
+
+const DefaultServiceName = "user-service"
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+type NewTracerProviderOptions struct {
+	// Exporter is one of none, stdout, file or otlp, spans are dropped
+	// when empty
+	Exporter string
+	// FilePath of the file exporter, spans are appended one JSON object
+	// per line
+	FilePath string
+	// OTLPEndpoint is the URL of the collector, such as
+	// http://localhost:4318. The OTEL_EXPORTER_OTLP_* environment
+	// variables are used when empty.
+	OTLPEndpoint string
+	// ServiceName defaults to DefaultServiceName
+	ServiceName string
+}
+
+// NewTracerProvider returns the provider of the tracers of the service, it
+// has to be shut down to flush the remaining spans.
+func NewTracerProvider(ctx context.Context, opts NewTracerProviderOptions) (*sdktrace.TracerProvider, error) {
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case "", ExporterNone:
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	case ExporterFile:
+		file, openErr := os.OpenFile(opts.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if openErr != nil {
+			return nil, openErr
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case ExporterOTLP:
+		var otlpOpts []otlptracehttp.Option
+		if opts.OTLPEndpoint != "" {
+			otlpOpts = append(otlpOpts, otlptracehttp.WithEndpointURL(opts.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, otlpOpts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if exporter != nil {
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+
+	return sdktrace.NewTracerProvider(providerOpts...), nil
+}
@@ -0,0 +1,153 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+// SoftwareAuthenticator is an in-memory ES256 authenticator with "none"
+// attestation. It stands in for a security key or a platform passkey in
+// tests, so no hardware is needed.
+type SoftwareAuthenticator struct {
+	RPID   string
+	Origin string
+	// SignCountStep is added to the counter on every assertion, zero
+	// emulates authenticators without a counter.
+	SignCountStep uint32
+
+	credentials map[string]*softwareCredential
+}
+
+type softwareCredential struct {
+	id         []byte
+	key        *ecdsa.PrivateKey
+	signCount  uint32
+	userHandle []byte
+}
+
+type NewSoftwareAuthenticatorOptions struct {
+	RPID          string
+	Origin        string
+	SignCountStep uint32
+}
+
+func NewSoftwareAuthenticator(opts NewSoftwareAuthenticatorOptions) *SoftwareAuthenticator {
+	return &SoftwareAuthenticator{
+		RPID:          opts.RPID,
+		Origin:        opts.Origin,
+		SignCountStep: opts.SignCountStep,
+		credentials:   map[string]*softwareCredential{},
+	}
+}
+
+// CreateCredential answers navigator.credentials.create() for the
+// challenge.
+func (a *SoftwareAuthenticator) CreateCredential(challenge string, userHandle []byte) (AttestationResponse, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return AttestationResponse{}, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return AttestationResponse{}, err
+	}
+	credential := &softwareCredential{
+		id:         id,
+		key:        key,
+		userHandle: userHandle,
+	}
+	a.credentials[string(id)] = credential
+
+	publicKey, err := encodeCBOR([]cborPair{
+		{Key: 1, Value: 2},
+		{Key: 3, Value: AlgES256},
+		{Key: -1, Value: 1},
+		{Key: -2, Value: padded(key.X.Bytes(), 32)},
+		{Key: -3, Value: padded(key.Y.Bytes(), 32)},
+	})
+	if err != nil {
+		return AttestationResponse{}, err
+	}
+
+	authData := a.authenticatorData(flagUserPresent|flagAttestedData, 0)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(id)))
+	authData = append(authData, id...)
+	authData = append(authData, publicKey...)
+
+	attestationObject, err := encodeCBOR([]cborPair{
+		{Key: "fmt", Value: "none"},
+		{Key: "attStmt", Value: []cborPair{}},
+		{Key: "authData", Value: authData},
+	})
+	if err != nil {
+		return AttestationResponse{}, err
+	}
+
+	clientDataJSON, err := a.clientData(typeCreate, challenge)
+	if err != nil {
+		return AttestationResponse{}, err
+	}
+
+	return AttestationResponse{
+		CredentialID:      id,
+		ClientDataJSON:    clientDataJSON,
+		AttestationObject: attestationObject,
+	}, nil
+}
+
+// GetAssertion answers navigator.credentials.get() for the challenge with
+// the given credential.
+func (a *SoftwareAuthenticator) GetAssertion(challenge string, credentialID []byte) (AssertionResponse, error) {
+	credential, ok := a.credentials[string(credentialID)]
+	if !ok {
+		return AssertionResponse{}, errors.New("unknown credential")
+	}
+	credential.signCount += a.SignCountStep
+
+	authData := a.authenticatorData(flagUserPresent, credential.signCount)
+	clientDataJSON, err := a.clientData(typeGet, challenge)
+	if err != nil {
+		return AssertionResponse{}, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, credential.key, digest[:])
+	if err != nil {
+		return AssertionResponse{}, err
+	}
+
+	return AssertionResponse{
+		CredentialID:      credential.id,
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         signature,
+		UserHandle:        credential.userHandle,
+	}, nil
+}
+
+func (a *SoftwareAuthenticator) authenticatorData(flags byte, signCount uint32) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	authData := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(authData, signCount)
+}
+
+func (a *SoftwareAuthenticator) clientData(ceremony string, challenge string) ([]byte, error) {
+	return json.Marshal(clientData{
+		Type:      ceremony,
+		Challenge: challenge,
+		Origin:    a.Origin,
+	})
+}
+
+func padded(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
@@ -0,0 +1,194 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// This file contains the subset of CBOR (RFC 8949) used by WebAuthn:
+// attestation objects and COSE keys only need integers, byte and text
+// strings, arrays, maps and simple values, always with definite lengths.
+
+var errCBORUnsupported = errors.New("unsupported cbor item")
+
+// maxCBORDepth bounds the nesting of decoded items, WebAuthn structures
+// are never deeper than a few levels.
+const maxCBORDepth = 16
+
+// decodeCBOR decodes the first item of data and returns the remaining
+// bytes. Maps are decoded to map[interface{}]interface{}, integers to int64
+// and byte strings to []byte.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errCBORUnsupported
+	}
+	if len(data) == 0 {
+		return nil, nil, errors.New("unexpected end of cbor data")
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	// Simple values and floats
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		default:
+			return nil, nil, errCBORUnsupported
+		}
+	}
+
+	arg, data, err := readCBORArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, nil, errCBORUnsupported
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, nil, errCBORUnsupported
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if uint64(len(data)) < arg {
+			return nil, nil, errors.New("unexpected end of cbor data")
+		}
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+		return append([]byte(nil), value...), data[arg:], nil
+	case 4:
+		if arg > uint64(len(data)) {
+			return nil, nil, errors.New("unexpected end of cbor data")
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item interface{}
+			item, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data)) {
+			return nil, nil, errors.New("unexpected end of cbor data")
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			key, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errCBORUnsupported
+			}
+			value, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, data, nil
+	default:
+		return nil, nil, errCBORUnsupported
+	}
+}
+
+func readCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	case info >= 28:
+		// Reserved values and indefinite lengths
+		return 0, nil, errCBORUnsupported
+	default:
+		return 0, nil, errors.New("unexpected end of cbor data")
+	}
+}
+
+// cborPair is a map entry for encodeCBOR, maps are encoded in the given
+// order.
+type cborPair struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// encodeCBOR encodes the items needed by the software authenticator:
+// integers, byte and text strings, and maps as []cborPair.
+func encodeCBOR(item interface{}) ([]byte, error) {
+	switch v := item.(type) {
+	case int:
+		return encodeCBOR(int64(v))
+	case int64:
+		if v < 0 {
+			return appendCBORHeader(nil, 1, uint64(-1-v)), nil
+		}
+		return appendCBORHeader(nil, 0, uint64(v)), nil
+	case []byte:
+		return append(appendCBORHeader(nil, 2, uint64(len(v))), v...), nil
+	case string:
+		return append(appendCBORHeader(nil, 3, uint64(len(v))), v...), nil
+	case []cborPair:
+		out := appendCBORHeader(nil, 5, uint64(len(v)))
+		for _, pair := range v {
+			key, err := encodeCBOR(pair.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := encodeCBOR(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			out = append(append(out, key...), value...)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", errCBORUnsupported, item)
+	}
+}
+
+func appendCBORHeader(out []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return append(out, major|byte(arg))
+	case arg <= 0xff:
+		return append(out, major|24, byte(arg))
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(out, major|25), uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(out, major|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(out, major|27), arg)
+	}
+}
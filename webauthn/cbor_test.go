@@ -0,0 +1,59 @@
+package webauthn
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCBORRoundTrip(t *testing.T) {
+	encoded, err := encodeCBOR([]cborPair{
+		{Key: 1, Value: 2},
+		{Key: -3, Value: []byte{1, 2, 3}},
+		{Key: "fmt", Value: "none"},
+		{Key: "big", Value: 70000},
+		{Key: "nested", Value: []cborPair{}},
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+
+	decoded, rest, err := decodeCBOR(append(encoded, 0xff))
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	if !bytes.Equal(rest, []byte{0xff}) {
+		t.Errorf("Expected remaining bytes, got %v", rest)
+	}
+	expected := map[interface{}]interface{}{
+		int64(1):  int64(2),
+		int64(-3): []byte{1, 2, 3},
+		"fmt":     "none",
+		"big":     int64(70000),
+		"nested":  map[interface{}]interface{}{},
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("Expected %v, got %v", expected, decoded)
+	}
+}
+
+func TestCBORDecodeErrors(t *testing.T) {
+	tests := []struct {
+		caseName string
+		data     []byte
+	}{
+		{caseName: "Empty", data: nil},
+		{caseName: "Truncated byte string", data: []byte{0x43, 1}},
+		{caseName: "Indefinite length", data: []byte{0x5f}},
+		{caseName: "Huge array", data: []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{caseName: "Float", data: []byte{0xf9, 0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			if _, _, err := decodeCBOR(test.data); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
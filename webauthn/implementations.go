@@ -0,0 +1,284 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+)
+
+const (
+	flagUserPresent  = 0x01
+	flagAttestedData = 0x40
+
+	challengeSize = 32
+)
+
+// Client data types of the two ceremonies
+const (
+	typeCreate = "webauthn.create"
+	typeGet    = "webauthn.get"
+)
+
+func (w *WebAuthn) BeginRegistration(user User, exclude [][]byte) (CreationOptions, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return CreationOptions{}, err
+	}
+
+	return CreationOptions{
+		Challenge:          challenge,
+		RPID:               w.RPID,
+		RPName:             w.RPName,
+		User:               user,
+		Algorithms:         []int{AlgES256, AlgRS256},
+		Timeout:            w.Timeout,
+		ExcludeCredentials: exclude,
+	}, nil
+}
+
+func (w *WebAuthn) FinishRegistration(input FinishRegistrationInput) (Credential, error) {
+	if err := w.verifyClientData(input.ClientDataJSON, typeCreate, input.Challenge); err != nil {
+		return Credential{}, err
+	}
+
+	item, _, err := decodeCBOR(input.AttestationObject)
+	if err != nil {
+		return Credential{}, ErrInvalidAuthenticatorData
+	}
+	attestation, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return Credential{}, ErrInvalidAuthenticatorData
+	}
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+	if format != "none" || len(statement) != 0 {
+		return Credential{}, ErrUnsupportedAttestation
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return Credential{}, ErrInvalidAuthenticatorData
+	}
+
+	authData, err := w.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return Credential{}, err
+	}
+	if authData.CredentialID == nil {
+		return Credential{}, ErrInvalidAuthenticatorData
+	}
+	if !bytes.Equal(authData.CredentialID, input.CredentialID) {
+		return Credential{}, ErrCredentialMismatch
+	}
+	if _, err := parsePublicKey(authData.PublicKey); err != nil {
+		return Credential{}, err
+	}
+
+	return Credential{
+		ID:        authData.CredentialID,
+		PublicKey: authData.PublicKey,
+		SignCount: authData.SignCount,
+		AAGUID:    authData.AAGUID,
+	}, nil
+}
+
+func (w *WebAuthn) BeginLogin(allow [][]byte) (RequestOptions, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return RequestOptions{}, err
+	}
+
+	return RequestOptions{
+		Challenge:        challenge,
+		RPID:             w.RPID,
+		Timeout:          w.Timeout,
+		AllowCredentials: allow,
+	}, nil
+}
+
+func (w *WebAuthn) FinishLogin(input FinishLoginInput) (FinishLoginOutput, error) {
+	if err := w.verifyClientData(input.ClientDataJSON, typeGet, input.Challenge); err != nil {
+		return FinishLoginOutput{}, err
+	}
+
+	authData, err := w.verifyAuthenticatorData(input.AuthenticatorData)
+	if err != nil {
+		return FinishLoginOutput{}, err
+	}
+
+	publicKey, err := parsePublicKey(input.Credential.PublicKey)
+	if err != nil {
+		return FinishLoginOutput{}, err
+	}
+	clientDataHash := sha256.Sum256(input.ClientDataJSON)
+	signed := append(append([]byte(nil), input.AuthenticatorData...), clientDataHash[:]...)
+	if err := verifySignature(publicKey, signed, input.Signature); err != nil {
+		return FinishLoginOutput{}, err
+	}
+
+	// Authenticators without a counter always report zero
+	stored := input.Credential.SignCount
+	if (authData.SignCount != 0 || stored != 0) && authData.SignCount <= stored {
+		return FinishLoginOutput{}, ErrSignCountInvalid
+	}
+
+	return FinishLoginOutput{
+		SignCount: authData.SignCount,
+	}, nil
+}
+
+func (w *WebAuthn) ChallengeOf(clientDataJSON []byte) (string, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil || data.Challenge == "" {
+		return "", ErrInvalidClientData
+	}
+	return data.Challenge, nil
+}
+
+func (w *WebAuthn) verifyClientData(clientDataJSON []byte, ceremony string, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return ErrInvalidClientData
+	}
+	if data.Type != ceremony {
+		return ErrInvalidClientData
+	}
+	if subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return ErrChallengeMismatch
+	}
+	if !w.Origins[data.Origin] {
+		return ErrOriginNotAllowed
+	}
+	return nil
+}
+
+func (w *WebAuthn) verifyAuthenticatorData(raw []byte) (authenticatorData, error) {
+	authData, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return authData, err
+	}
+	rpIDHash := sha256.Sum256([]byte(w.RPID))
+	if !bytes.Equal(authData.RPIDHash, rpIDHash[:]) {
+		return authData, ErrRPIDMismatch
+	}
+	if authData.Flags&flagUserPresent == 0 {
+		return authData, ErrUserNotPresent
+	}
+	return authData, nil
+}
+
+// parseAuthenticatorData parses the layout of the WebAuthn spec section
+// 6.1: rpIdHash (32) | flags (1) | signCount (4) | attestedCredentialData
+// | extensions.
+func parseAuthenticatorData(raw []byte) (authenticatorData, error) {
+	var authData authenticatorData
+	if len(raw) < 37 {
+		return authData, ErrInvalidAuthenticatorData
+	}
+	authData.RPIDHash = raw[:32]
+	authData.Flags = raw[32]
+	authData.SignCount = binary.BigEndian.Uint32(raw[33:37])
+
+	if authData.Flags&flagAttestedData == 0 {
+		return authData, nil
+	}
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return authData, ErrInvalidAuthenticatorData
+	}
+	authData.AAGUID = rest[:16]
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || len(rest) < idLength {
+		return authData, ErrInvalidAuthenticatorData
+	}
+	authData.CredentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	// The public key is the CBOR item following the credential id
+	_, remaining, err := decodeCBOR(rest)
+	if err != nil {
+		return authData, ErrInvalidAuthenticatorData
+	}
+	authData.PublicKey = rest[:len(rest)-len(remaining)]
+
+	return authData, nil
+}
+
+// parsePublicKey decodes a COSE_Key (RFC 8152) of a supported algorithm
+func parsePublicKey(coseKey []byte) (crypto.PublicKey, error) {
+	item, _, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	key, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+
+	switch {
+	case kty == 2 && alg == AlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrUnsupportedAlgorithm
+		}
+		publicKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, ErrUnsupportedAlgorithm
+		}
+		return publicKey, nil
+	case kty == 3 && alg == AlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedAlgorithm
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+func verifySignature(publicKey crypto.PublicKey, signed []byte, signature []byte) error {
+	digest := sha256.Sum256(signed)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return ErrInvalidSignature
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+	return nil
+}
+
+func newChallenge() (string, error) {
+	raw := make([]byte, challengeSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
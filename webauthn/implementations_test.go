@@ -0,0 +1,226 @@
+package webauthn
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestWebAuthn() *WebAuthn {
+	return NewWebAuthn(NewWebAuthnOptions{
+		RPID:    "example.com",
+		RPName:  "Example",
+		Origins: []string{"https://example.com"},
+	})
+}
+
+func register(t *testing.T, w *WebAuthn, a *SoftwareAuthenticator) Credential {
+	options, err := w.BeginRegistration(User{ID: []byte("1"), Name: "+628123456789"}, nil)
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	response, err := a.CreateCredential(options.Challenge, []byte("1"))
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	credential, err := w.FinishRegistration(FinishRegistrationInput{
+		Challenge:         options.Challenge,
+		CredentialID:      response.CredentialID,
+		ClientDataJSON:    response.ClientDataJSON,
+		AttestationObject: response.AttestationObject,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %s", err.Error())
+	}
+	return credential
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	w := newTestWebAuthn()
+	a := NewSoftwareAuthenticator(NewSoftwareAuthenticatorOptions{
+		RPID:          "example.com",
+		Origin:        "https://example.com",
+		SignCountStep: 1,
+	})
+
+	credential := register(t, w, a)
+	if len(credential.ID) == 0 || len(credential.PublicKey) == 0 {
+		t.Fatalf("Expected credential, got %v", credential)
+	}
+
+	for i := uint32(1); i <= 2; i++ {
+		options, _ := w.BeginLogin(nil)
+		assertion, err := a.GetAssertion(options.Challenge, credential.ID)
+		if err != nil {
+			t.Fatalf("Expected nil, got %s", err.Error())
+		}
+
+		challenge, err := w.ChallengeOf(assertion.ClientDataJSON)
+		if err != nil || challenge != options.Challenge {
+			t.Errorf("Expected challenge %s, got %s", options.Challenge, challenge)
+		}
+
+		output, err := w.FinishLogin(FinishLoginInput{
+			Challenge:         options.Challenge,
+			Credential:        credential,
+			ClientDataJSON:    assertion.ClientDataJSON,
+			AuthenticatorData: assertion.AuthenticatorData,
+			Signature:         assertion.Signature,
+		})
+		if err != nil {
+			t.Fatalf("Expected nil, got %s", err.Error())
+		}
+		if output.SignCount != i {
+			t.Errorf("Expected sign count %d, got %d", i, output.SignCount)
+		}
+		credential.SignCount = output.SignCount
+	}
+}
+
+func TestFinishLoginErrors(t *testing.T) {
+	w := newTestWebAuthn()
+	a := NewSoftwareAuthenticator(NewSoftwareAuthenticatorOptions{
+		RPID:          "example.com",
+		Origin:        "https://example.com",
+		SignCountStep: 1,
+	})
+	credential := register(t, w, a)
+
+	tests := []struct {
+		caseName string
+		modify   func(input *FinishLoginInput)
+		expected error
+	}{
+		{
+			caseName: "Other challenge",
+			modify: func(input *FinishLoginInput) {
+				input.Challenge = "other"
+			},
+			expected: ErrChallengeMismatch,
+		},
+		{
+			caseName: "Tampered signature",
+			modify: func(input *FinishLoginInput) {
+				input.Signature[len(input.Signature)-1] ^= 1
+			},
+			expected: ErrInvalidSignature,
+		},
+		{
+			caseName: "Sign count did not increase",
+			modify: func(input *FinishLoginInput) {
+				input.Credential.SignCount = 10
+			},
+			expected: ErrSignCountInvalid,
+		},
+		{
+			caseName: "Registration client data",
+			modify: func(input *FinishLoginInput) {
+				input.ClientDataJSON = []byte(`{"type":"webauthn.create","challenge":"` + input.Challenge + `","origin":"https://example.com"}`)
+			},
+			expected: ErrInvalidClientData,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			options, _ := w.BeginLogin(nil)
+			assertion, _ := a.GetAssertion(options.Challenge, credential.ID)
+			input := FinishLoginInput{
+				Challenge:         options.Challenge,
+				Credential:        credential,
+				ClientDataJSON:    assertion.ClientDataJSON,
+				AuthenticatorData: assertion.AuthenticatorData,
+				Signature:         assertion.Signature,
+			}
+			test.modify(&input)
+
+			if _, err := w.FinishLogin(input); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestFinishRegistrationErrors(t *testing.T) {
+	w := newTestWebAuthn()
+
+	tests := []struct {
+		caseName string
+		rpID     string
+		origin   string
+		expected error
+	}{
+		{
+			caseName: "Phishing origin",
+			rpID:     "example.com",
+			origin:   "https://examp1e.com",
+			expected: ErrOriginNotAllowed,
+		},
+		{
+			caseName: "Other relying party",
+			rpID:     "examp1e.com",
+			origin:   "https://example.com",
+			expected: ErrRPIDMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			a := NewSoftwareAuthenticator(NewSoftwareAuthenticatorOptions{
+				RPID:   test.rpID,
+				Origin: test.origin,
+			})
+			options, _ := w.BeginRegistration(User{ID: []byte("1")}, nil)
+			response, _ := a.CreateCredential(options.Challenge, []byte("1"))
+
+			_, err := w.FinishRegistration(FinishRegistrationInput{
+				Challenge:         options.Challenge,
+				CredentialID:      response.CredentialID,
+				ClientDataJSON:    response.ClientDataJSON,
+				AttestationObject: response.AttestationObject,
+			})
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestFinishRegistrationAttestationFormat(t *testing.T) {
+	w := newTestWebAuthn()
+	a := NewSoftwareAuthenticator(NewSoftwareAuthenticatorOptions{
+		RPID:   "example.com",
+		Origin: "https://example.com",
+	})
+	options, _ := w.BeginRegistration(User{ID: []byte("1")}, nil)
+	response, _ := a.CreateCredential(options.Challenge, []byte("1"))
+
+	// Re-encode the attestation object as "packed"
+	item, _, _ := decodeCBOR(response.AttestationObject)
+	authData := item.(map[interface{}]interface{})["authData"].([]byte)
+	attestationObject, _ := encodeCBOR([]cborPair{
+		{Key: "fmt", Value: "packed"},
+		{Key: "attStmt", Value: []cborPair{{Key: "alg", Value: AlgES256}}},
+		{Key: "authData", Value: authData},
+	})
+
+	_, err := w.FinishRegistration(FinishRegistrationInput{
+		Challenge:         options.Challenge,
+		CredentialID:      response.CredentialID,
+		ClientDataJSON:    response.ClientDataJSON,
+		AttestationObject: attestationObject,
+	})
+	if !errors.Is(err, ErrUnsupportedAttestation) {
+		t.Errorf("Expected %v, got %v", ErrUnsupportedAttestation, err)
+	}
+
+	// The credential id must be the one of the authenticator data
+	_, err = w.FinishRegistration(FinishRegistrationInput{
+		Challenge:         options.Challenge,
+		CredentialID:      []byte("other"),
+		ClientDataJSON:    response.ClientDataJSON,
+		AttestationObject: response.AttestationObject,
+	})
+	if !errors.Is(err, ErrCredentialMismatch) {
+		t.Errorf("Expected %v, got %v", ErrCredentialMismatch, err)
+	}
+}
@@ -0,0 +1,14 @@
+// This file contains the interfaces for the webauthn layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package webauthn
+
+type WebAuthnInterface interface {
+	BeginRegistration(user User, exclude [][]byte) (CreationOptions, error)
+	FinishRegistration(input FinishRegistrationInput) (Credential, error)
+	BeginLogin(allow [][]byte) (RequestOptions, error)
+	FinishLogin(input FinishLoginInput) (FinishLoginOutput, error)
+	// ChallengeOf returns the challenge signed by the authenticator, to look
+	// up the pending ceremony.
+	ChallengeOf(clientDataJSON []byte) (string, error)
+}
@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: webauthn/interfaces.go
+
+// Package webauthn is a generated GoMock package.
+package webauthn
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWebAuthnInterface is a mock of WebAuthnInterface interface.
+type MockWebAuthnInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnInterfaceMockRecorder
+}
+
+// MockWebAuthnInterfaceMockRecorder is the mock recorder for MockWebAuthnInterface.
+type MockWebAuthnInterfaceMockRecorder struct {
+	mock *MockWebAuthnInterface
+}
+
+// NewMockWebAuthnInterface creates a new mock instance.
+func NewMockWebAuthnInterface(ctrl *gomock.Controller) *MockWebAuthnInterface {
+	mock := &MockWebAuthnInterface{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnInterface) EXPECT() *MockWebAuthnInterfaceMockRecorder {
+	return m.recorder
+}
+
+// BeginLogin mocks base method.
+func (m *MockWebAuthnInterface) BeginLogin(allow [][]byte) (RequestOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLogin", allow)
+	ret0, _ := ret[0].(RequestOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginLogin indicates an expected call of BeginLogin.
+func (mr *MockWebAuthnInterfaceMockRecorder) BeginLogin(allow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLogin", reflect.TypeOf((*MockWebAuthnInterface)(nil).BeginLogin), allow)
+}
+
+// BeginRegistration mocks base method.
+func (m *MockWebAuthnInterface) BeginRegistration(user User, exclude [][]byte) (CreationOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRegistration", user, exclude)
+	ret0, _ := ret[0].(CreationOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRegistration indicates an expected call of BeginRegistration.
+func (mr *MockWebAuthnInterfaceMockRecorder) BeginRegistration(user, exclude interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRegistration", reflect.TypeOf((*MockWebAuthnInterface)(nil).BeginRegistration), user, exclude)
+}
+
+// ChallengeOf mocks base method.
+func (m *MockWebAuthnInterface) ChallengeOf(clientDataJSON []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChallengeOf", clientDataJSON)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChallengeOf indicates an expected call of ChallengeOf.
+func (mr *MockWebAuthnInterfaceMockRecorder) ChallengeOf(clientDataJSON interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChallengeOf", reflect.TypeOf((*MockWebAuthnInterface)(nil).ChallengeOf), clientDataJSON)
+}
+
+// FinishLogin mocks base method.
+func (m *MockWebAuthnInterface) FinishLogin(input FinishLoginInput) (FinishLoginOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", input)
+	ret0, _ := ret[0].(FinishLoginOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockWebAuthnInterfaceMockRecorder) FinishLogin(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockWebAuthnInterface)(nil).FinishLogin), input)
+}
+
+// FinishRegistration mocks base method.
+func (m *MockWebAuthnInterface) FinishRegistration(input FinishRegistrationInput) (Credential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishRegistration", input)
+	ret0, _ := ret[0].(Credential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishRegistration indicates an expected call of FinishRegistration.
+func (mr *MockWebAuthnInterfaceMockRecorder) FinishRegistration(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishRegistration", reflect.TypeOf((*MockWebAuthnInterface)(nil).FinishRegistration), input)
+}
@@ -0,0 +1,115 @@
+package webauthn
+
+import (
+	"errors"
+	"time"
+)
+
+// COSE algorithm identifiers of the supported credential keys
+const (
+	AlgES256 = -7
+	AlgRS256 = -257
+)
+
+var (
+	ErrInvalidClientData        = errors.New("invalid client data")
+	ErrChallengeMismatch        = errors.New("challenge mismatch")
+	ErrOriginNotAllowed         = errors.New("origin not allowed")
+	ErrInvalidAuthenticatorData = errors.New("invalid authenticator data")
+	ErrRPIDMismatch             = errors.New("relying party id mismatch")
+	ErrUserNotPresent           = errors.New("user not present")
+	ErrUnsupportedAttestation   = errors.New("unsupported attestation format")
+	ErrUnsupportedAlgorithm     = errors.New("unsupported public key algorithm")
+	ErrCredentialMismatch       = errors.New("credential id mismatch")
+	ErrInvalidSignature         = errors.New("invalid signature")
+	// ErrSignCountInvalid means the counter did not increase, the
+	// authenticator may have been cloned.
+	ErrSignCountInvalid = errors.New("sign count did not increase")
+)
+
+type User struct {
+	// ID is the user handle, it must not contain personal information
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+type CreationOptions struct {
+	Challenge  string
+	RPID       string
+	RPName     string
+	User       User
+	Algorithms []int
+	Timeout    time.Duration
+	// ExcludeCredentials are the credential ids already registered
+	ExcludeCredentials [][]byte
+}
+
+type RequestOptions struct {
+	Challenge string
+	RPID      string
+	Timeout   time.Duration
+	// AllowCredentials is empty for discoverable credentials (passkeys)
+	AllowCredentials [][]byte
+}
+
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE encoded public key
+	PublicKey []byte
+	SignCount uint32
+	AAGUID    []byte
+}
+
+type FinishRegistrationInput struct {
+	Challenge         string
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+type FinishLoginInput struct {
+	Challenge         string
+	Credential        Credential
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+}
+
+type FinishLoginOutput struct {
+	SignCount uint32
+}
+
+// AttestationResponse is what the authenticator returns to
+// navigator.credentials.create()
+type AttestationResponse struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// AssertionResponse is what the authenticator returns to
+// navigator.credentials.get()
+type AssertionResponse struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+	// Set when the attested credential data flag is
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
@@ -0,0 +1,50 @@
+// This file contains the WebAuthn relying party used for passkey
+// registration and login. Only the "none" attestation format is accepted,
+// the service trusts any authenticator and relies on the user being
+// verified by it.
+package webauthn
+
+import (
+	"strings"
+	"time"
+)
+
+const DefaultTimeout = time.Minute * 5
+
+type WebAuthn struct {
+	RPID    string
+	RPName  string
+	Origins map[string]bool
+	Timeout time.Duration
+}
+
+type NewWebAuthnOptions struct {
+	// RPID is the domain the credentials are scoped to, e.g. "example.com"
+	RPID   string
+	RPName string
+	// Origins allowed in the client data, e.g. "https://example.com"
+	Origins []string
+	Timeout time.Duration
+}
+
+func NewWebAuthn(opts NewWebAuthnOptions) *WebAuthn {
+	w := &WebAuthn{
+		RPID:    opts.RPID,
+		RPName:  opts.RPName,
+		Origins: map[string]bool{},
+		Timeout: opts.Timeout,
+	}
+	for _, origin := range opts.Origins {
+		origin = strings.TrimSpace(origin)
+		if origin != "" {
+			w.Origins[origin] = true
+		}
+	}
+	if w.RPName == "" {
+		w.RPName = w.RPID
+	}
+	if w.Timeout == 0 {
+		w.Timeout = DefaultTimeout
+	}
+	return w
+}
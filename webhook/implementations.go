@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/asrul10/UserService/events"
+	"github.com/asrul10/UserService/repository"
+)
+
+const signaturePrefix = "sha256="
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredTimestamp = errors.New("webhook timestamp outside of the tolerance")
+)
+
+// Sign returns the signature header of a delivery body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a delivery the way receivers should. The
+// timestamp must be within tolerance of now, so a captured delivery can't
+// be replayed later.
+func Verify(secret string, timestamp string, body []byte, signature string, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if diff := now.Sub(time.Unix(ts, 0)); diff > tolerance || diff < -tolerance {
+		return ErrExpiredTimestamp
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Publish queues the deliveries of the event, the dispatcher sends them
+func (f *Fanout) Publish(ctx context.Context, event events.Event) error {
+	_, err := f.Repository.CreateWebhookDeliveries(ctx, repository.CreateWebhookDeliveriesInput{
+		Event: event,
+	})
+	return err
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		// A claimed batch is attempted to the end on shutdown, cancelling
+		// it would leave its deliveries leased until the lease expires
+		if _, err := d.Dispatch(context.WithoutCancel(ctx)); err != nil {
+			d.Logger.ErrorContext(ctx, "Failed to dispatch webhooks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch claims a batch of due deliveries and attempts them one by one.
+// A delivery out of attempts is dead, it is only sent again when replayed.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	resp, err := d.Repository.ClaimWebhookDeliveries(ctx, repository.ClaimWebhookDeliveriesInput{
+		Lease: d.Lease,
+		Limit: d.BatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range resp.Deliveries {
+		record := repository.RecordWebhookDeliveryInput{
+			Id:            delivery.Id,
+			Status:        repository.WebhookDeliveryDelivered,
+			NextAttemptAt: time.Now(),
+		}
+		record.StatusCode, err = d.deliver(ctx, delivery)
+		if err != nil {
+			record.Error = err.Error()
+			record.Status = repository.WebhookDeliveryPending
+			record.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+			if delivery.Attempts >= d.MaxAttempts {
+				record.Status = repository.WebhookDeliveryDead
+				d.Logger.WarnContext(ctx, "Webhook delivery is dead", "delivery_id", delivery.Id, "attempts", delivery.Attempts, "error", err)
+			}
+		} else {
+			delivered++
+		}
+
+		// The delivery is attempted again after the lease if this fails
+		if _, err := d.Repository.RecordWebhookDelivery(ctx, record); err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery repository.WebhookDelivery) (int, error) {
+	secret, err := d.Cipher.Decrypt(delivery.EncryptedSecret)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryId, strconv.Itoa(delivery.Id))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, delivery.Payload))
+	req.Header.Set(events.HeaderEventId, strconv.Itoa(delivery.EventId))
+	req.Header.Set(events.HeaderEventType, delivery.EventType)
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.MinBackoff
+	for i := 1; i < attempts && backoff < d.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.MaxBackoff {
+		backoff = d.MaxBackoff
+	}
+	return backoff
+}
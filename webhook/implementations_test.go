@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asrul10/UserService/encryption"
+	"github.com/asrul10/UserService/events"
+	"github.com/asrul10/UserService/repository"
+	"github.com/golang/mock/gomock"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":1}`)
+	signature := Sign("secret", now.Unix(), body)
+
+	tests := []struct {
+		caseName      string
+		timestamp     string
+		body          []byte
+		signature     string
+		expectedError error
+	}{
+		{
+			caseName:      "Valid signature",
+			timestamp:     "1700000000",
+			body:          body,
+			signature:     signature,
+			expectedError: nil,
+		},
+		{
+			caseName:      "Changed body",
+			timestamp:     "1700000000",
+			body:          []byte(`{"id":2}`),
+			signature:     signature,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			caseName:      "Changed timestamp",
+			timestamp:     "1700000001",
+			body:          body,
+			signature:     signature,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			caseName:      "Old delivery",
+			timestamp:     "1699990000",
+			body:          body,
+			signature:     Sign("secret", 1699990000, body),
+			expectedError: ErrExpiredTimestamp,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.caseName, func(t *testing.T) {
+			err := Verify("secret", test.timestamp, test.body, test.signature, 5*time.Minute, now)
+			if err != test.expectedError {
+				t.Errorf("Expected %v, got %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cipher, _ := encryption.NewCipher(encryption.NewCipherOptions{
+		Key: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", encryption.KeySize))),
+	})
+	encryptedSecret, _ := cipher.Encrypt("secret")
+
+	// The receiver fails the deliveries of user.logged_in
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := Verify("secret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature), time.Minute, time.Now())
+		if err != nil {
+			t.Errorf("Expected a valid signature, got %s", err.Error())
+		}
+		if r.Header.Get(events.HeaderEventType) == events.TypeUserLoggedIn {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	m := repository.NewMockRepositoryInterface(ctrl)
+	d := NewDispatcher(NewDispatcherOptions{
+		Repository:  m,
+		Cipher:      cipher,
+		MinBackoff:  time.Minute,
+		MaxAttempts: 3,
+	})
+
+	delivery := func(id int, eventType string, attempts int) repository.WebhookDelivery {
+		return repository.WebhookDelivery{
+			Id:              id,
+			EventId:         id,
+			EventType:       eventType,
+			Payload:         []byte(`{"id":1}`),
+			Attempts:        attempts,
+			URL:             receiver.URL,
+			EncryptedSecret: encryptedSecret,
+		}
+	}
+	m.
+		EXPECT().
+		ClaimWebhookDeliveries(gomock.Any(), repository.ClaimWebhookDeliveriesInput{Lease: DefaultLease, Limit: DefaultBatchSize}).
+		Return(repository.ClaimWebhookDeliveriesOutput{
+			Deliveries: []repository.WebhookDelivery{
+				delivery(1, events.TypeUserRegistered, 1),
+				delivery(2, events.TypeUserLoggedIn, 2),
+				delivery(3, events.TypeUserLoggedIn, 3),
+			},
+		}, nil)
+
+	recorded := map[int]repository.RecordWebhookDeliveryInput{}
+	m.
+		EXPECT().
+		RecordWebhookDelivery(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input repository.RecordWebhookDeliveryInput) (repository.RecordWebhookDeliveryOutput, error) {
+			recorded[input.Id] = input
+			return repository.RecordWebhookDeliveryOutput{Id: input.Id}, nil
+		}).
+		Times(3)
+
+	delivered, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Errorf("Expected nil, got %s", err.Error())
+	}
+	if delivered != 1 {
+		t.Errorf("Expected 1 delivered, got %d", delivered)
+	}
+
+	if r := recorded[1]; r.Status != repository.WebhookDeliveryDelivered || r.StatusCode != http.StatusOK {
+		t.Errorf("Expected delivery 1 delivered, got %v", r)
+	}
+	// Second attempt waits twice the minimum
+	if r := recorded[2]; r.Status != repository.WebhookDeliveryPending || r.StatusCode != http.StatusServiceUnavailable ||
+		time.Until(r.NextAttemptAt) < time.Minute || time.Until(r.NextAttemptAt) > 2*time.Minute {
+		t.Errorf("Expected delivery 2 retried in 2 minutes, got %v", r)
+	}
+	if r := recorded[3]; r.Status != repository.WebhookDeliveryDead || r.Error == "" {
+		t.Errorf("Expected delivery 3 dead, got %v", r)
+	}
+}
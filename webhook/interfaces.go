@@ -0,0 +1,14 @@
+// This file contains the interfaces for the webhook layer.
+// For testing purpose we will generate mock implementations of these
+// interfaces using mockgen. See the Makefile for more information.
+package webhook
+
+import "context"
+
+type DispatcherInterface interface {
+	// Run dispatches the deliveries until the context is done
+	Run(ctx context.Context)
+	// Dispatch attempts the deliveries due once and returns how many were
+	// delivered
+	Dispatch(ctx context.Context) (int, error)
+}
@@ -0,0 +1,62 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: webhook/interfaces.go
+
+// Package webhook is a generated GoMock package.
+package webhook
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDispatcherInterface is a mock of DispatcherInterface interface.
+type MockDispatcherInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDispatcherInterfaceMockRecorder
+}
+
+// MockDispatcherInterfaceMockRecorder is the mock recorder for MockDispatcherInterface.
+type MockDispatcherInterfaceMockRecorder struct {
+	mock *MockDispatcherInterface
+}
+
+// NewMockDispatcherInterface creates a new mock instance.
+func NewMockDispatcherInterface(ctrl *gomock.Controller) *MockDispatcherInterface {
+	mock := &MockDispatcherInterface{ctrl: ctrl}
+	mock.recorder = &MockDispatcherInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDispatcherInterface) EXPECT() *MockDispatcherInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Dispatch mocks base method.
+func (m *MockDispatcherInterface) Dispatch(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dispatch", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Dispatch indicates an expected call of Dispatch.
+func (mr *MockDispatcherInterfaceMockRecorder) Dispatch(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockDispatcherInterface)(nil).Dispatch), ctx)
+}
+
+// Run mocks base method.
+func (m *MockDispatcherInterface) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockDispatcherInterfaceMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockDispatcherInterface)(nil).Run), ctx)
+}
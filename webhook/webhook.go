@@ -0,0 +1,130 @@
+// This file contains the webhooks of partner services. The fanout queues a
+// delivery of every published event for each subscription to its type, the
+// dispatcher posts the deliveries signed with the secret of the
+// subscription and retries them until they run out of attempts.
+package webhook
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/asrul10/UserService/encryption"
+	"github.com/asrul10/UserService/repository"
+)
+
+const (
+	DefaultInterval    = time.Second
+	DefaultBatchSize   = 50
+	DefaultLease       = time.Minute
+	DefaultTimeout     = time.Second * 10
+	DefaultMinBackoff  = time.Second * 30
+	DefaultMaxBackoff  = time.Hour
+	DefaultMaxAttempts = 12
+)
+
+// Headers of the deliveries
+const (
+	HeaderDeliveryId = "X-Webhook-Delivery-Id"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	// HeaderSignature is "sha256=" followed by the hex encoded HMAC-SHA256
+	// of the timestamp, a dot and the body, keyed with the secret of the
+	// subscription
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+type Fanout struct {
+	Repository repository.RepositoryInterface
+}
+
+type NewFanoutOptions struct {
+	Repository repository.RepositoryInterface
+}
+
+func NewFanout(opts NewFanoutOptions) *Fanout {
+	return &Fanout{
+		Repository: opts.Repository,
+	}
+}
+
+type Dispatcher struct {
+	Repository  repository.RepositoryInterface
+	Cipher      encryption.CipherInterface
+	Logger      *slog.Logger
+	Client      *http.Client
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	MaxAttempts int
+}
+
+type NewDispatcherOptions struct {
+	Repository repository.RepositoryInterface
+	// Cipher decrypts the secrets of the subscriptions
+	Cipher encryption.CipherInterface
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+	// Interval between two polls when no delivery is due, defaults to
+	// DefaultInterval
+	Interval time.Duration
+	// BatchSize is the number of deliveries claimed at once, defaults to
+	// DefaultBatchSize
+	BatchSize int
+	// Lease is how long claimed deliveries are hidden from other
+	// dispatchers, defaults to DefaultLease. It must be longer than a
+	// batch of timed out deliveries takes.
+	Lease time.Duration
+	// Timeout of a delivery, defaults to DefaultTimeout
+	Timeout time.Duration
+	// A failed delivery is retried after MinBackoff, doubled for every
+	// further failure up to MaxBackoff. They default to DefaultMinBackoff
+	// and DefaultMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts before a delivery is dead, defaults to
+	// DefaultMaxAttempts
+	MaxAttempts int
+}
+
+func NewDispatcher(opts NewDispatcherOptions) *Dispatcher {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	d := &Dispatcher{
+		Repository:  opts.Repository,
+		Cipher:      opts.Cipher,
+		Logger:      opts.Logger,
+		Client:      &http.Client{Timeout: timeout},
+		Interval:    opts.Interval,
+		BatchSize:   opts.BatchSize,
+		Lease:       opts.Lease,
+		MinBackoff:  opts.MinBackoff,
+		MaxBackoff:  opts.MaxBackoff,
+		MaxAttempts: opts.MaxAttempts,
+	}
+	if d.Logger == nil {
+		d.Logger = slog.Default()
+	}
+	if d.Interval <= 0 {
+		d.Interval = DefaultInterval
+	}
+	if d.BatchSize <= 0 {
+		d.BatchSize = DefaultBatchSize
+	}
+	if d.Lease <= 0 {
+		d.Lease = DefaultLease
+	}
+	if d.MinBackoff <= 0 {
+		d.MinBackoff = DefaultMinBackoff
+	}
+	if d.MaxBackoff <= 0 {
+		d.MaxBackoff = DefaultMaxBackoff
+	}
+	if d.MaxAttempts <= 0 {
+		d.MaxAttempts = DefaultMaxAttempts
+	}
+	return d
+}